@@ -4,10 +4,12 @@ go 1.23.0
 
 require (
 	github.com/go-chi/chi/v5 v5.2.1
+	github.com/golang/snappy v1.0.0
 	github.com/jackc/pgx/v5 v5.7.2
 	github.com/spf13/cobra v1.9.1
 	github.com/spf13/viper v1.20.0
 	go.uber.org/zap v1.27.0
+	google.golang.org/protobuf v1.36.5
 )
 
 require (
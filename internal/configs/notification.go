@@ -0,0 +1,219 @@
+package configs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"regexp"
+)
+
+// Default grouping settings used when the notification config omits them.
+const (
+	DefaultGroupWait      = 30    // Seconds
+	DefaultGroupInterval  = 300   // Seconds
+	DefaultRepeatInterval = 14400 // Seconds
+)
+
+// DefaultGroupBy groups alerts of the same rule together.
+var DefaultGroupBy = []string{"rule"}
+
+// NotificationConfig controls how alert notifications are grouped, deduplicated and inhibited.
+type NotificationConfig struct {
+	GroupBy        []string      `json:"group_by"`        // Label names; "metric_prefix" groups by the metric name prefix
+	GroupWait      int64         `json:"group_wait"`      // Seconds to wait for more alerts before the first notification of a group
+	GroupInterval  int64         `json:"group_interval"`  // Seconds between notifications about changes in a group
+	RepeatInterval int64         `json:"repeat_interval"` // Seconds before still firing alerts are notified again
+	InhibitRules   []InhibitRule `json:"inhibit_rules"`
+	Route          *Route        `json:"route"` // Without a route every alert is sent to all receivers
+
+	EscalationPolicies []EscalationPolicy `json:"escalation_policies"` // The first policy matching an alert applies
+
+	Receivers []Receiver `json:"receivers"` // Named receivers besides the webhook and email receivers of the server flags
+}
+
+// Receiver is a named notification channel with its own settings, for routes and escalation
+// policies to address by name. Exactly one of Webhook and Email is set.
+type Receiver struct {
+	Name    string           `json:"name"`
+	Webhook *WebhookReceiver `json:"webhook,omitempty"`
+	Email   *EmailReceiver   `json:"email,omitempty"`
+}
+
+// WebhookReceiver posts the alerts to a URL, signed with the secret when one is set.
+type WebhookReceiver struct {
+	URL      string `json:"url"`
+	Secret   string `json:"secret,omitempty"`
+	Template string `json:"template,omitempty"` // Defaults to the JSON payload
+}
+
+// EmailReceiver mails the alerts through an SMTP server.
+type EmailReceiver struct {
+	Host     string   `json:"host"`
+	Port     string   `json:"port"`
+	Username string   `json:"username,omitempty"`
+	Password string   `json:"password,omitempty"`
+	From     string   `json:"from"`
+	To       []string `json:"to"`
+	Template string   `json:"template,omitempty"`
+}
+
+// EscalationPolicy notifies additional receivers while a matching alert keeps firing unacknowledged.
+type EscalationPolicy struct {
+	Name  string            `json:"name"`
+	Match map[string]string `json:"match"` // Label values that must be equal; empty matches every alert
+	Steps []EscalationStep  `json:"steps"`
+}
+
+// EscalationStep is taken once the alert has been firing for After seconds.
+type EscalationStep struct {
+	After     int64    `json:"after"`
+	Receivers []string `json:"receivers"`
+}
+
+// Route is a node of the routing tree deciding which receiver gets an alert. An alert descends into
+// the first child route whose matchers all hold, and into the following matching siblings as long
+// as the matched routes have Continue set. It is delivered to the receivers of the deepest routes
+// it reaches; routes without a receiver inherit the receiver of their parent.
+type Route struct {
+	Receiver string            `json:"receiver"`
+	Match    map[string]string `json:"match"`    // Label values that must be equal
+	MatchRe  map[string]string `json:"match_re"` // Regular expressions the whole label value must match
+	Continue bool              `json:"continue"` // Keep matching the following sibling routes
+	Routes   []*Route          `json:"routes"`
+}
+
+// InhibitRule mutes alerts matching TargetMatch while an alert matching SourceMatch
+// is firing and both alerts have the same values of the Equal labels.
+type InhibitRule struct {
+	SourceMatch map[string]string `json:"source_match"`
+	TargetMatch map[string]string `json:"target_match"`
+	Equal       []string          `json:"equal"`
+}
+
+func NewNotificationConfig() *NotificationConfig {
+	return &NotificationConfig{
+		GroupBy:        DefaultGroupBy,
+		GroupWait:      DefaultGroupWait,
+		GroupInterval:  DefaultGroupInterval,
+		RepeatInterval: DefaultRepeatInterval,
+	}
+}
+
+// LoadNotificationConfig reads the JSON notification config, filling omitted settings with defaults.
+// An empty path returns the default config.
+func LoadNotificationConfig(path string) (*NotificationConfig, error) {
+	config := NewNotificationConfig()
+	if path == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read notification config: %v", err)
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse notification config: %v", err)
+	}
+
+	if len(config.GroupBy) == 0 {
+		config.GroupBy = DefaultGroupBy
+	}
+	if config.GroupWait < 0 || config.GroupInterval <= 0 || config.RepeatInterval <= 0 {
+		return nil, fmt.Errorf("invalid notification config: group_wait must not be negative, group_interval and repeat_interval must be positive")
+	}
+	if config.Route != nil {
+		if config.Route.Receiver == "" {
+			return nil, fmt.Errorf("invalid notification config: the root route must have a default receiver")
+		}
+		if err := validateRoute(config.Route); err != nil {
+			return nil, fmt.Errorf("invalid notification config: %v", err)
+		}
+	}
+
+	for _, policy := range config.EscalationPolicies {
+		if err := validateEscalationPolicy(policy); err != nil {
+			return nil, fmt.Errorf("invalid notification config: %v", err)
+		}
+	}
+
+	names := make(map[string]bool)
+	for _, receiver := range config.Receivers {
+		if err := validateReceiver(receiver); err != nil {
+			return nil, fmt.Errorf("invalid notification config: %v", err)
+		}
+		if names[receiver.Name] {
+			return nil, fmt.Errorf("invalid notification config: duplicate receiver %q", receiver.Name)
+		}
+		names[receiver.Name] = true
+	}
+
+	return config, nil
+}
+
+// validateReceiver checks that the receiver has a name and the required settings of exactly one channel.
+func validateReceiver(receiver Receiver) error {
+	if receiver.Name == "" {
+		return fmt.Errorf("receiver without a name")
+	}
+
+	switch {
+	case receiver.Webhook != nil && receiver.Email != nil:
+		return fmt.Errorf("receiver %q has both webhook and email settings", receiver.Name)
+	case receiver.Webhook != nil:
+		if receiver.Webhook.URL == "" {
+			return fmt.Errorf("receiver %q: missing webhook url", receiver.Name)
+		}
+	case receiver.Email != nil:
+		if receiver.Email.Host == "" || receiver.Email.Port == "" {
+			return fmt.Errorf("receiver %q: missing smtp host or port", receiver.Name)
+		}
+		if receiver.Email.From == "" || len(receiver.Email.To) == 0 {
+			return fmt.Errorf("receiver %q: missing email sender or recipients", receiver.Name)
+		}
+	default:
+		return fmt.Errorf("receiver %q has neither webhook nor email settings", receiver.Name)
+	}
+	return nil
+}
+
+// validateEscalationPolicy checks that the steps have receivers and strictly increasing timeouts.
+func validateEscalationPolicy(policy EscalationPolicy) error {
+	if len(policy.Steps) == 0 {
+		return fmt.Errorf("escalation policy %q has no steps", policy.Name)
+	}
+
+	var after int64
+	for i, step := range policy.Steps {
+		if step.After <= after {
+			return fmt.Errorf("escalation policy %q: step %d must come after %d seconds", policy.Name, i, after)
+		}
+		if len(step.Receivers) == 0 {
+			return fmt.Errorf("escalation policy %q: step %d has no receivers", policy.Name, i)
+		}
+		after = step.After
+	}
+	return nil
+}
+
+// validateRoute checks the regular expressions of the route and its children.
+func validateRoute(route *Route) error {
+	for label, expr := range route.MatchRe {
+		if _, err := regexp.Compile(AnchorRegexp(expr)); err != nil {
+			return fmt.Errorf("route match_re %s: %v", label, err)
+		}
+	}
+	for _, child := range route.Routes {
+		if child == nil {
+			return fmt.Errorf("empty route")
+		}
+		if err := validateRoute(child); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// AnchorRegexp makes the expression match whole label values only.
+func AnchorRegexp(expr string) string {
+	return "^(?:" + expr + ")$"
+}
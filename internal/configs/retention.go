@@ -0,0 +1,173 @@
+package configs
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// Default retention applied when the retention config omits it. Hourly rollups are kept forever.
+var (
+	DefaultRawRetention    = Duration(7 * 24 * time.Hour)
+	DefaultRollupRetention = map[Duration]Duration{Duration(time.Minute): Duration(90 * 24 * time.Hour)}
+)
+
+// RetentionConfig controls how long the history of the metrics is kept.
+type RetentionConfig struct {
+	Default RetentionPolicy         `json:"default"`
+	Metrics []MetricRetentionPolicy `json:"metrics,omitempty"` // The first policy whose pattern matches the metric ID applies
+}
+
+// RetentionPolicy tells how long raw samples and the rollups of every resolution are kept.
+// A zero or omitted retention keeps the data forever.
+type RetentionPolicy struct {
+	Raw     Duration              `json:"raw,omitempty"`
+	Rollups map[Duration]Duration `json:"rollups,omitempty"` // By resolution, e.g. {"1m": "90d"}
+}
+
+// MetricRetentionPolicy overrides the default policy for the metrics whose ID matches the
+// glob pattern (e.g. "Heap*"). Omitted or zero retentions are taken from the default policy.
+type MetricRetentionPolicy struct {
+	Pattern string `json:"pattern"`
+	RetentionPolicy
+}
+
+// Duration is a time.Duration written as a Go duration ("36h") or a whole number of
+// days ("90d") or weeks ("2w").
+type Duration time.Duration
+
+// String formats the duration in the largest whole unit of weeks, days, hours or minutes.
+func (d Duration) String() string {
+	for _, unit := range []struct {
+		suffix string
+		length time.Duration
+	}{{"w", 7 * 24 * time.Hour}, {"d", 24 * time.Hour}, {"h", time.Hour}, {"m", time.Minute}} {
+		if d != 0 && time.Duration(d)%unit.length == 0 {
+			return strconv.FormatInt(int64(time.Duration(d)/unit.length), 10) + unit.suffix
+		}
+	}
+	if d == 0 {
+		return "0"
+	}
+	return time.Duration(d).String()
+}
+
+func (d Duration) MarshalText() ([]byte, error) {
+	return []byte(d.String()), nil
+}
+
+func (d *Duration) UnmarshalText(text []byte) error {
+	value := string(text)
+	for suffix, unit := range map[string]time.Duration{"d": 24 * time.Hour, "w": 7 * 24 * time.Hour} {
+		if number, found := strings.CutSuffix(value, suffix); found {
+			n, err := strconv.ParseInt(number, 10, 64)
+			if err != nil {
+				return fmt.Errorf("invalid duration %q", value)
+			}
+			*d = Duration(time.Duration(n) * unit)
+			return nil
+		}
+	}
+
+	parsed, err := time.ParseDuration(value)
+	if err != nil {
+		return fmt.Errorf("invalid duration %q", value)
+	}
+	*d = Duration(parsed)
+	return nil
+}
+
+func NewRetentionConfig() *RetentionConfig {
+	rollups := make(map[Duration]Duration, len(DefaultRollupRetention))
+	for resolution, retention := range DefaultRollupRetention {
+		rollups[resolution] = retention
+	}
+	return &RetentionConfig{
+		Default: RetentionPolicy{Raw: DefaultRawRetention, Rollups: rollups},
+	}
+}
+
+// LoadRetentionConfig reads the JSON retention config on top of the default retention.
+// An empty path returns the default config.
+func LoadRetentionConfig(configPath string) (*RetentionConfig, error) {
+	config := NewRetentionConfig()
+	if configPath == "" {
+		return config, nil
+	}
+
+	data, err := os.ReadFile(configPath)
+	if err != nil {
+		return nil, fmt.Errorf("failed to read retention config: %v", err)
+	}
+	if err := json.Unmarshal(data, config); err != nil {
+		return nil, fmt.Errorf("failed to parse retention config: %v", err)
+	}
+
+	if err := validateRetentionPolicy(config.Default); err != nil {
+		return nil, fmt.Errorf("invalid retention config: default: %v", err)
+	}
+	for _, policy := range config.Metrics {
+		if err := validateMetricRetentionPolicy(policy); err != nil {
+			return nil, fmt.Errorf("invalid retention config: %v", err)
+		}
+	}
+
+	return config, nil
+}
+
+// Policy returns the retention of the metric, completing the matching policy with the default.
+func (c *RetentionConfig) Policy(metricID string) RetentionPolicy {
+	policy := RetentionPolicy{Raw: c.Default.Raw, Rollups: make(map[Duration]Duration)}
+	for resolution, retention := range c.Default.Rollups {
+		policy.Rollups[resolution] = retention
+	}
+
+	for _, override := range c.Metrics {
+		if matched, _ := path.Match(override.Pattern, metricID); !matched {
+			continue
+		}
+		if override.Raw != 0 {
+			policy.Raw = override.Raw
+		}
+		for resolution, retention := range override.Rollups {
+			if retention != 0 {
+				policy.Rollups[resolution] = retention
+			}
+		}
+		break
+	}
+
+	return policy
+}
+
+func validateMetricRetentionPolicy(policy MetricRetentionPolicy) error {
+	if policy.Pattern == "" {
+		return fmt.Errorf("metric retention policy without a pattern")
+	}
+	if _, err := path.Match(policy.Pattern, ""); err != nil {
+		return fmt.Errorf("invalid pattern %q: %v", policy.Pattern, err)
+	}
+	if err := validateRetentionPolicy(policy.RetentionPolicy); err != nil {
+		return fmt.Errorf("pattern %q: %v", policy.Pattern, err)
+	}
+	return nil
+}
+
+func validateRetentionPolicy(policy RetentionPolicy) error {
+	if policy.Raw < 0 {
+		return fmt.Errorf("negative raw retention")
+	}
+	for resolution, retention := range policy.Rollups {
+		if resolution <= 0 {
+			return fmt.Errorf("invalid rollup resolution %s", resolution)
+		}
+		if retention < 0 {
+			return fmt.Errorf("negative retention of %s rollups", resolution)
+		}
+	}
+	return nil
+}
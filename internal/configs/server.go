@@ -1,11 +1,27 @@
 package configs
 
 type ServerConfig struct {
-	Address         string
-	DatabaseDSN     string
-	StoreInterval   string
-	FileStoragePath string
-	Restore         string
+	Address             string
+	DatabaseDSN         string
+	StoreInterval       string
+	FileStoragePath     string
+	Restore             string
+	AlertConfigPath     string
+	WebhookURL          string
+	WebhookSecret       string
+	WebhookTemplate     string
+	SMTPHost            string
+	SMTPPort            string
+	SMTPUsername        string
+	SMTPPassword        string
+	SMTPFrom            string
+	SMTPTo              string // Comma separated list of recipients
+	SMTPTemplate        string
+	InfluxIntegerType   string
+	InfluxFloatType     string
+	GraphiteAddress     string // Empty disables the Graphite listener
+	StatsDAddress       string // Empty disables the StatsD listener
+	RetentionConfigPath string
 }
 
 func NewServerConfig() *ServerConfig {
@@ -0,0 +1,122 @@
+package expressions
+
+import (
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/types"
+	"math"
+)
+
+// LookupFunc returns the current value of the referenced metric and whether it exists.
+type LookupFunc func(metric types.MetricID) (float64, bool)
+
+// ErrNoData is returned when a referenced metric has no value yet, or when the arithmetic
+// has no finite result, e.g. a division by a counter that is still zero.
+var ErrNoData = errors.New("no data")
+
+// Evaluate evaluates a parsed condition. The returned value is the left side of the
+// outermost comparison, or 1 and 0 for true and false of composite conditions.
+func Evaluate(node Node, lookup LookupFunc) (bool, float64, error) {
+	result, err := eval(node, lookup)
+	if err != nil {
+		return false, 0, err
+	}
+	matched := result != 0
+
+	if root, ok := node.(*BinaryExpr); ok && isComparison(root.Op) {
+		value, err := eval(root.Left, lookup)
+		return matched, value, err
+	}
+	if matched {
+		return true, 1, nil
+	}
+	return false, 0, nil
+}
+
+// eval computes the value of the node, representing conditions as 1 and 0.
+func eval(node Node, lookup LookupFunc) (float64, error) {
+	switch n := node.(type) {
+	case *NumberLiteral:
+		return n.Value, nil
+
+	case *MetricRef:
+		value, ok := lookup(n.Metric)
+		if !ok {
+			return 0, fmt.Errorf("%w for %s", ErrNoData, n.Metric.ID)
+		}
+		return value, nil
+
+	case *UnaryExpr:
+		operand, err := eval(n.Operand, lookup)
+		if err != nil {
+			return 0, err
+		}
+		if n.Op == "-" {
+			return -operand, nil
+		}
+		return boolValue(operand == 0), nil
+
+	case *BinaryExpr:
+		left, err := eval(n.Left, lookup)
+		if err != nil {
+			return 0, err
+		}
+
+		// Short circuit the logical operators
+		switch {
+		case n.Op == "and" && left == 0:
+			return 0, nil
+		case n.Op == "or" && left != 0:
+			return 1, nil
+		}
+
+		right, err := eval(n.Right, lookup)
+		if err != nil {
+			return 0, err
+		}
+		result := apply(n.Op, left, right)
+		if math.IsNaN(result) || math.IsInf(result, 0) {
+			return 0, fmt.Errorf("%w: %g %s %g is not finite", ErrNoData, left, n.Op, right)
+		}
+		return result, nil
+	}
+
+	return 0, fmt.Errorf("unknown expression node %T", node)
+}
+
+func apply(op string, left, right float64) float64 {
+	switch op {
+	case "+":
+		return left + right
+	case "-":
+		return left - right
+	case "*":
+		return left * right
+	case "/":
+		return left / right
+	case "%":
+		return math.Mod(left, right)
+	case ">":
+		return boolValue(left > right)
+	case ">=":
+		return boolValue(left >= right)
+	case "<":
+		return boolValue(left < right)
+	case "<=":
+		return boolValue(left <= right)
+	case "==":
+		return boolValue(left == right)
+	case "!=":
+		return boolValue(left != right)
+	case "and", "or":
+		return boolValue(right != 0)
+	}
+	return math.NaN()
+}
+
+func boolValue(b bool) float64 {
+	if b {
+		return 1
+	}
+	return 0
+}
@@ -0,0 +1,84 @@
+package expressions
+
+import (
+	"errors"
+	"go-metrics-alerting/internal/types"
+	"testing"
+)
+
+func TestEvaluate(t *testing.T) {
+	values := map[types.MetricID]float64{
+		{ID: "HeapAlloc"}:                  90,
+		{ID: "HeapSys"}:                    100,
+		{ID: "PollCount", Type: "counter"}: 0,
+	}
+	lookup := func(metric types.MetricID) (float64, bool) {
+		value, ok := values[metric]
+		return value, ok
+	}
+
+	tests := []struct {
+		name        string
+		input       string
+		wantMatched bool
+		wantValue   float64
+	}{
+		{name: "comparison returns the left side", input: "HeapAlloc / HeapSys > 0.8", wantMatched: true, wantValue: 0.9},
+		{name: "comparison not matched", input: "HeapAlloc - HeapSys >= 0", wantMatched: false, wantValue: -10},
+		{name: "modulo", input: "HeapAlloc % 7 == 6", wantMatched: true, wantValue: 6},
+		{name: "composite matched", input: "HeapAlloc > 50 and HeapSys < 200", wantMatched: true, wantValue: 1},
+		{name: "composite not matched", input: "HeapAlloc > 95 or HeapSys < 50", wantMatched: false, wantValue: 0},
+		{name: "not", input: "not HeapAlloc > 95", wantMatched: true, wantValue: 1},
+		{name: "and short circuits missing metrics", input: "HeapAlloc > 95 and Missing > 0", wantMatched: false, wantValue: 0},
+		{name: "or short circuits missing metrics", input: "HeapAlloc > 50 or Missing > 0", wantMatched: true, wantValue: 1},
+		{name: "typed reference", input: "counter:PollCount == 0", wantMatched: true, wantValue: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			matched, value, err := Evaluate(node, lookup)
+			if err != nil {
+				t.Fatalf("Evaluate() error = %v", err)
+			}
+			if matched != tt.wantMatched || value != tt.wantValue {
+				t.Errorf("Evaluate() = %v, %v, want %v, %v", matched, value, tt.wantMatched, tt.wantValue)
+			}
+		})
+	}
+}
+
+func TestEvaluateNoData(t *testing.T) {
+	lookup := func(metric types.MetricID) (float64, bool) {
+		if metric.ID == "Missing" {
+			return 0, false
+		}
+		return 0, true
+	}
+
+	tests := []struct {
+		name  string
+		input string
+	}{
+		{name: "missing metric", input: "Missing > 0"},
+		{name: "missing metric on the right", input: "Zero > 1 or Missing > 0"},
+		{name: "division by zero", input: "1 / Zero > 0"},
+		{name: "zero divided by zero", input: "Zero / Zero > 0"},
+		{name: "modulo by zero", input: "1 % Zero == 0"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if _, _, err := Evaluate(node, lookup); !errors.Is(err, ErrNoData) {
+				t.Errorf("Evaluate() error = %v, want %v", err, ErrNoData)
+			}
+		})
+	}
+}
@@ -0,0 +1,77 @@
+package expressions
+
+import (
+	"go-metrics-alerting/internal/scanner"
+	"unicode"
+)
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenOperator
+	tokenLParen
+	tokenRParen
+)
+
+type token struct {
+	kind tokenKind
+	text string
+	pos  int // Byte offset in the source
+}
+
+// Error is a syntax or type error pointing at a position in the expression.
+type Error = scanner.Error
+
+var errorf = scanner.Errorf
+
+// syntax describes the tokens of expressions. A metric reference may carry an explicit type,
+// e.g. gauge:HeapAlloc.
+var syntax = &scanner.Syntax{
+	Operators:   []string{"&&", "||", ">=", "<=", "==", "!=", ">", "<", "+", "-", "*", "/", "%", "!"},
+	Punctuation: "()",
+	Qualifier:   ':',
+	IdentStart:  isIdentStart,
+	IdentPart:   isIdentPart,
+}
+
+// punctuation maps single characters to their token kinds.
+var punctuation = map[string]tokenKind{
+	"(": tokenLParen,
+	")": tokenRParen,
+}
+
+// tokenize splits the expression into tokens.
+func tokenize(src string) ([]token, error) {
+	scanned, err := scanner.Scan(src, syntax)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]token, 0, len(scanned))
+	for _, tok := range scanned {
+		var kind tokenKind
+		switch tok.Kind {
+		case scanner.Number:
+			kind = tokenNumber
+		case scanner.Ident:
+			kind = tokenIdent
+		case scanner.Operator:
+			kind = tokenOperator
+		case scanner.Punct:
+			kind = punctuation[tok.Text]
+		}
+		tokens = append(tokens, token{kind: kind, text: tok.Text, pos: tok.Pos})
+	}
+	return tokens, nil
+}
+
+func isIdentStart(c rune) bool {
+	return unicode.IsLetter(c) || c == '_'
+}
+
+func isIdentPart(c rune) bool {
+	return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '.'
+}
@@ -0,0 +1,301 @@
+package expressions
+
+import (
+	"go-metrics-alerting/internal/types"
+	"strconv"
+	"strings"
+)
+
+// Node is a node of a parsed expression.
+type Node interface {
+	Pos() int
+	boolean() bool
+}
+
+// NumberLiteral is a numeric constant.
+type NumberLiteral struct {
+	Value  float64
+	Offset int
+}
+
+// MetricRef references the current value of a metric. An empty type matches a gauge first, then a counter.
+type MetricRef struct {
+	Metric types.MetricID
+	Offset int
+}
+
+// UnaryExpr is a negation ("-") or a logical not ("not", "!").
+type UnaryExpr struct {
+	Op      string
+	Operand Node
+	Offset  int
+}
+
+// BinaryExpr is an arithmetic, comparison or logical operation.
+type BinaryExpr struct {
+	Op          string
+	Left, Right Node
+	Offset      int
+}
+
+func (n *NumberLiteral) Pos() int { return n.Offset }
+func (n *MetricRef) Pos() int     { return n.Offset }
+func (n *UnaryExpr) Pos() int     { return n.Offset }
+func (n *BinaryExpr) Pos() int    { return n.Offset }
+
+func (n *NumberLiteral) boolean() bool { return false }
+func (n *MetricRef) boolean() bool     { return false }
+func (n *UnaryExpr) boolean() bool     { return n.Op == "not" }
+func (n *BinaryExpr) boolean() bool    { return isComparison(n.Op) || isLogical(n.Op) }
+
+// Parse parses a boolean alert expression such as "HeapAlloc / HeapSys > 0.9".
+func Parse(src string) (Node, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+
+	p := &parser{tokens: tokens}
+	node, err := p.parseOr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, errorf(tok.pos, "unexpected %q", tok.text)
+	}
+	if !node.boolean() {
+		return nil, errorf(node.Pos(), "expression must be a condition, e.g. a comparison")
+	}
+
+	return node, nil
+}
+
+// References returns all metrics referenced by the expression.
+func References(node Node) []types.MetricID {
+	switch n := node.(type) {
+	case *MetricRef:
+		return []types.MetricID{n.Metric}
+	case *UnaryExpr:
+		return References(n.Operand)
+	case *BinaryExpr:
+		return append(References(n.Left), References(n.Right)...)
+	}
+	return nil
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// operator returns the normalized operator of the current token or an empty string.
+func (p *parser) operator() string {
+	tok := p.peek()
+	switch {
+	case tok.kind == tokenOperator:
+		switch tok.text {
+		case "&&":
+			return "and"
+		case "||":
+			return "or"
+		case "!":
+			return "not"
+		}
+		return tok.text
+	case tok.kind == tokenIdent:
+		switch strings.ToLower(tok.text) {
+		case "and", "or", "not":
+			return strings.ToLower(tok.text)
+		}
+	}
+	return ""
+}
+
+func (p *parser) parseOr() (Node, error) {
+	return p.parseLogical("or", p.parseAnd)
+}
+
+func (p *parser) parseAnd() (Node, error) {
+	return p.parseLogical("and", p.parseNot)
+}
+
+// parseLogical parses a left associative chain of boolean operands.
+func (p *parser) parseLogical(op string, operand func() (Node, error)) (Node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for p.operator() == op {
+		tok := p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if !left.boolean() {
+			return nil, errorf(left.Pos(), "left operand of %q must be a condition", op)
+		}
+		if !right.boolean() {
+			return nil, errorf(right.Pos(), "right operand of %q must be a condition", op)
+		}
+		left = &BinaryExpr{Op: op, Left: left, Right: right, Offset: tok.pos}
+	}
+	return left, nil
+}
+
+func (p *parser) parseNot() (Node, error) {
+	if p.operator() == "not" {
+		tok := p.next()
+		operand, err := p.parseNot()
+		if err != nil {
+			return nil, err
+		}
+		if !operand.boolean() {
+			return nil, errorf(operand.Pos(), "operand of \"not\" must be a condition")
+		}
+		return &UnaryExpr{Op: "not", Operand: operand, Offset: tok.pos}, nil
+	}
+	return p.parseComparison()
+}
+
+func (p *parser) parseComparison() (Node, error) {
+	left, err := p.parseAdditive()
+	if err != nil {
+		return nil, err
+	}
+	if op := p.operator(); isComparison(op) {
+		tok := p.next()
+		right, err := p.parseAdditive()
+		if err != nil {
+			return nil, err
+		}
+		if left.boolean() || right.boolean() {
+			return nil, errorf(tok.pos, "cannot compare conditions with %q", op)
+		}
+		if next := p.operator(); isComparison(next) {
+			return nil, errorf(p.peek().pos, "comparisons cannot be chained, use \"and\"")
+		}
+		return &BinaryExpr{Op: op, Left: left, Right: right, Offset: tok.pos}, nil
+	}
+	return left, nil
+}
+
+func (p *parser) parseAdditive() (Node, error) {
+	return p.parseArithmetic([]string{"+", "-"}, p.parseMultiplicative)
+}
+
+func (p *parser) parseMultiplicative() (Node, error) {
+	return p.parseArithmetic([]string{"*", "/", "%"}, p.parseUnary)
+}
+
+// parseArithmetic parses a left associative chain of numeric operands.
+func (p *parser) parseArithmetic(ops []string, operand func() (Node, error)) (Node, error) {
+	left, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	for contains(ops, p.operator()) {
+		tok := p.next()
+		right, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		if left.boolean() || right.boolean() {
+			return nil, errorf(tok.pos, "arithmetic %q on a condition", tok.text)
+		}
+		left = &BinaryExpr{Op: tok.text, Left: left, Right: right, Offset: tok.pos}
+	}
+	return left, nil
+}
+
+func (p *parser) parseUnary() (Node, error) {
+	if p.operator() == "-" {
+		tok := p.next()
+		operand, err := p.parseUnary()
+		if err != nil {
+			return nil, err
+		}
+		if operand.boolean() {
+			return nil, errorf(operand.Pos(), "cannot negate a condition")
+		}
+		return &UnaryExpr{Op: "-", Operand: operand, Offset: tok.pos}, nil
+	}
+	return p.parsePrimary()
+}
+
+func (p *parser) parsePrimary() (Node, error) {
+	tok := p.next()
+	switch tok.kind {
+	case tokenNumber:
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, errorf(tok.pos, "invalid number %q", tok.text)
+		}
+		return &NumberLiteral{Value: value, Offset: tok.pos}, nil
+	case tokenIdent:
+		if isKeyword(tok.text) {
+			return nil, errorf(tok.pos, "unexpected %q", tok.text)
+		}
+		return parseMetricRef(tok)
+	case tokenLParen:
+		node, err := p.parseOr()
+		if err != nil {
+			return nil, err
+		}
+		if closing := p.next(); closing.kind != tokenRParen {
+			return nil, errorf(closing.pos, "expected \")\"")
+		}
+		return node, nil
+	case tokenEOF:
+		return nil, errorf(tok.pos, "unexpected end of expression")
+	}
+	return nil, errorf(tok.pos, "unexpected %q", tok.text)
+}
+
+// parseMetricRef parses "type:id" or a bare metric id.
+func parseMetricRef(tok token) (Node, error) {
+	ref := &MetricRef{Metric: types.MetricID{ID: tok.text}, Offset: tok.pos}
+	if metricType, id, found := strings.Cut(tok.text, ":"); found {
+		if metricType != string(types.Gauge) && metricType != string(types.Counter) {
+			return nil, errorf(tok.pos, "unknown metric type %q", metricType)
+		}
+		ref.Metric = types.MetricID{ID: id, Type: metricType}
+	}
+	return ref, nil
+}
+
+func isComparison(op string) bool {
+	return contains([]string{">", ">=", "<", "<=", "==", "!="}, op)
+}
+
+func isLogical(op string) bool {
+	return op == "and" || op == "or"
+}
+
+func isKeyword(text string) bool {
+	switch strings.ToLower(text) {
+	case "and", "or", "not":
+		return true
+	}
+	return false
+}
+
+func contains(items []string, item string) bool {
+	for _, candidate := range items {
+		if candidate == item {
+			return true
+		}
+	}
+	return false
+}
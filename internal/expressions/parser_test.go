@@ -0,0 +1,118 @@
+package expressions
+
+import (
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/types"
+	"reflect"
+	"testing"
+)
+
+// describe renders a parsed expression fully parenthesized for comparisons.
+func describe(node Node) string {
+	switch n := node.(type) {
+	case *NumberLiteral:
+		return fmt.Sprint(n.Value)
+	case *MetricRef:
+		if n.Metric.Type == "" {
+			return n.Metric.ID
+		}
+		return n.Metric.Type + ":" + n.Metric.ID
+	case *UnaryExpr:
+		return fmt.Sprintf("(%s %s)", n.Op, describe(n.Operand))
+	case *BinaryExpr:
+		return fmt.Sprintf("(%s %s %s)", describe(n.Left), n.Op, describe(n.Right))
+	}
+	return fmt.Sprintf("%T", node)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "comparison", input: "HeapAlloc > 100", want: "(HeapAlloc > 100)"},
+		{name: "ratio", input: "HeapAlloc / HeapSys > 0.9", want: "((HeapAlloc / HeapSys) > 0.9)"},
+		{name: "precedence", input: "a + b * c % 2 <= 10", want: "((a + ((b * c) % 2)) <= 10)"},
+		{name: "parentheses", input: "(a + b) * c == 1", want: "(((a + b) * c) == 1)"},
+		{name: "negation", input: "-a >= -1e3", want: "((- a) >= (- 1000))"},
+		{name: "typed references", input: "gauge:cpu.load > counter:PollCount", want: "(gauge:cpu.load > counter:PollCount)"},
+		{name: "and binds tighter than or", input: "a > 1 or b > 2 and c > 3", want: "((a > 1) or ((b > 2) and (c > 3)))"},
+		{name: "symbolic operators", input: "!(a > 1) && b < 2 || c != 0", want: "(((not (a > 1)) and (b < 2)) or (c != 0))"},
+		{name: "case insensitive keywords", input: "NOT a == 1 AND b == 2", want: "((not (a == 1)) and (b == 2))"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := describe(node); got != tt.want {
+				t.Errorf("Parse() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		pos   int
+	}{
+		{name: "empty expression", input: "", pos: 0},
+		{name: "not a condition", input: "a + b", pos: 2},
+		{name: "missing operand", input: "a >", pos: 3},
+		{name: "unclosed parenthesis", input: "a + (b > 1", pos: 10},
+		{name: "logical operand is not a condition", input: "a > 1 and 1", pos: 10},
+		{name: "chained comparison", input: "1 < 2 < 3", pos: 6},
+		{name: "comparison of conditions", input: "(a > 1) == (b > 1)", pos: 8},
+		{name: "arithmetic on a condition", input: "(a > 1) + 1 > 0", pos: 8},
+		{name: "negated condition", input: "-(a > 1)", pos: 4},
+		{name: "unknown character", input: "a $ b", pos: 2},
+		{name: "unknown metric type", input: "foo:x > 1", pos: 0},
+		{name: "keyword as a metric", input: "and > 1", pos: 0},
+		{name: "trailing tokens", input: "a > 1 b", pos: 6},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var parseErr *Error
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Parse() error = %v, want an *Error", err)
+			}
+			if parseErr.Pos != tt.pos {
+				t.Errorf("Error.Pos = %d, want %d (%v)", parseErr.Pos, tt.pos, err)
+			}
+		})
+	}
+}
+
+func TestReferences(t *testing.T) {
+	tests := []struct {
+		input string
+		want  []types.MetricID
+	}{
+		{input: "1 > 0", want: nil},
+		{input: "-a > 0", want: []types.MetricID{{ID: "a"}}},
+		{
+			input: "gauge:a / counter:b > 1 or not a == 0",
+			want:  []types.MetricID{{ID: "a", Type: "gauge"}, {ID: "b", Type: "counter"}, {ID: "a"}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := References(node); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("References() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
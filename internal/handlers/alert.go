@@ -0,0 +1,261 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/services"
+	"go-metrics-alerting/internal/types"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// AlertService defines methods for managing alert rules and inspecting alerts.
+type AlertService interface {
+	ListAlerts(ctx context.Context) ([]*types.Alert, error)
+	ListHistory(ctx context.Context, filter types.AlertHistoryFilter) ([]*types.AlertTransition, error)
+	AcknowledgeAlert(ctx context.Context, id string, ack *types.AlertAck, now time.Time) (*types.Alert, error)
+	CreateRule(ctx context.Context, rule *types.AlertRule) (*types.AlertRule, error)
+	UpdateRule(ctx context.Context, id string, rule *types.AlertRule) (*types.AlertRule, error)
+	GetRule(ctx context.Context, id string) (*types.AlertRule, error)
+	ListRules(ctx context.Context) ([]*types.AlertRule, error)
+	DeleteRule(ctx context.Context, id string) error
+}
+
+// DefaultAlertHistoryLimit is the page size of the alert history when no limit is given.
+const DefaultAlertHistoryLimit = 100
+
+// AlertHistoryPage is a page of the alert history log.
+type AlertHistoryPage struct {
+	Items      []*types.AlertTransition `json:"items"`
+	Limit      int                      `json:"limit"`
+	Offset     int                      `json:"offset"`
+	NextOffset *int                     `json:"next_offset,omitempty"`
+}
+
+// AlertHandler contains the reference to the alert service.
+type AlertHandler struct {
+	svc AlertService
+}
+
+// NewAlertHandler creates a new instance of AlertHandler.
+func NewAlertHandler(svc AlertService) *AlertHandler {
+	return &AlertHandler{svc: svc}
+}
+
+// CreateAlertRuleHandler creates a new alert rule from the request body.
+func (h *AlertHandler) CreateAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	var rule types.AlertRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		fmt.Printf("Error: Invalid input: %v\n", err)
+		return
+	}
+
+	created, err := h.svc.CreateRule(r.Context(), &rule)
+	if err != nil {
+		writeAlertRuleError(w, rule.ID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// ListAlertRulesHandler returns all alert rules as JSON.
+func (h *AlertHandler) ListAlertRulesHandler(w http.ResponseWriter, r *http.Request) {
+	rules, err := h.svc.ListRules(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to retrieve alert rules", http.StatusInternalServerError)
+		fmt.Printf("Error: Failed to retrieve alert rules: %v\n", err)
+		return
+	}
+	if rules == nil {
+		rules = []*types.AlertRule{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rules)
+}
+
+// GetAlertRuleHandler returns a single alert rule by its ID.
+func (h *AlertHandler) GetAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	rule, err := h.svc.GetRule(r.Context(), id)
+	if err != nil {
+		writeAlertRuleError(w, id, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(rule)
+}
+
+// UpdateAlertRuleHandler replaces an existing alert rule with the request body.
+func (h *AlertHandler) UpdateAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var rule types.AlertRule
+	if err := json.NewDecoder(r.Body).Decode(&rule); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		fmt.Printf("Error: Invalid input: %v\n", err)
+		return
+	}
+
+	updated, err := h.svc.UpdateRule(r.Context(), id, &rule)
+	if err != nil {
+		writeAlertRuleError(w, id, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(updated)
+}
+
+// DeleteAlertRuleHandler removes an alert rule by its ID.
+func (h *AlertHandler) DeleteAlertRuleHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := h.svc.DeleteRule(r.Context(), id); err != nil {
+		writeAlertRuleError(w, id, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// ListAlertsHandler returns the current state of all alerts as JSON.
+func (h *AlertHandler) ListAlertsHandler(w http.ResponseWriter, r *http.Request) {
+	alerts, err := h.svc.ListAlerts(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to retrieve alerts", http.StatusInternalServerError)
+		fmt.Printf("Error: Failed to retrieve alerts: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(alerts)
+}
+
+// AckAlertHandler acknowledges the firing alert of the rule, stopping its escalation.
+// The body names who acknowledged the alert and an optional comment.
+func (h *AlertHandler) AckAlertHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	var ack types.AlertAck
+	if err := json.NewDecoder(r.Body).Decode(&ack); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		fmt.Printf("Error: Invalid input: %v\n", err)
+		return
+	}
+
+	alert, err := h.svc.AcknowledgeAlert(r.Context(), id, &ack, time.Now())
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrInvalidAck):
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		case errors.Is(err, services.ErrAlertNotFound):
+			http.Error(w, "Alert not found", http.StatusNotFound)
+		case errors.Is(err, services.ErrAlertNotFiring):
+			http.Error(w, "Alert is not firing", http.StatusConflict)
+		default:
+			http.Error(w, "Internal server error", http.StatusInternalServerError)
+		}
+		fmt.Printf("Error: Alert %s: %v\n", id, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(alert)
+}
+
+// ListAlertHistoryHandler returns a page of alert transitions filtered by the
+// rule, since, until, limit and offset query parameters.
+func (h *AlertHandler) ListAlertHistoryHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+	filter := types.AlertHistoryFilter{
+		RuleID: query.Get("rule"),
+		Limit:  DefaultAlertHistoryLimit,
+	}
+
+	var err error
+	if value := query.Get("since"); value != "" {
+		if filter.Since, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, "Invalid since, expected RFC3339 time", http.StatusBadRequest)
+			fmt.Printf("Error: Invalid since: %s, %v\n", value, err)
+			return
+		}
+	}
+	if value := query.Get("until"); value != "" {
+		if filter.Until, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, "Invalid until, expected RFC3339 time", http.StatusBadRequest)
+			fmt.Printf("Error: Invalid until: %s, %v\n", value, err)
+			return
+		}
+	}
+	if value := query.Get("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "Invalid limit", http.StatusBadRequest)
+			fmt.Printf("Error: Invalid limit: %s, %v\n", value, err)
+			return
+		}
+	}
+	if value := query.Get("offset"); value != "" {
+		if filter.Offset, err = strconv.Atoi(value); err != nil {
+			http.Error(w, "Invalid offset", http.StatusBadRequest)
+			fmt.Printf("Error: Invalid offset: %s, %v\n", value, err)
+			return
+		}
+	}
+
+	transitions, err := h.svc.ListHistory(r.Context(), filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidHistoryFilter) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "Failed to retrieve alert history", http.StatusInternalServerError)
+		}
+		fmt.Printf("Error: Failed to retrieve alert history: %v\n", err)
+		return
+	}
+
+	page := AlertHistoryPage{
+		Items:  transitions,
+		Limit:  filter.Limit,
+		Offset: filter.Offset,
+	}
+	if len(transitions) == filter.Limit {
+		next := filter.Offset + filter.Limit
+		page.NextOffset = &next
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(page)
+}
+
+// writeAlertRuleError maps alert service errors to HTTP status codes.
+func writeAlertRuleError(w http.ResponseWriter, id string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidAlertRule):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrAlertRuleNotFound):
+		http.Error(w, "Alert rule not found", http.StatusNotFound)
+	case errors.Is(err, services.ErrAlertRuleExists):
+		http.Error(w, "Alert rule already exists", http.StatusConflict)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+	fmt.Printf("Error: Alert rule %s: %v\n", id, err)
+}
@@ -0,0 +1,72 @@
+package handlers
+
+import (
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/influx"
+	"go-metrics-alerting/internal/types"
+	"net/http"
+)
+
+// InfluxValueField is the field name that maps to the bare measurement name.
+const InfluxValueField = "value"
+
+// InfluxWriteHandler accepts InfluxDB line protocol. Every numeric or boolean field becomes a metric
+// named measurement_field (or just measurement for the "value" field) labeled with the tags.
+// Integer fields are stored as IntegerType and float fields as FloatType; counter fields are
+// running totals, as Telegraf reports them, so the counter grows by their increase. String fields
+// cannot be stored and are skipped. Line timestamps are dropped: metrics only keep their current
+// value, so the points are stored at the time they are received.
+func (h *IngestHandler) InfluxWriteHandler(w http.ResponseWriter, r *http.Request) {
+	data, ok := readLimitedBody(w, r)
+	if !ok {
+		return
+	}
+
+	lines, err := influx.Parse(data)
+	if err != nil {
+		var parseErr *influx.ParseError
+		if errors.As(err, &parseErr) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "Invalid line protocol", http.StatusBadRequest)
+		}
+		fmt.Printf("Error: Invalid line protocol: %v\n", err)
+		return
+	}
+
+	var points []*types.Point
+	for _, line := range lines {
+		for _, field := range line.Fields {
+			var metricType types.MType
+			switch field.Kind {
+			case influx.FieldInteger, influx.FieldUnsigned:
+				metricType = h.influxIntegerType
+			case influx.FieldFloat:
+				metricType = h.influxFloatType
+			case influx.FieldBoolean:
+				metricType = types.Gauge
+			default:
+				continue
+			}
+
+			name := line.Measurement
+			if field.Key != InfluxValueField {
+				name += "_" + field.Key
+			}
+			points = append(points, &types.Point{
+				Name:       name,
+				Labels:     line.Tags,
+				Type:       metricType,
+				Value:      field.Value,
+				Cumulative: metricType == types.Counter,
+			})
+		}
+	}
+
+	if !h.ingest(w, r, points) {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
@@ -0,0 +1,63 @@
+package handlers
+
+import (
+	"context"
+	"go-metrics-alerting/internal/types"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+type fakeIngestService struct {
+	points []*types.Point
+}
+
+func (f *fakeIngestService) Ingest(ctx context.Context, points []*types.Point) error {
+	f.points = append(f.points, points...)
+	return nil
+}
+
+func TestInfluxWriteHandler(t *testing.T) {
+	tests := []struct {
+		name       string
+		body       string
+		wantStatus int
+		want       []*types.Point
+	}{
+		{
+			name:       "fields become metrics",
+			body:       "cpu,host=a value=0.5,cores=4i,idle=true,note=\"x\" 1700000000000000000",
+			wantStatus: http.StatusNoContent,
+			want: []*types.Point{
+				{Name: "cpu", Labels: map[string]string{"host": "a"}, Type: types.Gauge, Value: 0.5},
+				{Name: "cpu_cores", Labels: map[string]string{"host": "a"}, Type: types.Counter, Value: 4, Cumulative: true},
+				{Name: "cpu_idle", Labels: map[string]string{"host": "a"}, Type: types.Gauge, Value: 1},
+			},
+		},
+		{
+			name:       "invalid line protocol",
+			body:       "cpu value=x",
+			wantStatus: http.StatusBadRequest,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			svc := &fakeIngestService{}
+			h := NewIngestHandler(svc, types.Counter, types.Gauge)
+
+			w := httptest.NewRecorder()
+			r := httptest.NewRequest(http.MethodPost, "/api/v2/write", strings.NewReader(tt.body))
+			h.InfluxWriteHandler(w, r)
+
+			if w.Code != tt.wantStatus {
+				t.Errorf("status = %d, want %d", w.Code, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(svc.points, tt.want) {
+				t.Errorf("ingested points = %+v, want %+v", svc.points, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,67 @@
+package handlers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/services"
+	"go-metrics-alerting/internal/types"
+	"io"
+	"net/http"
+)
+
+// MaxIngestBodySize limits the size of a single push request.
+const MaxIngestBodySize = 32 << 20
+
+// IngestService defines the method used to store points pushed by external systems.
+type IngestService interface {
+	Ingest(ctx context.Context, points []*types.Point) error
+}
+
+// IngestHandler contains the reference to the ingest service.
+type IngestHandler struct {
+	svc               IngestService
+	influxIntegerType types.MType
+	influxFloatType   types.MType
+}
+
+// NewIngestHandler creates a new instance of IngestHandler. The types select how integer
+// and float fields of the Influx line protocol are stored.
+func NewIngestHandler(svc IngestService, influxIntegerType, influxFloatType types.MType) *IngestHandler {
+	return &IngestHandler{
+		svc:               svc,
+		influxIntegerType: influxIntegerType,
+		influxFloatType:   influxFloatType,
+	}
+}
+
+// ingest stores the points, answering 400 for points that can never be stored and 500
+// for storage failures, so that senders only retry requests that may succeed later.
+func (h *IngestHandler) ingest(w http.ResponseWriter, r *http.Request, points []*types.Point) bool {
+	if err := h.svc.Ingest(r.Context(), points); err != nil {
+		if errors.Is(err, services.ErrInvalidPoint) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "Failed to store metrics", http.StatusInternalServerError)
+		}
+		fmt.Printf("Error: Failed to ingest metrics: %v\n", err)
+		return false
+	}
+	return true
+}
+
+// readLimitedBody reads the request body, answering 413 when it exceeds MaxIngestBodySize.
+func readLimitedBody(w http.ResponseWriter, r *http.Request) ([]byte, bool) {
+	data, err := io.ReadAll(http.MaxBytesReader(w, r.Body, MaxIngestBodySize))
+	if err != nil {
+		var tooLarge *http.MaxBytesError
+		if errors.As(err, &tooLarge) {
+			http.Error(w, "Request body too large", http.StatusRequestEntityTooLarge)
+		} else {
+			http.Error(w, "Failed to read request body", http.StatusBadRequest)
+		}
+		fmt.Printf("Error: Failed to read request body: %v\n", err)
+		return nil, false
+	}
+	return data, true
+}
@@ -10,6 +10,7 @@ import (
 	"html/template"
 	"net/http"
 	"strconv"
+	"time"
 
 	"github.com/go-chi/chi/v5"
 )
@@ -19,16 +20,23 @@ type MetricService interface {
 	UpdatesMetric(ctx context.Context, metrics []*types.Metrics) ([]*types.Metrics, error)
 	GetMetricByTypeAndID(ctx context.Context, id types.MetricID) (*types.Metrics, error)
 	ListAllMetrics(ctx context.Context) ([]*types.Metrics, error)
+	QuerySeries(ctx context.Context, id types.MetricID, from, to time.Time, step time.Duration) (*types.MetricSeries, error)
+}
+
+// AlertLister defines the method used to show current alerts next to the metrics.
+type AlertLister interface {
+	ListAlerts(ctx context.Context) ([]*types.Alert, error)
 }
 
 // MetricHandler contains the reference to the metric service.
 type MetricHandler struct {
-	svc MetricService
+	svc    MetricService
+	alerts AlertLister
 }
 
 // NewMetricHandler creates a new instance of MetricHandler.
-func NewMetricHandler(svc MetricService) *MetricHandler {
-	return &MetricHandler{svc: svc}
+func NewMetricHandler(svc MetricService, alerts AlertLister) *MetricHandler {
+	return &MetricHandler{svc: svc, alerts: alerts}
 }
 
 // UpdateMetricPathHandler handles metric updates through path parameters.
@@ -281,12 +289,51 @@ func (h *MetricHandler) ListMetricsHTMLHandler(w http.ResponseWriter, r *http.Re
 		return
 	}
 
+	alerts, err := h.alerts.ListAlerts(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to retrieve alerts", http.StatusInternalServerError)
+		fmt.Printf("Error: Failed to retrieve alerts: %v\n", err)
+		return
+	}
+
 	// Prepare the view model for HTML rendering
 	type MetricViewModel struct {
 		ID    string
 		Value string
 	}
 
+	type AlertViewModel struct {
+		RuleID     string
+		MetricID   string
+		State      string
+		Silenced   bool
+		Value      string
+		ActiveAt   string
+		FiredAt    string
+		ResolvedAt string
+	}
+
+	formatTime := func(t *time.Time) string {
+		if t == nil {
+			return "-"
+		}
+		return t.Format(time.RFC3339)
+	}
+
+	var alertViewModel []AlertViewModel
+	for _, alert := range alerts {
+		alertViewModel = append(alertViewModel, AlertViewModel{
+			RuleID:     alert.RuleID,
+			MetricID:   alert.Metric.ID,
+			State:      string(alert.State),
+			Silenced:   alert.Silenced,
+			Value:      fmt.Sprintf("%f", alert.Value),
+			ActiveAt:   formatTime(alert.ActiveAt),
+			FiredAt:    formatTime(alert.FiredAt),
+			ResolvedAt: formatTime(alert.ResolvedAt),
+		})
+	}
+
 	var viewModel []MetricViewModel
 	for _, metric := range metrics {
 		var value string
@@ -310,12 +357,21 @@ func (h *MetricHandler) ListMetricsHTMLHandler(w http.ResponseWriter, r *http.Re
 		<body>
 			<h1>Metrics List</h1>
 			<ul>
-				{{range .}}
+				{{range .Metrics}}
 					<li>{{.ID}}: {{.Value}}</li>
 				{{else}}
 					<li>No metrics found.</li>
 				{{end}}
 			</ul>
+			<h1>Alerts</h1>
+			<table>
+				<tr><th>Rule</th><th>Metric</th><th>State</th><th>Value</th><th>Active</th><th>Fired</th><th>Resolved</th></tr>
+				{{range .Alerts}}
+					<tr><td>{{.RuleID}}</td><td>{{.MetricID}}</td><td>{{.State}}{{if .Silenced}} (silenced){{end}}</td><td>{{.Value}}</td><td>{{.ActiveAt}}</td><td>{{.FiredAt}}</td><td>{{.ResolvedAt}}</td></tr>
+				{{else}}
+					<tr><td colspan="7">No alerts found.</td></tr>
+				{{end}}
+			</table>
 		</body>
 	</html>
 	`
@@ -331,7 +387,14 @@ func (h *MetricHandler) ListMetricsHTMLHandler(w http.ResponseWriter, r *http.Re
 	// Render the template with the data
 	w.Header().Set("Content-Type", "text/html")
 	w.WriteHeader(http.StatusOK)
-	if err := t.Execute(w, viewModel); err != nil {
+	data := struct {
+		Metrics []MetricViewModel
+		Alerts  []AlertViewModel
+	}{
+		Metrics: viewModel,
+		Alerts:  alertViewModel,
+	}
+	if err := t.Execute(w, data); err != nil {
 		http.Error(w, "Failed to render template", http.StatusInternalServerError)
 		fmt.Printf("Error: Failed to render template: %v\n", err)
 	}
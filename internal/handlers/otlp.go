@@ -0,0 +1,118 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-metrics-alerting/internal/otlp"
+	"go-metrics-alerting/internal/types"
+	"mime"
+	"net/http"
+)
+
+// OTLP content types of the protobuf and JSON encodings.
+const (
+	OTLPProtobufContentType = "application/x-protobuf"
+	OTLPJSONContentType     = "application/json"
+)
+
+// OTLPMetricsHandler accepts ExportMetricsServiceRequest payloads of OTLP/HTTP in protobuf or JSON.
+// Gauges are stored as gauges. Monotonic sums are stored as counters, receiving delta values as
+// increments and cumulative values as running totals; non-monotonic cumulative sums are stored as
+// gauges. Resource attributes and data point attributes become labels, the latter taking precedence.
+// Other metric kinds and non-monotonic delta sums are skipped.
+func (h *IngestHandler) OTLPMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	contentType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	if err != nil || (contentType != OTLPProtobufContentType && contentType != OTLPJSONContentType) {
+		http.Error(w, "Unsupported content type", http.StatusUnsupportedMediaType)
+		fmt.Printf("Error: Unsupported OTLP content type %q\n", r.Header.Get("Content-Type"))
+		return
+	}
+
+	data, ok := readLimitedBody(w, r)
+	if !ok {
+		return
+	}
+
+	var req otlp.ExportMetricsServiceRequest
+	if contentType == OTLPJSONContentType {
+		err = json.Unmarshal(data, &req)
+	} else {
+		err = req.Unmarshal(data)
+	}
+	if err != nil {
+		http.Error(w, "Invalid OTLP body", http.StatusBadRequest)
+		fmt.Printf("Error: Invalid OTLP body: %v\n", err)
+		return
+	}
+
+	if !h.ingest(w, r, otlpPoints(&req)) {
+		return
+	}
+
+	// An empty ExportMetricsServiceResponse in the encoding of the request
+	w.Header().Set("Content-Type", contentType)
+	w.WriteHeader(http.StatusOK)
+	if contentType == OTLPJSONContentType {
+		w.Write([]byte("{}"))
+	}
+}
+
+// otlpPoints translates every supported data point of the request into a point.
+func otlpPoints(req *otlp.ExportMetricsServiceRequest) []*types.Point {
+	var points []*types.Point
+	for _, rm := range req.ResourceMetrics {
+		for _, sm := range rm.ScopeMetrics {
+			for _, metric := range sm.Metrics {
+				var dataPoints []otlp.NumberDataPoint
+				var metricType types.MType
+				var cumulative bool
+
+				switch {
+				case metric.Gauge != nil:
+					dataPoints, metricType = metric.Gauge.DataPoints, types.Gauge
+				case metric.Sum != nil:
+					dataPoints = metric.Sum.DataPoints
+					temporality := metric.Sum.AggregationTemporality
+					switch {
+					case temporality == otlp.AggregationTemporalityCumulative && metric.Sum.IsMonotonic:
+						metricType, cumulative = types.Counter, true
+					case temporality == otlp.AggregationTemporalityDelta && metric.Sum.IsMonotonic:
+						metricType = types.Counter
+					case temporality == otlp.AggregationTemporalityCumulative:
+						metricType = types.Gauge
+					default:
+						continue
+					}
+				default:
+					continue
+				}
+
+				for i := range dataPoints {
+					value, ok := dataPoints[i].Value()
+					if !ok {
+						continue
+					}
+					points = append(points, &types.Point{
+						Name:       metric.Name,
+						Labels:     otlpLabels(rm.Resource.Attributes, dataPoints[i].Attributes),
+						Type:       metricType,
+						Value:      value,
+						Cumulative: cumulative,
+					})
+				}
+			}
+		}
+	}
+	return points
+}
+
+// otlpLabels merges the attribute lists into labels, later lists overriding earlier ones.
+func otlpLabels(attributes ...[]otlp.KeyValue) map[string]string {
+	labels := make(map[string]string)
+	for _, list := range attributes {
+		for i := range list {
+			labels[list[i].Key] = list[i].Value.String()
+		}
+	}
+	return labels
+}
@@ -0,0 +1,131 @@
+package handlers
+
+import (
+	"context"
+	"go-metrics-alerting/internal/otlp"
+	"go-metrics-alerting/internal/services"
+	"go-metrics-alerting/internal/types"
+	"net/http"
+	"net/http/httptest"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestOTLPPoints(t *testing.T) {
+	str := func(v string) otlp.AnyValue { return otlp.AnyValue{StringValue: &v} }
+	double := func(v float64) *otlp.Double { d := otlp.Double(v); return &d }
+	integer := func(v int64) *otlp.Int64 { i := otlp.Int64(v); return &i }
+	sum := func(temporality otlp.AggregationTemporality, monotonic bool) *otlp.Sum {
+		return &otlp.Sum{
+			DataPoints:             []otlp.NumberDataPoint{{AsInt: integer(3)}},
+			AggregationTemporality: temporality,
+			IsMonotonic:            monotonic,
+		}
+	}
+
+	request := &otlp.ExportMetricsServiceRequest{ResourceMetrics: []otlp.ResourceMetrics{{
+		Resource: otlp.Resource{Attributes: []otlp.KeyValue{
+			{Key: "service.name", Value: str("api")},
+			{Key: "host", Value: str("resource")},
+		}},
+		ScopeMetrics: []otlp.ScopeMetrics{{Metrics: []otlp.Metric{
+			{Name: "load", Gauge: &otlp.Gauge{DataPoints: []otlp.NumberDataPoint{
+				{AsDouble: double(0.5), Attributes: []otlp.KeyValue{{Key: "host", Value: str("point")}}},
+				{}, // No value
+			}}},
+			{Name: "cumulative", Sum: sum(otlp.AggregationTemporalityCumulative, true)},
+			{Name: "delta", Sum: sum(otlp.AggregationTemporalityDelta, true)},
+			{Name: "updown", Sum: sum(otlp.AggregationTemporalityCumulative, false)},
+			{Name: "delta_updown", Sum: sum(otlp.AggregationTemporalityDelta, false)},
+			{Name: "histogram"},
+		}}},
+	}}}
+
+	labels := map[string]string{"service.name": "api", "host": "resource"}
+	want := []*types.Point{
+		{Name: "load", Labels: map[string]string{"service.name": "api", "host": "point"}, Type: types.Gauge, Value: 0.5},
+		{Name: "cumulative", Labels: labels, Type: types.Counter, Value: 3, Cumulative: true},
+		{Name: "delta", Labels: labels, Type: types.Counter, Value: 3},
+		{Name: "updown", Labels: labels, Type: types.Gauge, Value: 3},
+	}
+	if got := otlpPoints(request); !reflect.DeepEqual(got, want) {
+		t.Errorf("otlpPoints() = %+v, want %+v", got, want)
+	}
+}
+
+// recordingMetricService records the metrics written by the ingest service.
+type recordingMetricService struct {
+	metrics []*types.Metrics
+}
+
+func (r *recordingMetricService) UpdatesMetric(ctx context.Context, metrics []*types.Metrics) ([]*types.Metrics, error) {
+	r.metrics = append(r.metrics, metrics...)
+	return metrics, nil
+}
+
+type noCounterTotals struct{}
+
+func (noCounterTotals) SaveTotals(ctx context.Context, totals []*types.CounterTotal) error {
+	return nil
+}
+
+func (noCounterTotals) ListTotals(ctx context.Context) ([]*types.CounterTotal, error) {
+	return nil, nil
+}
+
+func TestOTLPMetricsHandlerSDKResource(t *testing.T) {
+	// The resource an OpenTelemetry SDK reports alone makes the metric ID longer than 255 characters
+	body := `{"resourceMetrics": [{
+		"resource": {"attributes": [
+			{"key": "service.name", "value": {"stringValue": "checkout"}},
+			{"key": "service.namespace", "value": {"stringValue": "shop"}},
+			{"key": "service.version", "value": {"stringValue": "1.42.0"}},
+			{"key": "service.instance.id", "value": {"stringValue": "627cc493-f310-47de-96bd-71410b7dec09"}},
+			{"key": "telemetry.sdk.name", "value": {"stringValue": "opentelemetry"}},
+			{"key": "telemetry.sdk.language", "value": {"stringValue": "go"}},
+			{"key": "telemetry.sdk.version", "value": {"stringValue": "1.28.0"}},
+			{"key": "host.name", "value": {"stringValue": "checkout-7d9f8b6c5d-x2x4z"}},
+			{"key": "host.arch", "value": {"stringValue": "amd64"}},
+			{"key": "process.pid", "value": {"intValue": "1"}},
+			{"key": "process.executable.name", "value": {"stringValue": "checkout"}},
+			{"key": "process.runtime.name", "value": {"stringValue": "go"}},
+			{"key": "process.runtime.version", "value": {"stringValue": "go1.23.2"}}
+		]},
+		"scopeMetrics": [{"metrics": [{
+			"name": "http.server.request.count",
+			"sum": {
+				"aggregationTemporality": 2,
+				"isMonotonic": true,
+				"dataPoints": [{"asInt": "12", "attributes": [{"key": "http.route", "value": {"stringValue": "/api/cart"}}]}]
+			}
+		}]}]
+	}]}`
+
+	metrics := &recordingMetricService{}
+	h := NewIngestHandler(services.NewIngestService(metrics, noCounterTotals{}, services.DefaultIngestBatchSize), types.Counter, types.Gauge)
+
+	w := httptest.NewRecorder()
+	r := httptest.NewRequest(http.MethodPost, "/v1/metrics", strings.NewReader(body))
+	r.Header.Set("Content-Type", OTLPJSONContentType)
+	h.OTLPMetricsHandler(w, r)
+
+	if w.Code != http.StatusOK {
+		t.Fatalf("status = %d, want %d (%s)", w.Code, http.StatusOK, w.Body.String())
+	}
+	if len(metrics.metrics) != 1 {
+		t.Fatalf("written metrics = %+v, want one", metrics.metrics)
+	}
+	metric := metrics.metrics[0]
+	if len(metric.ID) <= 255 {
+		t.Errorf("metric ID is %d bytes long, want more than 255", len(metric.ID))
+	}
+	for _, label := range []string{`service.instance.id="627cc493-f310-47de-96bd-71410b7dec09"`, `http.route="/api/cart"`} {
+		if !strings.Contains(metric.ID, label) {
+			t.Errorf("metric ID %s does not contain %s", metric.ID, label)
+		}
+	}
+	if metric.Delta == nil || *metric.Delta != 12 {
+		t.Errorf("metric delta = %v, want 12", metric.Delta)
+	}
+}
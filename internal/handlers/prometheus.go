@@ -0,0 +1,120 @@
+package handlers
+
+import (
+	"fmt"
+	"go-metrics-alerting/internal/types"
+	"math"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// PrometheusContentType is the content type of the Prometheus text exposition format.
+const PrometheusContentType = "text/plain; version=0.0.4; charset=utf-8"
+
+// promSeries is a single sample of a metric family.
+type promSeries struct {
+	labels string // Rendered label set, e.g. {code="200"}
+	value  float64
+}
+
+// promFamily groups the series of a metric name for a single # TYPE line.
+type promFamily struct {
+	name   string
+	kind   string
+	series []promSeries
+}
+
+// ListMetricsPrometheusHandler renders all metrics in the Prometheus text exposition format.
+// Labels encoded in metric IDs as name{label="value"} become Prometheus labels.
+func (h *MetricHandler) ListMetricsPrometheusHandler(w http.ResponseWriter, r *http.Request) {
+	metrics, err := h.svc.ListAllMetrics(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to retrieve metrics", http.StatusInternalServerError)
+		fmt.Printf("Error: Failed to retrieve metrics: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", PrometheusContentType)
+	w.WriteHeader(http.StatusOK)
+
+	for _, family := range prometheusFamilies(metrics) {
+		fmt.Fprintf(w, "# TYPE %s %s\n", family.name, family.kind)
+		for _, series := range family.series {
+			fmt.Fprintf(w, "%s%s %s\n", family.name, series.labels, formatPrometheusValue(series.value))
+		}
+	}
+}
+
+// prometheusFamilies groups the metrics into families ordered by name.
+func prometheusFamilies(metrics []*types.Metrics) []*promFamily {
+	names := types.PrometheusNames(metrics)
+
+	families := make(map[string]*promFamily)
+	for _, metric := range metrics {
+		var kind string
+		var value float64
+		switch {
+		case metric.Type == string(types.Gauge) && metric.Value != nil:
+			kind, value = "gauge", *metric.Value
+		case metric.Type == string(types.Counter) && metric.Delta != nil:
+			kind, value = "counter", float64(*metric.Delta)
+		default:
+			continue
+		}
+
+		name := names[types.MetricID{ID: metric.ID, Type: metric.Type}]
+		_, labels := types.ParseMetricName(metric.ID)
+
+		family, exists := families[name]
+		if !exists {
+			family = &promFamily{name: name, kind: kind}
+			families[name] = family
+		}
+		family.series = append(family.series, promSeries{labels: formatPrometheusLabels(labels), value: value})
+	}
+
+	result := make([]*promFamily, 0, len(families))
+	for _, family := range families {
+		sort.Slice(family.series, func(i, j int) bool { return family.series[i].labels < family.series[j].labels })
+		result = append(result, family)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].name < result[j].name })
+
+	return result
+}
+
+// formatPrometheusLabels renders the labels sorted by name, escaping the values.
+func formatPrometheusLabels(labels map[string]string) string {
+	if len(labels) == 0 {
+		return ""
+	}
+
+	names := types.PrometheusLabelNames(labels)
+	pairs := make([]string, 0, len(labels))
+	for name, value := range labels {
+		pairs = append(pairs, names[name]+`="`+escapeLabelValue(value)+`"`)
+	}
+	sort.Strings(pairs)
+
+	return "{" + strings.Join(pairs, ",") + "}"
+}
+
+var labelValueEscaper = strings.NewReplacer(`\`, `\\`, `"`, `\"`, "\n", `\n`)
+
+func escapeLabelValue(value string) string {
+	return labelValueEscaper.Replace(value)
+}
+
+func formatPrometheusValue(value float64) string {
+	switch {
+	case math.IsNaN(value):
+		return "NaN"
+	case math.IsInf(value, 1):
+		return "+Inf"
+	case math.IsInf(value, -1):
+		return "-Inf"
+	}
+	return strconv.FormatFloat(value, 'g', -1, 64)
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"context"
+	"go-metrics-alerting/internal/types"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+)
+
+// listingMetricService returns a fixed list of metrics.
+type listingMetricService struct {
+	metrics []*types.Metrics
+}
+
+func (l *listingMetricService) UpdatesMetric(ctx context.Context, metrics []*types.Metrics) ([]*types.Metrics, error) {
+	return metrics, nil
+}
+
+func (l *listingMetricService) GetMetricByTypeAndID(ctx context.Context, id types.MetricID) (*types.Metrics, error) {
+	return nil, nil
+}
+
+func (l *listingMetricService) ListAllMetrics(ctx context.Context) ([]*types.Metrics, error) {
+	return l.metrics, nil
+}
+
+func (l *listingMetricService) QuerySeries(ctx context.Context, id types.MetricID, from, to time.Time, step time.Duration) (*types.MetricSeries, error) {
+	return nil, nil
+}
+
+func TestListMetricsPrometheusHandler(t *testing.T) {
+	gauge := func(id string, value float64) *types.Metrics {
+		return &types.Metrics{ID: id, Type: string(types.Gauge), Value: &value}
+	}
+	counter := func(id string, delta int64) *types.Metrics {
+		return &types.Metrics{ID: id, Type: string(types.Counter), Delta: &delta}
+	}
+
+	tests := []struct {
+		name    string
+		metrics []*types.Metrics
+		want    string
+	}{
+		{
+			name:    "plain metrics",
+			metrics: []*types.Metrics{gauge("Alloc", 1.5), counter("PollCount", 3)},
+			want:    "# TYPE Alloc gauge\nAlloc 1.5\n# TYPE PollCount counter\nPollCount 3\n",
+		},
+		{
+			name:    "labels sorted and escaped",
+			metrics: []*types.Metrics{gauge(`http_requests{path="/a\"b",code="200"}`, 2)},
+			want:    "# TYPE http_requests gauge\nhttp_requests{code=\"200\",path=\"/a\\\"b\"} 2\n",
+		},
+		{
+			name:    "label names sanitized",
+			metrics: []*types.Metrics{gauge(`cpu{host.name="a",1st="b"}`, 1)},
+			want:    "# TYPE cpu gauge\ncpu{_1st=\"b\",host_name=\"a\"} 1\n",
+		},
+		{
+			name:    "colliding label names",
+			metrics: []*types.Metrics{gauge(`cpu{a.b="dot",a_b="underscore",a-b="dash"}`, 1)},
+			want:    "# TYPE cpu gauge\ncpu{a_b=\"underscore\",a_b_108bf50c=\"dot\",a_b_2a89df63=\"dash\"} 1\n",
+		},
+		{
+			name:    "colliding metric names",
+			metrics: []*types.Metrics{gauge("heap.alloc", 1), gauge("heap_alloc", 2)},
+			want:    "# TYPE heap_alloc gauge\nheap_alloc 2\n# TYPE heap_alloc_cc894308 gauge\nheap_alloc_cc894308 1\n",
+		},
+		{
+			name:    "counter sharing a gauge name",
+			metrics: []*types.Metrics{gauge("requests", 1), counter("requests", 5)},
+			want:    "# TYPE requests gauge\nrequests 1\n# TYPE requests_total counter\nrequests_total 5\n",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			h := NewMetricHandler(&listingMetricService{metrics: tt.metrics}, nil)
+			w := httptest.NewRecorder()
+			h.ListMetricsPrometheusHandler(w, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+			if w.Code != http.StatusOK {
+				t.Fatalf("status = %d, want %d", w.Code, http.StatusOK)
+			}
+			if got := w.Header().Get("Content-Type"); got != PrometheusContentType {
+				t.Errorf("Content-Type = %q, want %q", got, PrometheusContentType)
+			}
+			if got := w.Body.String(); got != tt.want {
+				t.Errorf("body =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,284 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/promql"
+	"go-metrics-alerting/internal/services"
+	"math"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// Error types of the Prometheus HTTP API.
+const (
+	PromErrorBadData  = "bad_data"
+	PromErrorInternal = "internal"
+)
+
+// QueryService defines the methods used to answer the Prometheus HTTP API.
+type QueryService interface {
+	Query(ctx context.Context, query string, t time.Time) (promql.Value, error)
+	QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (promql.Matrix, error)
+	Series(ctx context.Context, matchers []string, start, end time.Time) ([]map[string]string, error)
+	LabelNames(ctx context.Context, matchers []string, start, end time.Time) ([]string, error)
+	LabelValues(ctx context.Context, name string, matchers []string, start, end time.Time) ([]string, error)
+}
+
+// QueryHandler serves a subset of the Prometheus HTTP API so that the server can be used
+// as a Prometheus data source. Parameters are read from the URL query or a form body.
+type QueryHandler struct {
+	svc QueryService
+}
+
+// NewQueryHandler creates a new instance of QueryHandler.
+func NewQueryHandler(svc QueryService) *QueryHandler {
+	return &QueryHandler{svc: svc}
+}
+
+// promResponse is the envelope of every Prometheus HTTP API response.
+type promResponse struct {
+	Status    string      `json:"status"`
+	Data      interface{} `json:"data,omitempty"`
+	ErrorType string      `json:"errorType,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
+
+// promQueryData is the data of query responses.
+type promQueryData struct {
+	ResultType promql.ValueType `json:"resultType"`
+	Result     interface{}      `json:"result"`
+}
+
+type promVectorSample struct {
+	Metric map[string]string `json:"metric"`
+	Value  promPoint         `json:"value"`
+}
+
+type promMatrixSeries struct {
+	Metric map[string]string `json:"metric"`
+	Values []promPoint       `json:"values"`
+}
+
+// promPoint is rendered as [unix seconds, "value"].
+type promPoint struct {
+	Time  time.Time
+	Value float64
+}
+
+func (p promPoint) MarshalJSON() ([]byte, error) {
+	return json.Marshal([]interface{}{float64(p.Time.UnixMilli()) / 1000, formatPrometheusValue(p.Value)})
+}
+
+// InstantQueryHandler evaluates the query parameter at the time parameter, which defaults to now.
+func (h *QueryHandler) InstantQueryHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writePromError(w, PromErrorBadData, fmt.Errorf("invalid form: %w", err))
+		return
+	}
+
+	t := time.Now()
+	if r.Form.Get("time") != "" {
+		var err error
+		if t, err = parsePromTime(r.Form.Get("time")); err != nil {
+			writePromError(w, PromErrorBadData, fmt.Errorf("invalid parameter \"time\": %w", err))
+			return
+		}
+	}
+
+	value, err := h.svc.Query(r.Context(), r.Form.Get("query"), t)
+	if err != nil {
+		writePromServiceError(w, err)
+		return
+	}
+
+	data := promQueryData{ResultType: value.Type()}
+	switch v := value.(type) {
+	case promql.Scalar:
+		data.Result = promPoint{Time: t, Value: float64(v)}
+	case promql.Vector:
+		result := make([]promVectorSample, 0, len(v))
+		for _, sample := range v {
+			result = append(result, promVectorSample{Metric: sample.Labels, Value: promPoint{Time: t, Value: sample.Value}})
+		}
+		data.Result = result
+	}
+	writePromData(w, data)
+}
+
+// RangeQueryHandler evaluates the query parameter from start to end every step.
+func (h *QueryHandler) RangeQueryHandler(w http.ResponseWriter, r *http.Request) {
+	if err := r.ParseForm(); err != nil {
+		writePromError(w, PromErrorBadData, fmt.Errorf("invalid form: %w", err))
+		return
+	}
+
+	start, err := parsePromTime(r.Form.Get("start"))
+	if err != nil {
+		writePromError(w, PromErrorBadData, fmt.Errorf("invalid parameter \"start\": %w", err))
+		return
+	}
+	end, err := parsePromTime(r.Form.Get("end"))
+	if err != nil {
+		writePromError(w, PromErrorBadData, fmt.Errorf("invalid parameter \"end\": %w", err))
+		return
+	}
+	if end.Before(start) {
+		writePromError(w, PromErrorBadData, errors.New("end timestamp must not be before start time"))
+		return
+	}
+	step, err := parsePromDuration(r.Form.Get("step"))
+	if err != nil {
+		writePromError(w, PromErrorBadData, fmt.Errorf("invalid parameter \"step\": %w", err))
+		return
+	}
+	if step <= 0 {
+		writePromError(w, PromErrorBadData, errors.New("zero or negative query resolution step widths are not accepted. Try a positive integer"))
+		return
+	}
+
+	matrix, err := h.svc.QueryRange(r.Context(), r.Form.Get("query"), start, end, step)
+	if err != nil {
+		writePromServiceError(w, err)
+		return
+	}
+
+	result := make([]promMatrixSeries, 0, len(matrix))
+	for _, series := range matrix {
+		values := make([]promPoint, 0, len(series.Samples))
+		for _, sample := range series.Samples {
+			values = append(values, promPoint{Time: sample.Timestamp, Value: sample.Value})
+		}
+		result = append(result, promMatrixSeries{Metric: series.Labels, Values: values})
+	}
+	writePromData(w, promQueryData{ResultType: promql.ValueTypeMatrix, Result: result})
+}
+
+// SeriesHandler returns the label sets of the series matching the match[] selectors.
+func (h *QueryHandler) SeriesHandler(w http.ResponseWriter, r *http.Request) {
+	matchers, start, end, ok := parsePromSelection(w, r)
+	if !ok {
+		return
+	}
+	if len(matchers) == 0 {
+		writePromError(w, PromErrorBadData, errors.New("no match[] parameter provided"))
+		return
+	}
+
+	series, err := h.svc.Series(r.Context(), matchers, start, end)
+	if err != nil {
+		writePromServiceError(w, err)
+		return
+	}
+	writePromData(w, series)
+}
+
+// LabelsHandler returns the label names of the series matching the optional match[] selectors.
+func (h *QueryHandler) LabelsHandler(w http.ResponseWriter, r *http.Request) {
+	matchers, start, end, ok := parsePromSelection(w, r)
+	if !ok {
+		return
+	}
+
+	names, err := h.svc.LabelNames(r.Context(), matchers, start, end)
+	if err != nil {
+		writePromServiceError(w, err)
+		return
+	}
+	writePromData(w, names)
+}
+
+// LabelValuesHandler returns the values of the label named in the path.
+func (h *QueryHandler) LabelValuesHandler(w http.ResponseWriter, r *http.Request) {
+	matchers, start, end, ok := parsePromSelection(w, r)
+	if !ok {
+		return
+	}
+
+	values, err := h.svc.LabelValues(r.Context(), chi.URLParam(r, "name"), matchers, start, end)
+	if err != nil {
+		writePromServiceError(w, err)
+		return
+	}
+	writePromData(w, values)
+}
+
+// parsePromSelection reads the match[], start and end parameters shared by the metadata endpoints.
+// Without start and end all samples are considered.
+func parsePromSelection(w http.ResponseWriter, r *http.Request) ([]string, time.Time, time.Time, bool) {
+	if err := r.ParseForm(); err != nil {
+		writePromError(w, PromErrorBadData, fmt.Errorf("invalid form: %w", err))
+		return nil, time.Time{}, time.Time{}, false
+	}
+
+	start, end := time.Time{}, time.Now()
+	var err error
+	if r.Form.Get("start") != "" {
+		if start, err = parsePromTime(r.Form.Get("start")); err != nil {
+			writePromError(w, PromErrorBadData, fmt.Errorf("invalid parameter \"start\": %w", err))
+			return nil, time.Time{}, time.Time{}, false
+		}
+	}
+	if r.Form.Get("end") != "" {
+		if end, err = parsePromTime(r.Form.Get("end")); err != nil {
+			writePromError(w, PromErrorBadData, fmt.Errorf("invalid parameter \"end\": %w", err))
+			return nil, time.Time{}, time.Time{}, false
+		}
+	}
+	return r.Form["match[]"], start, end, true
+}
+
+// parsePromTime parses a Unix timestamp in seconds, possibly fractional, or an RFC 3339 time.
+func parsePromTime(s string) (time.Time, error) {
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil && !math.IsNaN(seconds) && !math.IsInf(seconds, 0) {
+		whole, fraction := math.Modf(seconds)
+		return time.Unix(int64(whole), int64(math.Round(fraction*1e9))), nil
+	}
+	if t, err := time.Parse(time.RFC3339Nano, s); err == nil {
+		return t, nil
+	}
+	return time.Time{}, fmt.Errorf("cannot parse %q to a valid timestamp", s)
+}
+
+// parsePromDuration parses a duration in seconds, possibly fractional, or a Prometheus duration such as 1m.
+func parsePromDuration(s string) (time.Duration, error) {
+	if seconds, err := strconv.ParseFloat(s, 64); err == nil && !math.IsNaN(seconds) && !math.IsInf(seconds, 0) {
+		return time.Duration(seconds * float64(time.Second)), nil
+	}
+	if d, err := promql.ParseDuration(s); err == nil {
+		return d, nil
+	}
+	return 0, fmt.Errorf("cannot parse %q to a valid duration", s)
+}
+
+// writePromServiceError answers bad_data for invalid queries and internal for everything else.
+func writePromServiceError(w http.ResponseWriter, err error) {
+	if errors.Is(err, services.ErrInvalidQuery) {
+		writePromError(w, PromErrorBadData, err)
+		return
+	}
+	writePromError(w, PromErrorInternal, err)
+}
+
+func writePromError(w http.ResponseWriter, errorType string, err error) {
+	status := http.StatusBadRequest
+	if errorType == PromErrorInternal {
+		status = http.StatusInternalServerError
+	}
+	fmt.Printf("Error: Query failed: %v\n", err)
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(status)
+	json.NewEncoder(w).Encode(promResponse{Status: "error", ErrorType: errorType, Error: err.Error()})
+}
+
+func writePromData(w http.ResponseWriter, data interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(promResponse{Status: "success", Data: data})
+}
@@ -0,0 +1,106 @@
+package handlers
+
+import (
+	"fmt"
+	"go-metrics-alerting/internal/prompb"
+	"go-metrics-alerting/internal/types"
+	"net/http"
+	"strings"
+
+	"github.com/golang/snappy"
+)
+
+// counterSuffixes mark cumulative counters when the request carries no metadata about the family.
+var counterSuffixes = []string{"_total", "_count", "_bucket"}
+
+// RemoteWriteHandler accepts snappy compressed protobuf WriteRequest bodies of the Prometheus
+// remote write protocol. Counters are recognized by the request metadata or by their name suffix,
+// everything else is stored as a gauge with the value of the latest sample.
+func (h *IngestHandler) RemoteWriteHandler(w http.ResponseWriter, r *http.Request) {
+	compressed, ok := readLimitedBody(w, r)
+	if !ok {
+		return
+	}
+
+	data, err := snappy.Decode(nil, compressed)
+	if err != nil {
+		http.Error(w, "Invalid snappy body", http.StatusBadRequest)
+		fmt.Printf("Error: Invalid snappy body: %v\n", err)
+		return
+	}
+
+	var req prompb.WriteRequest
+	if err := req.Unmarshal(data); err != nil {
+		http.Error(w, "Invalid protobuf body", http.StatusBadRequest)
+		fmt.Printf("Error: Invalid protobuf body: %v\n", err)
+		return
+	}
+
+	if !h.ingest(w, r, remoteWritePoints(&req)) {
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// remoteWritePoints translates every sample of the request into a point.
+func remoteWritePoints(req *prompb.WriteRequest) []*types.Point {
+	familyTypes := make(map[string]prompb.MetricType)
+	for _, md := range req.Metadata {
+		familyTypes[md.MetricFamilyName] = md.Type
+	}
+
+	var points []*types.Point
+	for _, ts := range req.Timeseries {
+		name := ts.Name()
+		labels := make(map[string]string, len(ts.Labels))
+		for _, label := range ts.Labels {
+			if label.Name != prompb.MetricNameLabel {
+				labels[label.Name] = label.Value
+			}
+		}
+
+		metricType := types.Gauge
+		if isRemoteWriteCounter(name, familyTypes) {
+			metricType = types.Counter
+		}
+
+		for _, sample := range ts.Samples {
+			points = append(points, &types.Point{
+				Name:       name,
+				Labels:     labels,
+				Type:       metricType,
+				Value:      sample.Value,
+				Cumulative: true,
+			})
+		}
+	}
+	return points
+}
+
+// isRemoteWriteCounter reports whether the series is a cumulative counter. The _count and
+// _bucket series of histograms and summaries are counters, their _sum and quantiles are not.
+func isRemoteWriteCounter(name string, familyTypes map[string]prompb.MetricType) bool {
+	if t, known := familyTypes[name]; known {
+		return t == prompb.MetricTypeCounter
+	}
+	for _, suffix := range []string{"_count", "_bucket", "_sum"} {
+		family := strings.TrimSuffix(name, suffix)
+		if family == name {
+			continue
+		}
+		switch familyTypes[family] {
+		case prompb.MetricTypeHistogram, prompb.MetricTypeSummary:
+			return suffix != "_sum"
+		case prompb.MetricTypeGaugeHistogram:
+			return false
+		}
+	}
+
+	for _, suffix := range counterSuffixes {
+		if strings.HasSuffix(name, suffix) {
+			return true
+		}
+	}
+	return false
+}
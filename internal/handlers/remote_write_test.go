@@ -0,0 +1,79 @@
+package handlers
+
+import (
+	"go-metrics-alerting/internal/prompb"
+	"go-metrics-alerting/internal/types"
+	"reflect"
+	"testing"
+)
+
+func TestRemoteWritePoints(t *testing.T) {
+	series := func(name string, values ...float64) prompb.TimeSeries {
+		ts := prompb.TimeSeries{Labels: []prompb.Label{{Name: prompb.MetricNameLabel, Value: name}, {Name: "job", Value: "api"}}}
+		for i, value := range values {
+			ts.Samples = append(ts.Samples, prompb.Sample{Value: value, Timestamp: int64(i)})
+		}
+		return ts
+	}
+	point := func(name string, metricType types.MType, value float64) *types.Point {
+		return &types.Point{Name: name, Labels: map[string]string{"job": "api"}, Type: metricType, Value: value, Cumulative: true}
+	}
+
+	tests := []struct {
+		name    string
+		request prompb.WriteRequest
+		want    []*types.Point
+	}{
+		{
+			name:    "every sample is a point",
+			request: prompb.WriteRequest{Timeseries: []prompb.TimeSeries{series("temperature", 20, 21)}},
+			want:    []*types.Point{point("temperature", types.Gauge, 20), point("temperature", types.Gauge, 21)},
+		},
+		{
+			name: "counter suffixes without metadata",
+			request: prompb.WriteRequest{Timeseries: []prompb.TimeSeries{
+				series("requests_total", 5), series("latency_count", 2), series("latency_bucket", 1), series("latency_sum", 0.5),
+			}},
+			want: []*types.Point{
+				point("requests_total", types.Counter, 5),
+				point("latency_count", types.Counter, 2),
+				point("latency_bucket", types.Counter, 1),
+				point("latency_sum", types.Gauge, 0.5),
+			},
+		},
+		{
+			name: "metadata overrides suffixes",
+			request: prompb.WriteRequest{
+				Timeseries: []prompb.TimeSeries{series("queue_total", 3), series("jobs", 4)},
+				Metadata: []prompb.MetricMetadata{
+					{Type: prompb.MetricTypeGauge, MetricFamilyName: "queue_total"},
+					{Type: prompb.MetricTypeCounter, MetricFamilyName: "jobs"},
+				},
+			},
+			want: []*types.Point{point("queue_total", types.Gauge, 3), point("jobs", types.Counter, 4)},
+		},
+		{
+			name: "histogram and gauge histogram families",
+			request: prompb.WriteRequest{
+				Timeseries: []prompb.TimeSeries{series("size_sum", 9), series("size_count", 3), series("depth_count", 2)},
+				Metadata: []prompb.MetricMetadata{
+					{Type: prompb.MetricTypeHistogram, MetricFamilyName: "size"},
+					{Type: prompb.MetricTypeGaugeHistogram, MetricFamilyName: "depth"},
+				},
+			},
+			want: []*types.Point{
+				point("size_sum", types.Gauge, 9),
+				point("size_count", types.Counter, 3),
+				point("depth_count", types.Gauge, 2),
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := remoteWritePoints(&tt.request); !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("remoteWritePoints() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,51 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/services"
+	"go-metrics-alerting/internal/types"
+	"net/http"
+)
+
+// RetentionService defines the lookup of the retention settings.
+type RetentionService interface {
+	RetentionSettings(ctx context.Context) (*configs.RetentionConfig, *types.CompactionStats, error)
+}
+
+// RetentionResponse is the retention config in effect and the result of the last compaction.
+type RetentionResponse struct {
+	Settings       *configs.RetentionConfig `json:"settings"`
+	MinRetention   configs.Duration         `json:"min_retention"`   // Shorter retentions are raised to it
+	LastCompaction *types.CompactionStats   `json:"last_compaction"` // Null before the first compaction
+}
+
+// RetentionHandler contains the reference to the retention service.
+type RetentionHandler struct {
+	svc RetentionService
+}
+
+// NewRetentionHandler creates a new instance of RetentionHandler.
+func NewRetentionHandler(svc RetentionService) *RetentionHandler {
+	return &RetentionHandler{svc: svc}
+}
+
+// RetentionSettingsHandler returns the retention settings and the last compaction statistics.
+func (h *RetentionHandler) RetentionSettingsHandler(w http.ResponseWriter, r *http.Request) {
+	settings, lastCompaction, err := h.svc.RetentionSettings(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to retrieve retention settings", http.StatusInternalServerError)
+		fmt.Printf("Error: Failed to retrieve retention settings: %v\n", err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(RetentionResponse{
+		Settings:       settings,
+		MinRetention:   configs.Duration(services.MinRetention),
+		LastCompaction: lastCompaction,
+	})
+}
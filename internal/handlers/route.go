@@ -0,0 +1,61 @@
+package handlers
+
+import (
+	"encoding/json"
+	"fmt"
+	"go-metrics-alerting/internal/types"
+	"net/http"
+)
+
+// AlertRouter defines the lookup of notification routes for an alert.
+type AlertRouter interface {
+	RouteAlert(labels map[string]string) []types.AlertRouteMatch
+}
+
+// RouteTestRequest is a sample alert given by its labels.
+type RouteTestRequest struct {
+	Labels map[string]string `json:"labels"`
+}
+
+// RouteTestResponse lists the routes and receivers the sample alert would be delivered to.
+type RouteTestResponse struct {
+	Routes    []types.AlertRouteMatch `json:"routes"`
+	Receivers []string                `json:"receivers"`
+}
+
+// RouteHandler contains the reference to the alert router.
+type RouteHandler struct {
+	router AlertRouter
+}
+
+// NewRouteHandler creates a new instance of RouteHandler.
+func NewRouteHandler(router AlertRouter) *RouteHandler {
+	return &RouteHandler{router: router}
+}
+
+// TestRouteHandler shows where an alert with the labels from the request body would be sent,
+// without sending anything.
+func (h *RouteHandler) TestRouteHandler(w http.ResponseWriter, r *http.Request) {
+	var req RouteTestRequest
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		fmt.Printf("Error: Invalid input: %v\n", err)
+		return
+	}
+
+	resp := RouteTestResponse{
+		Routes:    h.router.RouteAlert(req.Labels),
+		Receivers: []string{},
+	}
+	seen := make(map[string]bool)
+	for _, match := range resp.Routes {
+		if !seen[match.Receiver] {
+			seen[match.Receiver] = true
+			resp.Receivers = append(resp.Receivers, match.Receiver)
+		}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(resp)
+}
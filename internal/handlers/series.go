@@ -0,0 +1,102 @@
+package handlers
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/services"
+	"go-metrics-alerting/internal/types"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// DefaultSeriesRange is the span of history returned when no from time is given.
+const DefaultSeriesRange = time.Hour
+
+// SeriesResponse is the history of a metric within the requested range.
+type SeriesResponse struct {
+	ID         string                `json:"id"`
+	Type       string                `json:"type"`
+	From       time.Time             `json:"from"`
+	To         time.Time             `json:"to"`
+	Resolution int64                 `json:"resolution"` // Seconds, 0 for raw samples
+	Samples    []types.Sample        `json:"samples"`
+	Rollups    []*types.MetricRollup `json:"rollups,omitempty"`
+}
+
+// ListSamplesHandler returns the samples of a metric between the from and to query parameters
+// (RFC3339). To defaults to now and from to DefaultSeriesRange before to. The optional step
+// (e.g. "5m") picks the coarsest rollup resolution not exceeding it; the rollups are returned
+// along with the samples derived from them. Counters are returned with their running totals;
+// metrics without history return no samples.
+func (h *MetricHandler) ListSamplesHandler(w http.ResponseWriter, r *http.Request) {
+	metricType := chi.URLParam(r, "type")
+	metricID, err := url.PathUnescape(chi.URLParam(r, "id"))
+	if err != nil {
+		http.Error(w, "Invalid metric ID", http.StatusBadRequest)
+		fmt.Printf("Error: Invalid metric ID: %v\n", err)
+		return
+	}
+	if metricType != string(types.Gauge) && metricType != string(types.Counter) {
+		http.Error(w, "Unknown metric type", http.StatusBadRequest)
+		fmt.Printf("Error: Unknown metric type: %s\n", metricType)
+		return
+	}
+
+	query := r.URL.Query()
+	to := time.Now()
+	if value := query.Get("to"); value != "" {
+		if to, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, "Invalid to, expected RFC3339 time", http.StatusBadRequest)
+			fmt.Printf("Error: Invalid to: %s, %v\n", value, err)
+			return
+		}
+	}
+	from := to.Add(-DefaultSeriesRange)
+	if value := query.Get("from"); value != "" {
+		if from, err = time.Parse(time.RFC3339, value); err != nil {
+			http.Error(w, "Invalid from, expected RFC3339 time", http.StatusBadRequest)
+			fmt.Printf("Error: Invalid from: %s, %v\n", value, err)
+			return
+		}
+	}
+
+	var step time.Duration
+	if value := query.Get("step"); value != "" {
+		if step, err = time.ParseDuration(value); err != nil {
+			http.Error(w, "Invalid step, expected a duration such as 5m", http.StatusBadRequest)
+			fmt.Printf("Error: Invalid step: %s, %v\n", value, err)
+			return
+		}
+	}
+
+	id := types.MetricID{ID: metricID, Type: metricType}
+	series, err := h.svc.QuerySeries(r.Context(), id, from, to, step)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidSampleRange) {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+		} else {
+			http.Error(w, "Failed to retrieve samples", http.StatusInternalServerError)
+		}
+		fmt.Printf("Error: Failed to retrieve samples of %s: %v\n", metricID, err)
+		return
+	}
+	if series.Samples == nil {
+		series.Samples = []types.Sample{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(SeriesResponse{
+		ID:         id.ID,
+		Type:       id.Type,
+		From:       from,
+		To:         to,
+		Resolution: series.Resolution,
+		Samples:    series.Samples,
+		Rollups:    series.Rollups,
+	})
+}
@@ -0,0 +1,108 @@
+package handlers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/services"
+	"go-metrics-alerting/internal/types"
+	"net/http"
+
+	"github.com/go-chi/chi/v5"
+)
+
+// SilenceService defines methods for managing silences.
+type SilenceService interface {
+	CreateSilence(ctx context.Context, silence *types.Silence) (*types.Silence, error)
+	GetSilence(ctx context.Context, id string) (*types.Silence, error)
+	ListSilences(ctx context.Context) ([]*types.Silence, error)
+	DeleteSilence(ctx context.Context, id string) error
+}
+
+// SilenceHandler contains the reference to the silence service.
+type SilenceHandler struct {
+	svc SilenceService
+}
+
+// NewSilenceHandler creates a new instance of SilenceHandler.
+func NewSilenceHandler(svc SilenceService) *SilenceHandler {
+	return &SilenceHandler{svc: svc}
+}
+
+// CreateSilenceHandler creates a new silence from the request body.
+func (h *SilenceHandler) CreateSilenceHandler(w http.ResponseWriter, r *http.Request) {
+	var silence types.Silence
+	if err := json.NewDecoder(r.Body).Decode(&silence); err != nil {
+		http.Error(w, "Invalid input", http.StatusBadRequest)
+		fmt.Printf("Error: Invalid input: %v\n", err)
+		return
+	}
+
+	created, err := h.svc.CreateSilence(r.Context(), &silence)
+	if err != nil {
+		writeSilenceError(w, silence.ID, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusCreated)
+	json.NewEncoder(w).Encode(created)
+}
+
+// ListSilencesHandler returns all silences as JSON.
+func (h *SilenceHandler) ListSilencesHandler(w http.ResponseWriter, r *http.Request) {
+	silences, err := h.svc.ListSilences(r.Context())
+	if err != nil {
+		http.Error(w, "Failed to retrieve silences", http.StatusInternalServerError)
+		fmt.Printf("Error: Failed to retrieve silences: %v\n", err)
+		return
+	}
+	if silences == nil {
+		silences = []*types.Silence{}
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(silences)
+}
+
+// GetSilenceHandler returns a single silence by its ID.
+func (h *SilenceHandler) GetSilenceHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	silence, err := h.svc.GetSilence(r.Context(), id)
+	if err != nil {
+		writeSilenceError(w, id, err)
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(http.StatusOK)
+	json.NewEncoder(w).Encode(silence)
+}
+
+// DeleteSilenceHandler removes a silence by its ID.
+func (h *SilenceHandler) DeleteSilenceHandler(w http.ResponseWriter, r *http.Request) {
+	id := chi.URLParam(r, "id")
+
+	if err := h.svc.DeleteSilence(r.Context(), id); err != nil {
+		writeSilenceError(w, id, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// writeSilenceError maps silence service errors to HTTP status codes.
+func writeSilenceError(w http.ResponseWriter, id string, err error) {
+	switch {
+	case errors.Is(err, services.ErrInvalidSilence):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, services.ErrSilenceNotFound):
+		http.Error(w, "Silence not found", http.StatusNotFound)
+	default:
+		http.Error(w, "Internal server error", http.StatusInternalServerError)
+	}
+	fmt.Printf("Error: Silence %s: %v\n", id, err)
+}
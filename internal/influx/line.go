@@ -0,0 +1,205 @@
+// Package influx parses the InfluxDB line protocol:
+//
+//	measurement[,tag=value...] field=value[,field=value...] [timestamp]
+package influx
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// FieldKind is the type of a field value.
+type FieldKind int
+
+const (
+	FieldFloat FieldKind = iota
+	FieldInteger
+	FieldUnsigned
+	FieldBoolean
+	FieldString
+)
+
+// Field is a single field of a line. Numbers and booleans are also available as a float.
+type Field struct {
+	Key   string
+	Kind  FieldKind
+	Value float64
+	Text  string // Value of string fields
+}
+
+// Line is a parsed line of the line protocol.
+type Line struct {
+	Measurement string
+	Tags        map[string]string
+	Fields      []Field
+	Timestamp   *int64 // Nil when the line has no timestamp
+}
+
+// ParseError reports the 1-based line number of a malformed line.
+type ParseError struct {
+	Line int
+	Msg  string
+}
+
+func (e *ParseError) Error() string {
+	return fmt.Sprintf("line %d: %s", e.Line, e.Msg)
+}
+
+// Parse parses a batch of lines. Empty lines and comments starting with # are skipped.
+func Parse(data []byte) ([]*Line, error) {
+	var lines []*Line
+	for i, raw := range bytes.Split(data, []byte("\n")) {
+		text := strings.TrimSpace(strings.TrimSuffix(string(raw), "\r"))
+		if text == "" || strings.HasPrefix(text, "#") {
+			continue
+		}
+
+		line, err := parseLine(text)
+		if err != nil {
+			return nil, &ParseError{Line: i + 1, Msg: err.Error()}
+		}
+		lines = append(lines, line)
+	}
+	return lines, nil
+}
+
+// parseLine parses a single non-empty line.
+func parseLine(text string) (*Line, error) {
+	sections := split(text, ' ', true)
+	if len(sections) < 2 {
+		return nil, fmt.Errorf("missing fields")
+	}
+	if len(sections) > 3 {
+		return nil, fmt.Errorf("unexpected text after timestamp: %q", strings.Join(sections[3:], " "))
+	}
+
+	// Measurement and tags
+	series := split(sections[0], ',', false)
+	line := &Line{Measurement: unescape(series[0]), Tags: make(map[string]string)}
+	if line.Measurement == "" {
+		return nil, fmt.Errorf("missing measurement")
+	}
+	for _, tag := range series[1:] {
+		key, value, err := splitPair(tag)
+		if err != nil {
+			return nil, fmt.Errorf("invalid tag %q: %v", tag, err)
+		}
+		line.Tags[unescape(key)] = unescape(value)
+	}
+
+	// Fields
+	for _, field := range split(sections[1], ',', true) {
+		key, value, err := splitPair(field)
+		if err != nil {
+			return nil, fmt.Errorf("invalid field %q: %v", field, err)
+		}
+		parsed, err := parseFieldValue(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of field %s: %v", unescape(key), err)
+		}
+		parsed.Key = unescape(key)
+		line.Fields = append(line.Fields, parsed)
+	}
+
+	// Optional timestamp
+	if len(sections) == 3 {
+		timestamp, err := strconv.ParseInt(sections[2], 10, 64)
+		if err != nil {
+			return nil, fmt.Errorf("invalid timestamp %q", sections[2])
+		}
+		line.Timestamp = &timestamp
+	}
+
+	return line, nil
+}
+
+// parseFieldValue parses a field value: "string", 1i, 1u, true or 1.5.
+func parseFieldValue(value string) (Field, error) {
+	switch {
+	case value == "":
+		return Field{}, fmt.Errorf("empty value")
+
+	case strings.HasPrefix(value, `"`):
+		if len(value) < 2 || !strings.HasSuffix(value, `"`) || strings.HasSuffix(value, `\"`) && !strings.HasSuffix(value, `\\"`) {
+			return Field{}, fmt.Errorf("unterminated string")
+		}
+		text := strings.NewReplacer(`\"`, `"`, `\\`, `\`).Replace(value[1 : len(value)-1])
+		return Field{Kind: FieldString, Text: text}, nil
+
+	case strings.HasSuffix(value, "i"):
+		n, err := strconv.ParseInt(strings.TrimSuffix(value, "i"), 10, 64)
+		if err != nil {
+			return Field{}, fmt.Errorf("invalid integer %q", value)
+		}
+		return Field{Kind: FieldInteger, Value: float64(n)}, nil
+
+	case strings.HasSuffix(value, "u"):
+		n, err := strconv.ParseUint(strings.TrimSuffix(value, "u"), 10, 64)
+		if err != nil {
+			return Field{}, fmt.Errorf("invalid unsigned integer %q", value)
+		}
+		return Field{Kind: FieldUnsigned, Value: float64(n)}, nil
+	}
+
+	switch value {
+	case "t", "T", "true", "True", "TRUE":
+		return Field{Kind: FieldBoolean, Value: 1}, nil
+	case "f", "F", "false", "False", "FALSE":
+		return Field{Kind: FieldBoolean, Value: 0}, nil
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return Field{}, fmt.Errorf("invalid number %q", value)
+	}
+	return Field{Kind: FieldFloat, Value: f}, nil
+}
+
+// split splits the text at unescaped separators, optionally ignoring separators inside quoted strings.
+func split(text string, sep byte, quotes bool) []string {
+	var parts []string
+	start, quoted := 0, false
+	for i := 0; i < len(text); i++ {
+		switch c := text[i]; {
+		case c == '\\':
+			i++
+		case quotes && c == '"':
+			quoted = !quoted
+		case c == sep && !quoted:
+			parts = append(parts, text[start:i])
+			start = i + 1
+			// Consecutive spaces separate sections only once
+			for sep == ' ' && start < len(text) && text[start] == ' ' {
+				start++
+				i++
+			}
+		}
+	}
+	return append(parts, text[start:])
+}
+
+// splitPair splits key=value at the first unescaped equals sign.
+func splitPair(pair string) (string, string, error) {
+	for i := 0; i < len(pair); i++ {
+		switch pair[i] {
+		case '\\':
+			i++
+		case '=':
+			if i == 0 {
+				return "", "", fmt.Errorf("missing key")
+			}
+			return pair[:i], pair[i+1:], nil
+		}
+	}
+	return "", "", fmt.Errorf("missing '='")
+}
+
+// unescape removes the backslashes escaping commas, spaces, equals signs and backslashes.
+func unescape(text string) string {
+	if !strings.Contains(text, `\`) {
+		return text
+	}
+	return strings.NewReplacer(`\,`, ",", `\ `, " ", `\=`, "=", `\\`, `\`).Replace(text)
+}
@@ -0,0 +1,120 @@
+package influx
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	timestamp := int64(1700000000000000000)
+
+	tests := []struct {
+		name  string
+		input string
+		want  []*Line
+	}{
+		{
+			name:  "float field without tags",
+			input: "cpu value=0.5",
+			want: []*Line{{
+				Measurement: "cpu",
+				Tags:        map[string]string{},
+				Fields:      []Field{{Key: "value", Kind: FieldFloat, Value: 0.5}},
+			}},
+		},
+		{
+			name:  "tags, typed fields and timestamp",
+			input: `mem,host=a,region=eu used=10i,free=5u,ok=true,note="x y" 1700000000000000000`,
+			want: []*Line{{
+				Measurement: "mem",
+				Tags:        map[string]string{"host": "a", "region": "eu"},
+				Fields: []Field{
+					{Key: "used", Kind: FieldInteger, Value: 10},
+					{Key: "free", Kind: FieldUnsigned, Value: 5},
+					{Key: "ok", Kind: FieldBoolean, Value: 1},
+					{Key: "note", Kind: FieldString, Text: "x y"},
+				},
+				Timestamp: &timestamp,
+			}},
+		},
+		{
+			name:  "escaped measurement, tag and field key",
+			input: `my\ cpu,data\ center=us\,east load\=avg=F`,
+			want: []*Line{{
+				Measurement: "my cpu",
+				Tags:        map[string]string{"data center": "us,east"},
+				Fields:      []Field{{Key: "load=avg", Kind: FieldBoolean, Value: 0}},
+			}},
+		},
+		{
+			name:  "escaped quotes in a string field",
+			input: `log msg="say \"hi\""`,
+			want: []*Line{{
+				Measurement: "log",
+				Tags:        map[string]string{},
+				Fields:      []Field{{Key: "msg", Kind: FieldString, Text: `say "hi"`}},
+			}},
+		},
+		{
+			name:  "comments, blank lines and CRLF",
+			input: "# comment\r\n\r\ndisk free=1e3\r\n",
+			want: []*Line{{
+				Measurement: "disk",
+				Tags:        map[string]string{},
+				Fields:      []Field{{Key: "free", Kind: FieldFloat, Value: 1000}},
+			}},
+		},
+		{
+			name:  "only comments",
+			input: "# nothing\n\n",
+			want:  nil,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Parse([]byte(tt.input))
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Parse() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		line  int
+	}{
+		{name: "missing fields", input: "cpu", line: 1},
+		{name: "missing measurement", input: ",host=a value=1", line: 1},
+		{name: "tag without equals sign", input: "cpu,host value=1", line: 1},
+		{name: "field without key", input: "cpu =1", line: 1},
+		{name: "empty field value", input: "cpu value=", line: 1},
+		{name: "invalid integer", input: "cpu value=1.5i", line: 1},
+		{name: "negative unsigned", input: "cpu value=-1u", line: 1},
+		{name: "invalid number", input: "cpu value=abc", line: 1},
+		{name: "unterminated string", input: `cpu msg="abc`, line: 1},
+		{name: "invalid timestamp", input: "cpu value=1 soon", line: 1},
+		{name: "text after timestamp", input: "cpu value=1 1 2", line: 1},
+		{name: "line number of the bad line", input: "cpu value=1\n\ncpu value=x", line: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse([]byte(tt.input))
+			var parseErr *ParseError
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Parse() error = %v, want a *ParseError", err)
+			}
+			if parseErr.Line != tt.line {
+				t.Errorf("ParseError.Line = %d, want %d", parseErr.Line, tt.line)
+			}
+		})
+	}
+}
@@ -0,0 +1,93 @@
+package listeners
+
+import (
+	"context"
+	"fmt"
+	"go-metrics-alerting/internal/types"
+	"sync"
+	"time"
+)
+
+// MaxPendingBatches is how many full batches may be buffered while a write is in progress
+// before receiving more points blocks.
+const MaxPendingBatches = 10
+
+// pointBatch buffers received points until they are written.
+type pointBatch struct {
+	size    int
+	points  []*types.Point
+	full    chan struct{} // Signals that the batch reached its size
+	drained *sync.Cond    // Signals that the buffered points were taken
+	mu      sync.Mutex
+}
+
+func newPointBatch(size int) *pointBatch {
+	b := &pointBatch{
+		size: size,
+		full: make(chan struct{}, 1),
+	}
+	b.drained = sync.NewCond(&b.mu)
+	return b
+}
+
+// add buffers the point, waking up the writer once the batch is full. While MaxPendingBatches
+// are buffered it blocks until the writer takes them, so a slow store holds back the senders
+// instead of growing the buffer without bound.
+func (b *pointBatch) add(point *types.Point) {
+	b.mu.Lock()
+	for len(b.points) >= b.size*MaxPendingBatches {
+		b.drained.Wait()
+	}
+	b.points = append(b.points, point)
+	full := len(b.points) >= b.size
+	b.mu.Unlock()
+
+	if full {
+		select {
+		case b.full <- struct{}{}:
+		default:
+		}
+	}
+}
+
+// take returns and clears the buffered points.
+func (b *pointBatch) take() []*types.Point {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	points := b.points
+	b.points = nil
+	b.drained.Broadcast()
+	return points
+}
+
+// run writes the batch every interval or when it is full until stop is closed, then writes
+// the remaining points. Writes are not canceled with the context so no received point is lost.
+func (b *pointBatch) run(ctx context.Context, stop <-chan struct{}, ingest PointIngester, interval time.Duration, source string) {
+	ctx = context.WithoutCancel(ctx)
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			b.flush(ctx, ingest, source)
+			return
+		case <-ticker.C:
+			b.flush(ctx, ingest, source)
+		case <-b.full:
+			b.flush(ctx, ingest, source)
+		}
+	}
+}
+
+func (b *pointBatch) flush(ctx context.Context, ingest PointIngester, source string) {
+	points := b.take()
+	if len(points) == 0 {
+		return
+	}
+	if err := ingest.Ingest(ctx, points); err != nil {
+		fmt.Printf("Error: Failed to store %d %s points: %v\n", len(points), source, err)
+	}
+}
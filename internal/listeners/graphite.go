@@ -0,0 +1,169 @@
+package listeners
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/types"
+	"math"
+	"net"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// PointIngester defines the method used to store the received points.
+type PointIngester interface {
+	Ingest(ctx context.Context, points []*types.Point) error
+}
+
+// GraphiteListener accepts Graphite plaintext lines over TCP:
+//
+//	path[;tag=value...] value [timestamp]
+//
+// Every line becomes a gauge. Points are buffered and written in batches of batchSize
+// or every flushInterval, whichever comes first.
+type GraphiteListener struct {
+	listener      net.Listener
+	ingest        PointIngester
+	batch         *pointBatch
+	flushInterval time.Duration
+	conns         map[net.Conn]bool
+	mu            sync.Mutex
+}
+
+// NewGraphiteListener creates a new instance of GraphiteListener serving the bound listener.
+func NewGraphiteListener(listener net.Listener, ingest PointIngester, batchSize int, flushInterval time.Duration) *GraphiteListener {
+	return &GraphiteListener{
+		listener:      listener,
+		ingest:        ingest,
+		batch:         newPointBatch(batchSize),
+		flushInterval: flushInterval,
+		conns:         make(map[net.Conn]bool),
+	}
+}
+
+// Start serves connections until the context is canceled, then closes the listener and the
+// open connections and writes the points still buffered.
+func (l *GraphiteListener) Start(ctx context.Context) error {
+	stop := make(chan struct{})
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		l.batch.run(ctx, stop, l.ingest, l.flushInterval, "graphite")
+	}()
+
+	go func() {
+		<-ctx.Done()
+		l.listener.Close()
+
+		l.mu.Lock()
+		for conn := range l.conns {
+			conn.Close()
+		}
+		l.mu.Unlock()
+	}()
+
+	var wg sync.WaitGroup
+	for {
+		conn, err := l.listener.Accept()
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				break
+			}
+			fmt.Printf("Error: Graphite listener failed to accept connection: %v\n", err)
+			continue
+		}
+
+		// A connection accepted while shutting down is not tracked by the closer anymore
+		l.mu.Lock()
+		if ctx.Err() != nil {
+			l.mu.Unlock()
+			conn.Close()
+			break
+		}
+		l.conns[conn] = true
+		l.mu.Unlock()
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.serve(conn)
+		}()
+	}
+
+	// Write what the connections received before they were closed
+	wg.Wait()
+	close(stop)
+	<-flushed
+
+	return nil
+}
+
+// serve reads lines from the connection until it is closed, skipping malformed lines.
+func (l *GraphiteListener) serve(conn net.Conn) {
+	defer func() {
+		l.mu.Lock()
+		delete(l.conns, conn)
+		l.mu.Unlock()
+		conn.Close()
+	}()
+
+	scanner := bufio.NewScanner(conn)
+	lineNumber := 0
+	for scanner.Scan() {
+		lineNumber++
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+
+		point, err := ParseGraphiteLine(line)
+		if err != nil {
+			fmt.Printf("Error: Graphite line %d from %s: %v\n", lineNumber, conn.RemoteAddr(), err)
+			continue
+		}
+		l.batch.add(point)
+	}
+}
+
+// ParseGraphiteLine parses a plaintext line into a gauge point. The timestamp is validated
+// but not used since only the current value of a metric is stored.
+func ParseGraphiteLine(line string) (*types.Point, error) {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || len(fields) > 3 {
+		return nil, fmt.Errorf("expected \"path value [timestamp]\", got %q", line)
+	}
+
+	value, err := strconv.ParseFloat(fields[1], 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid value %q", fields[1])
+	}
+	if len(fields) == 3 {
+		timestamp, err := strconv.ParseFloat(fields[2], 64)
+		if err != nil || math.IsNaN(timestamp) {
+			return nil, fmt.Errorf("invalid timestamp %q", fields[2])
+		}
+	}
+
+	// Tagged series: path;tag=value;tag=value
+	parts := strings.Split(fields[0], ";")
+	point := &types.Point{Name: parts[0], Type: types.Gauge, Value: value}
+	if point.Name == "" {
+		return nil, fmt.Errorf("missing path")
+	}
+	for _, tag := range parts[1:] {
+		name, tagValue, found := strings.Cut(tag, "=")
+		if !found || name == "" {
+			return nil, fmt.Errorf("invalid tag %q", tag)
+		}
+		if point.Labels == nil {
+			point.Labels = make(map[string]string)
+		}
+		point.Labels[name] = tagValue
+	}
+
+	return point, nil
+}
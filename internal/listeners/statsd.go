@@ -0,0 +1,289 @@
+package listeners
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/types"
+	"math"
+	"net"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// StatsDMaxPacketSize is the largest UDP datagram read at once.
+const StatsDMaxPacketSize = 65535
+
+// StatsDGaugeTTL is how long a gauge is kept without updates. Relative updates of a gauge
+// forgotten after that start again from zero.
+const StatsDGaugeTTL = time.Hour
+
+// StatsD timer percentiles reported as derived gauges.
+var statsDPercentiles = []float64{50, 90, 99}
+
+// statsDTimer collects the values of a timer within a flush interval.
+type statsDTimer struct {
+	name   string
+	labels map[string]string
+	values []float64
+	count  float64 // Scaled by the sample rates
+}
+
+// StatsDListener accepts StatsD metrics over UDP:
+//
+//	name:value|c[|@rate][|#tag:value,...]    counter increment
+//	name:value|g, name:+value|g, name:-value|g    gauge, absolute or relative
+//	name:value|ms[|@rate]    timer (h is accepted as an alias)
+//
+// Metrics are aggregated in memory and written every flush interval. Counters only grow, so
+// negative increments are rejected rather than failing the whole flush. Timers are written
+// as the gauges name.count, name.mean, name.p50, name.p90 and name.p99. Gauges not updated
+// for StatsDGaugeTTL are dropped, so that short lived names do not pile up.
+type StatsDListener struct {
+	conn          net.PacketConn
+	ingest        PointIngester
+	flushInterval time.Duration
+
+	counters map[string]*types.Point
+	gauges   map[string]*types.Point // Kept across flushes so relative updates apply to the last value
+	changed  map[string]bool         // Gauges updated since the last flush
+	touched  map[string]time.Time    // Flush time of the last update of every gauge
+	timers   map[string]*statsDTimer
+	mu       sync.Mutex
+}
+
+// NewStatsDListener creates a new instance of StatsDListener serving the bound connection.
+func NewStatsDListener(conn net.PacketConn, ingest PointIngester, flushInterval time.Duration) *StatsDListener {
+	return &StatsDListener{
+		conn:          conn,
+		ingest:        ingest,
+		flushInterval: flushInterval,
+		counters:      make(map[string]*types.Point),
+		gauges:        make(map[string]*types.Point),
+		changed:       make(map[string]bool),
+		touched:       make(map[string]time.Time),
+		timers:        make(map[string]*statsDTimer),
+	}
+}
+
+// Start reads packets until the context is canceled, then writes the last aggregates.
+func (l *StatsDListener) Start(ctx context.Context) error {
+	stop := make(chan struct{})
+	flushed := make(chan struct{})
+	go func() {
+		defer close(flushed)
+		l.run(ctx, stop)
+	}()
+
+	go func() {
+		<-ctx.Done()
+		l.conn.Close()
+	}()
+
+	buf := make([]byte, StatsDMaxPacketSize)
+	for {
+		n, addr, err := l.conn.ReadFrom(buf)
+		if err != nil {
+			if ctx.Err() != nil || errors.Is(err, net.ErrClosed) {
+				break
+			}
+			fmt.Printf("Error: StatsD listener failed to read packet: %v\n", err)
+			continue
+		}
+
+		for _, line := range strings.Split(string(buf[:n]), "\n") {
+			line = strings.TrimSpace(line)
+			if line == "" {
+				continue
+			}
+			if err := l.handleLine(line); err != nil {
+				fmt.Printf("Error: StatsD metric from %s: %v\n", addr, err)
+			}
+		}
+	}
+
+	close(stop)
+	<-flushed
+	return nil
+}
+
+// run flushes the aggregates every interval until stop is closed, then flushes once more.
+func (l *StatsDListener) run(ctx context.Context, stop <-chan struct{}) {
+	ctx = context.WithoutCancel(ctx)
+
+	ticker := time.NewTicker(l.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-stop:
+			l.flush(ctx)
+			return
+		case <-ticker.C:
+			l.flush(ctx)
+		}
+	}
+}
+
+// handleLine parses a single metric and adds it to the aggregates.
+func (l *StatsDListener) handleLine(line string) error {
+	name, rest, found := strings.Cut(line, ":")
+	if !found || name == "" {
+		return fmt.Errorf("expected \"name:value|type\", got %q", line)
+	}
+
+	sections := strings.Split(rest, "|")
+	if len(sections) < 2 {
+		return fmt.Errorf("missing type in %q", line)
+	}
+	rawValue, metricType := sections[0], sections[1]
+
+	value, err := strconv.ParseFloat(rawValue, 64)
+	if err != nil || math.IsNaN(value) || math.IsInf(value, 0) {
+		return fmt.Errorf("invalid value %q in %q", rawValue, line)
+	}
+	if metricType == "c" && value < 0 {
+		return fmt.Errorf("negative counter increment %q in %q", rawValue, line)
+	}
+
+	rate := 1.0
+	var labels map[string]string
+	for _, section := range sections[2:] {
+		switch {
+		case strings.HasPrefix(section, "@"):
+			rate, err = strconv.ParseFloat(section[1:], 64)
+			if err != nil || rate <= 0 || rate > 1 {
+				return fmt.Errorf("invalid sample rate %q in %q", section, line)
+			}
+		case strings.HasPrefix(section, "#"):
+			labels = parseStatsDTags(section[1:])
+		default:
+			return fmt.Errorf("unexpected section %q in %q", section, line)
+		}
+	}
+
+	key := types.FormatMetricName(name, labels)
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	switch metricType {
+	case "c":
+		counter, exists := l.counters[key]
+		if !exists {
+			counter = &types.Point{Name: name, Labels: labels, Type: types.Counter}
+			l.counters[key] = counter
+		}
+		counter.Value += value / rate
+
+	case "g":
+		gauge, exists := l.gauges[key]
+		if !exists {
+			gauge = &types.Point{Name: name, Labels: labels, Type: types.Gauge}
+			l.gauges[key] = gauge
+		}
+		if strings.HasPrefix(rawValue, "+") || strings.HasPrefix(rawValue, "-") {
+			gauge.Value += value
+		} else {
+			gauge.Value = value
+		}
+		l.changed[key] = true
+
+	case "ms", "h":
+		timer, exists := l.timers[key]
+		if !exists {
+			timer = &statsDTimer{name: name, labels: labels}
+			l.timers[key] = timer
+		}
+		timer.values = append(timer.values, value)
+		timer.count += 1 / rate
+
+	default:
+		return fmt.Errorf("unsupported metric type %q in %q", metricType, line)
+	}
+
+	return nil
+}
+
+// flush writes the aggregates of the interval, resets counters and timers and drops the
+// gauges not updated for StatsDGaugeTTL.
+func (l *StatsDListener) flush(ctx context.Context) {
+	now := time.Now()
+
+	l.mu.Lock()
+	var points []*types.Point
+	for _, counter := range l.counters {
+		points = append(points, counter)
+	}
+	for key := range l.changed {
+		gauge := *l.gauges[key]
+		points = append(points, &gauge)
+		l.touched[key] = now
+	}
+	for key, touched := range l.touched {
+		if now.Sub(touched) >= StatsDGaugeTTL {
+			delete(l.gauges, key)
+			delete(l.touched, key)
+		}
+	}
+	for _, timer := range l.timers {
+		points = append(points, timer.points()...)
+	}
+	l.counters = make(map[string]*types.Point)
+	l.changed = make(map[string]bool)
+	l.timers = make(map[string]*statsDTimer)
+	l.mu.Unlock()
+
+	if len(points) == 0 {
+		return
+	}
+	if err := l.ingest.Ingest(ctx, points); err != nil {
+		fmt.Printf("Error: Failed to store %d statsd points: %v\n", len(points), err)
+	}
+}
+
+// points derives the count, mean and percentile gauges of the timer.
+func (t *statsDTimer) points() []*types.Point {
+	sort.Float64s(t.values)
+
+	var sum float64
+	for _, value := range t.values {
+		sum += value
+	}
+
+	gauge := func(suffix string, value float64) *types.Point {
+		return &types.Point{Name: t.name + "." + suffix, Labels: t.labels, Type: types.Gauge, Value: value}
+	}
+	points := []*types.Point{
+		gauge("count", t.count),
+		gauge("mean", sum/float64(len(t.values))),
+	}
+	for _, percentile := range statsDPercentiles {
+		points = append(points, gauge("p"+strconv.FormatFloat(percentile, 'f', -1, 64), nearestRank(t.values, percentile)))
+	}
+	return points
+}
+
+// nearestRank returns the percentile of the sorted values using the nearest-rank method.
+func nearestRank(sorted []float64, percentile float64) float64 {
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
+
+// parseStatsDTags parses DogStatsD tags: tag:value,tag:value. Tags without a value get an empty one.
+func parseStatsDTags(text string) map[string]string {
+	labels := make(map[string]string)
+	for _, tag := range strings.Split(text, ",") {
+		name, value, _ := strings.Cut(tag, ":")
+		if name != "" {
+			labels[name] = value
+		}
+	}
+	return labels
+}
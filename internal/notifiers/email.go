@@ -0,0 +1,180 @@
+package notifiers
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/templates"
+	"go-metrics-alerting/internal/types"
+	"net"
+	"net/smtp"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// EmailChannel is the channel name used to look up rule level email templates.
+const EmailChannel = "email"
+
+// EmailTimeout bounds connecting to the SMTP server and the whole session, so that a hung
+// server cannot block the delivery of notifications.
+const EmailTimeout = 30 * time.Second
+
+// DefaultEmailSubjectTemplate renders the subject line of alert emails.
+const DefaultEmailSubjectTemplate = `[metrics-alerting] {{len .Alerts}} alert(s) changed state`
+
+// EmailData is the data passed to the email subject template.
+type EmailData struct {
+	Alerts []*types.AlertEvent
+}
+
+// EmailNotifier sends alert events as plain text emails through an SMTP server.
+type EmailNotifier struct {
+	host    string
+	addr    string
+	auth    smtp.Auth
+	from    string
+	to      []string
+	subject *template.Template
+	body    *template.Template
+}
+
+// NewEmailNotifier creates a new instance of EmailNotifier.
+// The body template renders every alert separately and defaults to templates.Long.
+// Authentication is used only when a username is provided.
+func NewEmailNotifier(host, port, username, password, from string, to []string, tmpl string) (*EmailNotifier, error) {
+	subject, err := template.New("subject").Parse(DefaultEmailSubjectTemplate)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse email subject template: %v", err)
+	}
+	if tmpl == "" {
+		tmpl = templates.Long
+	}
+	body, err := templates.Parse(EmailChannel, tmpl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse email body template: %v", err)
+	}
+
+	var auth smtp.Auth
+	if username != "" {
+		auth = smtp.PlainAuth("", username, password, host)
+	}
+
+	return &EmailNotifier{
+		host:    host,
+		addr:    net.JoinHostPort(host, port),
+		auth:    auth,
+		from:    from,
+		to:      to,
+		subject: subject,
+		body:    body,
+	}, nil
+}
+
+// Notify renders the events into a single email and sends it to all recipients.
+func (n *EmailNotifier) Notify(ctx context.Context, events []*types.AlertEvent) error {
+	var subject bytes.Buffer
+	if err := n.subject.Execute(&subject, EmailData{Alerts: events}); err != nil {
+		return fmt.Errorf("failed to render email subject: %v", err)
+	}
+
+	// Render every alert with its rule level template or the channel template
+	var body bytes.Buffer
+	for _, event := range events {
+		tmpl, err := channelTemplate(EmailChannel, n.body, event)
+		if err != nil {
+			return err
+		}
+		text, err := templates.Render(tmpl, event)
+		if err != nil {
+			return fmt.Errorf("failed to render email body: %v", err)
+		}
+		body.WriteString(strings.TrimRight(text, "\n"))
+		body.WriteString("\n\n")
+	}
+
+	msg := n.buildMessage(strings.TrimSpace(subject.String()), body.Bytes())
+	if err := n.send(ctx, msg); err != nil {
+		return fmt.Errorf("failed to send email: %v", err)
+	}
+
+	return nil
+}
+
+// send delivers the message like smtp.SendMail, upgrading to TLS when the server offers it,
+// but gives up after EmailTimeout or when the context is canceled.
+func (n *EmailNotifier) send(ctx context.Context, msg []byte) error {
+	dialer := net.Dialer{Timeout: EmailTimeout}
+	conn, err := dialer.DialContext(ctx, "tcp", n.addr)
+	if err != nil {
+		return err
+	}
+	deadline := time.Now().Add(EmailTimeout)
+	if ctxDeadline, ok := ctx.Deadline(); ok && ctxDeadline.Before(deadline) {
+		deadline = ctxDeadline
+	}
+	if err := conn.SetDeadline(deadline); err != nil {
+		conn.Close()
+		return err
+	}
+
+	// Closing the connection aborts a session blocked on the server
+	stop := context.AfterFunc(ctx, func() { conn.Close() })
+	defer stop()
+
+	client, err := smtp.NewClient(conn, n.host)
+	if err != nil {
+		conn.Close()
+		return err
+	}
+	defer client.Close()
+
+	if ok, _ := client.Extension("STARTTLS"); ok {
+		if err := client.StartTLS(&tls.Config{ServerName: n.host}); err != nil {
+			return err
+		}
+	}
+	if n.auth != nil {
+		if ok, _ := client.Extension("AUTH"); !ok {
+			return errors.New("server does not support AUTH")
+		}
+		if err := client.Auth(n.auth); err != nil {
+			return err
+		}
+	}
+
+	if err := client.Mail(n.from); err != nil {
+		return err
+	}
+	for _, to := range n.to {
+		if err := client.Rcpt(to); err != nil {
+			return err
+		}
+	}
+	w, err := client.Data()
+	if err != nil {
+		return err
+	}
+	if _, err := w.Write(msg); err != nil {
+		return err
+	}
+	if err := w.Close(); err != nil {
+		return err
+	}
+	return client.Quit()
+}
+
+// buildMessage assembles the headers and body of the email.
+func (n *EmailNotifier) buildMessage(subject string, body []byte) []byte {
+	var msg bytes.Buffer
+	fmt.Fprintf(&msg, "From: %s\r\n", n.from)
+	fmt.Fprintf(&msg, "To: %s\r\n", strings.Join(n.to, ", "))
+	fmt.Fprintf(&msg, "Subject: %s\r\n", subject)
+	msg.WriteString("MIME-Version: 1.0\r\n")
+	msg.WriteString("Content-Type: text/plain; charset=\"utf-8\"\r\n")
+	msg.WriteString("\r\n")
+	msg.Write(body)
+	return msg.Bytes()
+}
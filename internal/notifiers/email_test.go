@@ -0,0 +1,210 @@
+package notifiers
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"go-metrics-alerting/internal/types"
+	"net"
+	"net/textproto"
+	"strings"
+	"testing"
+	"time"
+)
+
+// smtpSession is what the fake SMTP server received during one session.
+type smtpSession struct {
+	commands []string
+	auth     string // Decoded AUTH PLAIN response
+	from     string
+	to       []string
+	data     string
+}
+
+// fakeSMTPServer accepts a single session, offering AUTH PLAIN when auth is set, and sends
+// what it received to the returned channel once the client quits or disconnects.
+func fakeSMTPServer(t *testing.T, auth bool) (net.Listener, <-chan *smtpSession) {
+	t.Helper()
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+
+	sessions := make(chan *smtpSession, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+
+		session := &smtpSession{}
+		defer func() { sessions <- session }()
+
+		text := textproto.NewConn(conn)
+		text.PrintfLine("220 fake ESMTP")
+		for {
+			line, err := text.ReadLine()
+			if err != nil {
+				return
+			}
+			verb, arg, _ := strings.Cut(line, " ")
+			verb = strings.ToUpper(verb)
+			session.commands = append(session.commands, verb)
+
+			switch verb {
+			case "EHLO":
+				if auth {
+					text.PrintfLine("250-fake")
+					text.PrintfLine("250 AUTH PLAIN")
+				} else {
+					text.PrintfLine("250 fake")
+				}
+			case "AUTH":
+				_, encoded, _ := strings.Cut(arg, " ")
+				decoded, _ := base64.StdEncoding.DecodeString(encoded)
+				session.auth = string(decoded)
+				text.PrintfLine("235 2.7.0 Authentication successful")
+			case "MAIL":
+				session.from = arg
+				text.PrintfLine("250 OK")
+			case "RCPT":
+				session.to = append(session.to, arg)
+				text.PrintfLine("250 OK")
+			case "DATA":
+				text.PrintfLine("354 End data with <CR><LF>.<CR><LF>")
+				data, err := text.ReadDotBytes()
+				if err != nil {
+					return
+				}
+				session.data = string(data)
+				text.PrintfLine("250 OK")
+			case "QUIT":
+				text.PrintfLine("221 Bye")
+				return
+			default:
+				text.PrintfLine("502 Command not implemented")
+			}
+		}
+	}()
+
+	return ln, sessions
+}
+
+func TestEmailNotifierNotify(t *testing.T) {
+	tests := []struct {
+		name         string
+		serverAuth   bool
+		username     string
+		wantCommands []string
+		wantAuth     string
+		wantErr      bool
+	}{
+		{
+			name:         "without authentication",
+			wantCommands: []string{"EHLO", "MAIL", "RCPT", "RCPT", "DATA", "QUIT"},
+		},
+		{
+			name:         "with authentication",
+			serverAuth:   true,
+			username:     "alerts",
+			wantCommands: []string{"EHLO", "AUTH", "MAIL", "RCPT", "RCPT", "DATA", "QUIT"},
+			wantAuth:     "\x00alerts\x00secret",
+		},
+		{
+			name:         "server without AUTH",
+			username:     "alerts",
+			wantCommands: []string{"EHLO"},
+			wantErr:      true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ln, sessions := fakeSMTPServer(t, tt.serverAuth)
+			defer ln.Close()
+			host, port, _ := net.SplitHostPort(ln.Addr().String())
+
+			notifier, err := NewEmailNotifier(host, port, tt.username, "secret", "alerts@example.com",
+				[]string{"ops@example.com", "dev@example.com"}, "short")
+			if err != nil {
+				t.Fatalf("NewEmailNotifier() error = %v", err)
+			}
+			err = notifier.Notify(context.Background(), []*types.AlertEvent{testEvent("high-heap")})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			var session *smtpSession
+			select {
+			case session = <-sessions:
+			case <-time.After(5 * time.Second):
+				t.Fatal("the SMTP session did not end")
+			}
+			if strings.Join(session.commands, " ") != strings.Join(tt.wantCommands, " ") {
+				t.Errorf("commands = %v, want %v", session.commands, tt.wantCommands)
+			}
+			if session.auth != tt.wantAuth {
+				t.Errorf("AUTH = %q, want %q", session.auth, tt.wantAuth)
+			}
+			if tt.wantErr {
+				return
+			}
+
+			if session.from != "FROM:<alerts@example.com>" {
+				t.Errorf("MAIL = %q, want FROM:<alerts@example.com>", session.from)
+			}
+			if strings.Join(session.to, " ") != "TO:<ops@example.com> TO:<dev@example.com>" {
+				t.Errorf("RCPT = %v, want both recipients", session.to)
+			}
+			for _, want := range []string{
+				"From: alerts@example.com\n",
+				"To: ops@example.com, dev@example.com\n",
+				"Subject: [metrics-alerting] 1 alert(s) changed state\n",
+				"[FIRING] high-heap: gauge/HeapAlloc = 42\n",
+			} {
+				if !strings.Contains(session.data, want) {
+					t.Errorf("DATA = %q, want it to contain %q", session.data, want)
+				}
+			}
+		})
+	}
+}
+
+func TestEmailNotifierStalledServer(t *testing.T) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen: %v", err)
+	}
+	defer ln.Close()
+
+	// Accept connections but never send the greeting
+	go func() {
+		for {
+			conn, err := ln.Accept()
+			if err != nil {
+				return
+			}
+			go func() {
+				defer conn.Close()
+				bufio.NewReader(conn).ReadString('\n')
+			}()
+		}
+	}()
+
+	host, port, _ := net.SplitHostPort(ln.Addr().String())
+	notifier, err := NewEmailNotifier(host, port, "", "", "alerts@example.com", []string{"ops@example.com"}, "")
+	if err != nil {
+		t.Fatalf("NewEmailNotifier() error = %v", err)
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if err := notifier.Notify(ctx, []*types.AlertEvent{testEvent("high-heap")}); err == nil {
+		t.Fatal("Notify() error = nil, want a timeout")
+	}
+	if elapsed := time.Since(start); elapsed > 5*time.Second {
+		t.Errorf("Notify() returned after %s, want it to give up at the deadline", elapsed)
+	}
+}
@@ -0,0 +1,23 @@
+package notifiers
+
+import (
+	"fmt"
+	"go-metrics-alerting/internal/templates"
+	"go-metrics-alerting/internal/types"
+	"text/template"
+)
+
+// channelTemplate returns the rule level template of the channel if the rule defines one,
+// falling back to the channel template otherwise.
+func channelTemplate(channel string, fallback *template.Template, event *types.AlertEvent) (*template.Template, error) {
+	text, ok := event.Rule.Templates[channel]
+	if !ok || text == "" {
+		return fallback, nil
+	}
+
+	tmpl, err := templates.Parse(channel, text)
+	if err != nil {
+		return nil, fmt.Errorf("rule %s: failed to parse %s template: %v", event.Rule.ID, channel, err)
+	}
+	return tmpl, nil
+}
@@ -0,0 +1,199 @@
+package notifiers
+
+import (
+	"bytes"
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"go-metrics-alerting/internal/templates"
+	"go-metrics-alerting/internal/types"
+	"net/http"
+	"text/template"
+	"time"
+)
+
+const (
+	// WebhookChannel is the channel name used to look up rule level webhook templates.
+	WebhookChannel = "webhook"
+
+	// WebhookSignatureHeader carries the HMAC-SHA256 signature of the request body.
+	WebhookSignatureHeader = "X-Signature-256"
+	// WebhookSignaturePrefix precedes the hex encoded signature in the header value.
+	WebhookSignaturePrefix = "sha256="
+)
+
+// WebhookPayload is the JSON body posted to the webhook receiver.
+type WebhookPayload struct {
+	Alerts []*types.AlertEvent `json:"alerts"`
+}
+
+// WebhookNotifier posts alert events to a configured URL.
+type WebhookNotifier struct {
+	url        string
+	secret     string
+	tmpl       *template.Template
+	maxRetries int
+	backoff    time.Duration
+	client     *http.Client
+}
+
+// NewWebhookNotifier creates a new instance of WebhookNotifier.
+// Without a template the events are posted as a single WebhookPayload, otherwise every event
+// is rendered and posted separately. A failed delivery is retried up to maxRetries times,
+// doubling the backoff after every attempt. When a later request fails, the events already
+// delivered are reported through a PartialDeliveryError.
+func NewWebhookNotifier(url, secret, tmpl string, maxRetries int, backoff time.Duration) (*WebhookNotifier, error) {
+	var parsed *template.Template
+	if tmpl != "" {
+		var err error
+		parsed, err = templates.Parse(WebhookChannel, tmpl)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse webhook template: %v", err)
+		}
+	}
+
+	return &WebhookNotifier{
+		url:        url,
+		secret:     secret,
+		tmpl:       parsed,
+		maxRetries: maxRetries,
+		backoff:    backoff,
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}, nil
+}
+
+// Notify sends the events as signed requests with retries and exponential backoff.
+func (n *WebhookNotifier) Notify(ctx context.Context, events []*types.AlertEvent) error {
+	// Render every templated event before sending anything, so that a broken template
+	// does not leave the events half delivered
+	var rendered []*types.AlertEvent
+	var bodies [][]byte
+	var plain []*types.AlertEvent
+	for _, event := range events {
+		tmpl, err := channelTemplate(WebhookChannel, n.tmpl, event)
+		if err != nil {
+			return err
+		}
+		if tmpl == nil {
+			plain = append(plain, event)
+			continue
+		}
+
+		body, err := templates.Render(tmpl, event)
+		if err != nil {
+			return fmt.Errorf("failed to render webhook template: %v", err)
+		}
+		rendered = append(rendered, event)
+		bodies = append(bodies, []byte(body))
+	}
+
+	// Events rendered by a template are delivered one by one
+	for i, body := range bodies {
+		if err := n.deliver(ctx, body); err != nil {
+			return partialDelivery(rendered[:i], err)
+		}
+	}
+	if len(plain) == 0 {
+		return nil
+	}
+
+	data, err := json.Marshal(WebhookPayload{Alerts: plain})
+	if err != nil {
+		return partialDelivery(rendered, fmt.Errorf("failed to marshal webhook payload: %v", err))
+	}
+
+	if err := n.deliver(ctx, data); err != nil {
+		return partialDelivery(rendered, err)
+	}
+	return nil
+}
+
+// PartialDeliveryError is returned when some of the events were delivered before a request failed,
+// so that a retry can leave them out.
+type PartialDeliveryError struct {
+	Delivered []*types.AlertEvent
+	Err       error
+}
+
+func (e *PartialDeliveryError) Error() string {
+	return fmt.Sprintf("delivered %d event(s) before failing: %v", len(e.Delivered), e.Err)
+}
+
+func (e *PartialDeliveryError) Unwrap() error {
+	return e.Err
+}
+
+// DeliveredEvents returns the events delivered before the failure.
+func (e *PartialDeliveryError) DeliveredEvents() []*types.AlertEvent {
+	return e.Delivered
+}
+
+// partialDelivery wraps the error in a PartialDeliveryError if any events were delivered.
+func partialDelivery(delivered []*types.AlertEvent, err error) error {
+	if len(delivered) == 0 {
+		return err
+	}
+	return &PartialDeliveryError{Delivered: delivered, Err: err}
+}
+
+// deliver posts the body, retrying retriable failures with exponential backoff.
+func (n *WebhookNotifier) deliver(ctx context.Context, data []byte) error {
+	delay := n.backoff
+	for attempt := 0; ; attempt++ {
+		retriable, err := n.send(ctx, data)
+		if err == nil {
+			return nil
+		}
+		if !retriable || attempt >= n.maxRetries {
+			return err
+		}
+
+		// Wait before the next attempt unless the context is canceled
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(delay):
+		}
+		delay *= 2
+	}
+}
+
+// send performs a single delivery attempt and reports whether a failure is worth retrying.
+func (n *WebhookNotifier) send(ctx context.Context, data []byte) (bool, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, n.url, bytes.NewReader(data))
+	if err != nil {
+		return false, err
+	}
+	if json.Valid(data) {
+		req.Header.Set("Content-Type", "application/json")
+	} else {
+		req.Header.Set("Content-Type", "text/plain; charset=utf-8")
+	}
+	if n.secret != "" {
+		req.Header.Set(WebhookSignatureHeader, WebhookSignaturePrefix+SignPayload(n.secret, data))
+	}
+
+	resp, err := n.client.Do(req)
+	if err != nil {
+		return true, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 200 && resp.StatusCode < 300 {
+		return false, nil
+	}
+
+	// Server errors and throttling are retriable, other client errors are not
+	retriable := resp.StatusCode >= 500 || resp.StatusCode == http.StatusTooManyRequests
+	return retriable, fmt.Errorf("webhook responded with status %d", resp.StatusCode)
+}
+
+// SignPayload returns the hex encoded HMAC-SHA256 of the body, so receivers can verify it.
+func SignPayload(secret string, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,287 @@
+package notifiers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"go-metrics-alerting/internal/types"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+)
+
+// webhookReceiver answers the requests with the given statuses in turn, then with 200,
+// and records the requests it received.
+type webhookReceiver struct {
+	statuses []int
+	mu       sync.Mutex
+	requests []receivedRequest
+}
+
+type receivedRequest struct {
+	at     time.Time
+	header http.Header
+	body   []byte
+}
+
+func (r *webhookReceiver) ServeHTTP(w http.ResponseWriter, req *http.Request) {
+	body, _ := io.ReadAll(req.Body)
+
+	r.mu.Lock()
+	n := len(r.requests)
+	r.requests = append(r.requests, receivedRequest{at: time.Now(), header: req.Header.Clone(), body: body})
+	r.mu.Unlock()
+
+	if n < len(r.statuses) {
+		w.WriteHeader(r.statuses[n])
+	}
+}
+
+func (r *webhookReceiver) received() []receivedRequest {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return append([]receivedRequest(nil), r.requests...)
+}
+
+func testEvent(ruleID string) *types.AlertEvent {
+	return &types.AlertEvent{
+		Rule: &types.AlertRule{ID: ruleID},
+		Alert: types.Alert{
+			RuleID: ruleID,
+			Metric: types.MetricID{ID: "HeapAlloc", Type: string(types.Gauge)},
+			State:  types.AlertFiring,
+			Value:  42,
+		},
+		PreviousState: types.AlertPending,
+		Timestamp:     time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC),
+	}
+}
+
+func TestWebhookNotifierRetries(t *testing.T) {
+	const backoff = 20 * time.Millisecond
+
+	tests := []struct {
+		name         string
+		statuses     []int
+		maxRetries   int
+		wantRequests int
+		wantErr      bool
+	}{
+		{name: "delivered at once", statuses: nil, maxRetries: 3, wantRequests: 1},
+		{name: "server errors retried", statuses: []int{500, 502}, maxRetries: 3, wantRequests: 3},
+		{name: "throttling retried", statuses: []int{429}, maxRetries: 3, wantRequests: 2},
+		{name: "retries exhausted", statuses: []int{503, 503, 503}, maxRetries: 2, wantRequests: 3, wantErr: true},
+		{name: "client errors not retried", statuses: []int{400}, maxRetries: 3, wantRequests: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := &webhookReceiver{statuses: tt.statuses}
+			server := httptest.NewServer(receiver)
+			defer server.Close()
+
+			notifier, err := NewWebhookNotifier(server.URL, "", "", tt.maxRetries, backoff)
+			if err != nil {
+				t.Fatalf("NewWebhookNotifier() error = %v", err)
+			}
+			err = notifier.Notify(context.Background(), []*types.AlertEvent{testEvent("high-heap")})
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("Notify() error = %v, wantErr %v", err, tt.wantErr)
+			}
+
+			requests := receiver.received()
+			if len(requests) != tt.wantRequests {
+				t.Fatalf("requests = %d, want %d", len(requests), tt.wantRequests)
+			}
+
+			// The delay doubles after every attempt
+			delay := backoff
+			for i := 1; i < len(requests); i++ {
+				if gap := requests[i].at.Sub(requests[i-1].at); gap < delay {
+					t.Errorf("attempt %d came %s after the previous one, want at least %s", i+1, gap, delay)
+				}
+				delay *= 2
+			}
+		})
+	}
+}
+
+func TestWebhookNotifierRetryCanceled(t *testing.T) {
+	receiver := &webhookReceiver{statuses: []int{500, 500, 500}}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	notifier, err := NewWebhookNotifier(server.URL, "", "", 3, time.Hour)
+	if err != nil {
+		t.Fatalf("NewWebhookNotifier() error = %v", err)
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+
+	err = notifier.Notify(ctx, []*types.AlertEvent{testEvent("high-heap")})
+	if !errors.Is(err, context.DeadlineExceeded) {
+		t.Errorf("Notify() error = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if requests := receiver.received(); len(requests) != 1 {
+		t.Errorf("requests = %d, want 1", len(requests))
+	}
+}
+
+func TestWebhookNotifierSignature(t *testing.T) {
+	tests := []struct {
+		name            string
+		secret          string
+		tmpl            string
+		wantContentType string
+	}{
+		{name: "signed payload", secret: "s3cret", wantContentType: "application/json"},
+		{name: "signed template", secret: "s3cret", tmpl: "short", wantContentType: "text/plain; charset=utf-8"},
+		{name: "unsigned payload", wantContentType: "application/json"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			receiver := &webhookReceiver{}
+			server := httptest.NewServer(receiver)
+			defer server.Close()
+
+			notifier, err := NewWebhookNotifier(server.URL, tt.secret, tt.tmpl, 0, 0)
+			if err != nil {
+				t.Fatalf("NewWebhookNotifier() error = %v", err)
+			}
+			event := testEvent("high-heap")
+			if err := notifier.Notify(context.Background(), []*types.AlertEvent{event}); err != nil {
+				t.Fatalf("Notify() error = %v", err)
+			}
+
+			requests := receiver.received()
+			if len(requests) != 1 {
+				t.Fatalf("requests = %d, want 1", len(requests))
+			}
+			request := requests[0]
+			if got := request.header.Get("Content-Type"); got != tt.wantContentType {
+				t.Errorf("Content-Type = %q, want %q", got, tt.wantContentType)
+			}
+
+			signature := request.header.Get(WebhookSignatureHeader)
+			if tt.secret == "" {
+				if signature != "" {
+					t.Errorf("%s = %q, want none", WebhookSignatureHeader, signature)
+				}
+			} else if want := WebhookSignaturePrefix + SignPayload(tt.secret, request.body); signature != want {
+				t.Errorf("%s = %q, want %q", WebhookSignatureHeader, signature, want)
+			}
+
+			if tt.tmpl == "" {
+				var payload WebhookPayload
+				if err := json.Unmarshal(request.body, &payload); err != nil {
+					t.Fatalf("failed to decode the payload: %v", err)
+				}
+				if len(payload.Alerts) != 1 || payload.Alerts[0].Alert.RuleID != event.Alert.RuleID {
+					t.Errorf("payload = %s, want the event", request.body)
+				}
+			}
+		})
+	}
+}
+
+func TestSignPayload(t *testing.T) {
+	// Known HMAC-SHA256 test vector (RFC 4231, test case 2)
+	got := SignPayload("Jefe", []byte("what do ya want for nothing?"))
+	want := "5bdcc146bf60754e6a042426089575c75a003f089d2739839dec58b964ec3843"
+	if got != want {
+		t.Errorf("SignPayload() = %s, want %s", got, want)
+	}
+}
+
+func TestWebhookNotifierPartialDelivery(t *testing.T) {
+	templated := func(id string) *types.AlertEvent {
+		event := testEvent(id)
+		event.Rule.Templates = map[string]string{WebhookChannel: "{{.Rule.ID}}"}
+		return event
+	}
+
+	tests := []struct {
+		name          string
+		events        []*types.AlertEvent
+		statuses      []int
+		wantDelivered []string
+	}{
+		{
+			name:     "first templated event failed",
+			events:   []*types.AlertEvent{templated("a"), templated("b")},
+			statuses: []int{400},
+		},
+		{
+			name:          "second templated event failed",
+			events:        []*types.AlertEvent{templated("a"), templated("b"), templated("c")},
+			statuses:      []int{200, 400},
+			wantDelivered: []string{"a"},
+		},
+		{
+			name:          "payload failed after the templated events",
+			events:        []*types.AlertEvent{templated("a"), testEvent("b"), templated("c")},
+			statuses:      []int{200, 200, 400},
+			wantDelivered: []string{"a", "c"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			server := httptest.NewServer(&webhookReceiver{statuses: tt.statuses})
+			defer server.Close()
+
+			notifier, err := NewWebhookNotifier(server.URL, "", "", 0, 0)
+			if err != nil {
+				t.Fatalf("NewWebhookNotifier() error = %v", err)
+			}
+			err = notifier.Notify(context.Background(), tt.events)
+			if err == nil {
+				t.Fatal("Notify() error = nil, want an error")
+			}
+
+			var partial *PartialDeliveryError
+			if !errors.As(err, &partial) {
+				if tt.wantDelivered != nil {
+					t.Fatalf("Notify() error = %v, want a PartialDeliveryError", err)
+				}
+				return
+			}
+			var delivered []string
+			for _, event := range partial.DeliveredEvents() {
+				delivered = append(delivered, event.Rule.ID)
+			}
+			if len(delivered) != len(tt.wantDelivered) {
+				t.Fatalf("delivered = %v, want %v", delivered, tt.wantDelivered)
+			}
+			for i := range delivered {
+				if delivered[i] != tt.wantDelivered[i] {
+					t.Errorf("delivered = %v, want %v", delivered, tt.wantDelivered)
+				}
+			}
+		})
+	}
+}
+
+func TestWebhookNotifierInvalidTemplate(t *testing.T) {
+	receiver := &webhookReceiver{}
+	server := httptest.NewServer(receiver)
+	defer server.Close()
+
+	notifier, err := NewWebhookNotifier(server.URL, "", "", 0, 0)
+	if err != nil {
+		t.Fatalf("NewWebhookNotifier() error = %v", err)
+	}
+	broken := testEvent("b")
+	broken.Rule.Templates = map[string]string{WebhookChannel: "{{.Missing}}"}
+
+	if err := notifier.Notify(context.Background(), []*types.AlertEvent{testEvent("a"), broken}); err == nil {
+		t.Fatal("Notify() error = nil, want an error")
+	}
+	if requests := receiver.received(); len(requests) != 0 {
+		t.Errorf("requests = %d, want nothing sent", len(requests))
+	}
+}
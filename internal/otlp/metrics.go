@@ -0,0 +1,465 @@
+// Package otlp decodes the ExportMetricsServiceRequest message of the OpenTelemetry protocol
+// from its protobuf and JSON encodings; the JSON form is decoded with encoding/json. Only gauges and sums are decoded; histograms,
+// summaries, exemplars and other fields not needed to ingest values are skipped.
+package otlp
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"math"
+	"strconv"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// AggregationTemporality tells whether the values of a sum are increments or running totals.
+type AggregationTemporality int32
+
+const (
+	AggregationTemporalityUnspecified AggregationTemporality = 0
+	AggregationTemporalityDelta       AggregationTemporality = 1
+	AggregationTemporalityCumulative  AggregationTemporality = 2
+)
+
+type ExportMetricsServiceRequest struct {
+	ResourceMetrics []ResourceMetrics `json:"resourceMetrics"`
+}
+
+type ResourceMetrics struct {
+	Resource     Resource       `json:"resource"`
+	ScopeMetrics []ScopeMetrics `json:"scopeMetrics"`
+}
+
+type Resource struct {
+	Attributes []KeyValue `json:"attributes"`
+}
+
+type ScopeMetrics struct {
+	Metrics []Metric `json:"metrics"`
+}
+
+// Metric holds the data of one of the supported kinds; Gauge and Sum are nil for other kinds.
+type Metric struct {
+	Name  string `json:"name"`
+	Gauge *Gauge `json:"gauge"`
+	Sum   *Sum   `json:"sum"`
+}
+
+type Gauge struct {
+	DataPoints []NumberDataPoint `json:"dataPoints"`
+}
+
+type Sum struct {
+	DataPoints             []NumberDataPoint      `json:"dataPoints"`
+	AggregationTemporality AggregationTemporality `json:"aggregationTemporality"`
+	IsMonotonic            bool                   `json:"isMonotonic"`
+}
+
+// NumberDataPoint carries either a double or an integer value.
+type NumberDataPoint struct {
+	Attributes   []KeyValue `json:"attributes"`
+	TimeUnixNano Int64      `json:"timeUnixNano"`
+	AsDouble     *Double    `json:"asDouble"`
+	AsInt        *Int64     `json:"asInt"`
+}
+
+type KeyValue struct {
+	Key   string   `json:"key"`
+	Value AnyValue `json:"value"`
+}
+
+// AnyValue holds at most one of its values.
+type AnyValue struct {
+	StringValue *string       `json:"stringValue"`
+	BoolValue   *bool         `json:"boolValue"`
+	IntValue    *Int64        `json:"intValue"`
+	DoubleValue *Double       `json:"doubleValue"`
+	ArrayValue  *ArrayValue   `json:"arrayValue"`
+	KvlistValue *KeyValueList `json:"kvlistValue"`
+	BytesValue  []byte        `json:"bytesValue"`
+}
+
+type ArrayValue struct {
+	Values []AnyValue `json:"values"`
+}
+
+type KeyValueList struct {
+	Values []KeyValue `json:"values"`
+}
+
+// Int64 is an integer that the JSON encoding may carry as a string.
+type Int64 int64
+
+// Double is a float that the JSON encoding may carry as a string, e.g. "NaN" or "Infinity".
+type Double float64
+
+// Value returns the value of the data point as a float.
+func (p *NumberDataPoint) Value() (float64, bool) {
+	switch {
+	case p.AsDouble != nil:
+		return float64(*p.AsDouble), true
+	case p.AsInt != nil:
+		return float64(*p.AsInt), true
+	}
+	return 0, false
+}
+
+// String renders the value as a label value. Bytes are rendered as base64, arrays and
+// key-value lists as JSON.
+func (v *AnyValue) String() string {
+	switch {
+	case v.StringValue != nil:
+		return *v.StringValue
+	case v.BoolValue != nil:
+		return strconv.FormatBool(*v.BoolValue)
+	case v.IntValue != nil:
+		return strconv.FormatInt(int64(*v.IntValue), 10)
+	case v.DoubleValue != nil:
+		return strconv.FormatFloat(float64(*v.DoubleValue), 'g', -1, 64)
+	case v.BytesValue != nil:
+		return base64.StdEncoding.EncodeToString(v.BytesValue)
+	case v.ArrayValue != nil:
+		values := make([]any, 0, len(v.ArrayValue.Values))
+		for _, value := range v.ArrayValue.Values {
+			values = append(values, value.plain())
+		}
+		data, _ := json.Marshal(values)
+		return string(data)
+	case v.KvlistValue != nil:
+		data, _ := json.Marshal(v.plain())
+		return string(data)
+	}
+	return ""
+}
+
+// plain converts the value into the matching Go value for JSON rendering.
+func (v *AnyValue) plain() any {
+	switch {
+	case v.ArrayValue != nil:
+		values := make([]any, 0, len(v.ArrayValue.Values))
+		for _, value := range v.ArrayValue.Values {
+			values = append(values, value.plain())
+		}
+		return values
+	case v.KvlistValue != nil:
+		values := make(map[string]any, len(v.KvlistValue.Values))
+		for _, kv := range v.KvlistValue.Values {
+			values[kv.Key] = kv.Value.plain()
+		}
+		return values
+	}
+	return v.String()
+}
+
+// UnmarshalJSON accepts both the number and the string form.
+func (i *Int64) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		text = string(data)
+	}
+	value, err := strconv.ParseInt(text, 10, 64)
+	if err != nil {
+		return fmt.Errorf("invalid integer %s", data)
+	}
+	*i = Int64(value)
+	return nil
+}
+
+// UnmarshalJSON accepts both the number and the string form, including NaN and infinities.
+func (d *Double) UnmarshalJSON(data []byte) error {
+	var text string
+	if err := json.Unmarshal(data, &text); err != nil {
+		text = string(data)
+	}
+	switch text {
+	case "NaN":
+		*d = Double(math.NaN())
+		return nil
+	case "Infinity":
+		*d = Double(math.Inf(1))
+		return nil
+	case "-Infinity":
+		*d = Double(math.Inf(-1))
+		return nil
+	}
+	value, err := strconv.ParseFloat(text, 64)
+	if err != nil {
+		return fmt.Errorf("invalid number %s", data)
+	}
+	*d = Double(value)
+	return nil
+}
+
+// Unmarshal decodes the protobuf encoding of the request.
+func (m *ExportMetricsServiceRequest) Unmarshal(b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num == 1 && typ == protowire.BytesType {
+			return decodeEmbedded(b, "resource_metrics", func(data []byte) error {
+				var rm ResourceMetrics
+				if err := rm.Unmarshal(data); err != nil {
+					return err
+				}
+				m.ResourceMetrics = append(m.ResourceMetrics, rm)
+				return nil
+			})
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+// Unmarshal decodes a ResourceMetrics message.
+func (m *ResourceMetrics) Unmarshal(b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			return decodeEmbedded(b, "resource", m.Resource.Unmarshal)
+		case num == 2 && typ == protowire.BytesType:
+			return decodeEmbedded(b, "scope_metrics", func(data []byte) error {
+				var sm ScopeMetrics
+				if err := sm.Unmarshal(data); err != nil {
+					return err
+				}
+				m.ScopeMetrics = append(m.ScopeMetrics, sm)
+				return nil
+			})
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+// Unmarshal decodes a Resource message.
+func (m *Resource) Unmarshal(b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num == 1 && typ == protowire.BytesType {
+			return decodeEmbedded(b, "attribute", func(data []byte) error {
+				return appendKeyValue(&m.Attributes, data)
+			})
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+// Unmarshal decodes a ScopeMetrics message, skipping the instrumentation scope.
+func (m *ScopeMetrics) Unmarshal(b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num == 2 && typ == protowire.BytesType {
+			return decodeEmbedded(b, "metric", func(data []byte) error {
+				var metric Metric
+				if err := metric.Unmarshal(data); err != nil {
+					return err
+				}
+				m.Metrics = append(m.Metrics, metric)
+				return nil
+			})
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+// Unmarshal decodes a Metric message. Data of unsupported kinds is skipped.
+func (m *Metric) Unmarshal(b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			value, n := protowire.ConsumeString(b)
+			m.Name = value
+			return n, nil
+		case num == 5 && typ == protowire.BytesType:
+			m.Gauge = &Gauge{}
+			return decodeEmbedded(b, "gauge", m.Gauge.Unmarshal)
+		case num == 7 && typ == protowire.BytesType:
+			m.Sum = &Sum{}
+			return decodeEmbedded(b, "sum", m.Sum.Unmarshal)
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+// Unmarshal decodes a Gauge message.
+func (m *Gauge) Unmarshal(b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if num == 1 && typ == protowire.BytesType {
+			return decodeEmbedded(b, "data_point", func(data []byte) error {
+				return appendDataPoint(&m.DataPoints, data)
+			})
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+// Unmarshal decodes a Sum message.
+func (m *Sum) Unmarshal(b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			return decodeEmbedded(b, "data_point", func(data []byte) error {
+				return appendDataPoint(&m.DataPoints, data)
+			})
+		case num == 2 && typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b)
+			m.AggregationTemporality = AggregationTemporality(value)
+			return n, nil
+		case num == 3 && typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b)
+			m.IsMonotonic = protowire.DecodeBool(value)
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+// Unmarshal decodes a NumberDataPoint message.
+func (m *NumberDataPoint) Unmarshal(b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 3 && typ == protowire.Fixed64Type:
+			value, n := protowire.ConsumeFixed64(b)
+			m.TimeUnixNano = Int64(value)
+			return n, nil
+		case num == 4 && typ == protowire.Fixed64Type:
+			bits, n := protowire.ConsumeFixed64(b)
+			value := Double(math.Float64frombits(bits))
+			m.AsDouble, m.AsInt = &value, nil
+			return n, nil
+		case num == 6 && typ == protowire.Fixed64Type:
+			bits, n := protowire.ConsumeFixed64(b)
+			value := Int64(bits)
+			m.AsDouble, m.AsInt = nil, &value
+			return n, nil
+		case num == 7 && typ == protowire.BytesType:
+			return decodeEmbedded(b, "attribute", func(data []byte) error {
+				return appendKeyValue(&m.Attributes, data)
+			})
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+// Unmarshal decodes a KeyValue message.
+func (m *KeyValue) Unmarshal(b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			value, n := protowire.ConsumeString(b)
+			m.Key = value
+			return n, nil
+		case num == 2 && typ == protowire.BytesType:
+			return decodeEmbedded(b, "value", m.Value.Unmarshal)
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+// Unmarshal decodes an AnyValue message.
+func (m *AnyValue) Unmarshal(b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			value, n := protowire.ConsumeString(b)
+			*m = AnyValue{StringValue: &value}
+			return n, nil
+		case num == 2 && typ == protowire.VarintType:
+			bits, n := protowire.ConsumeVarint(b)
+			value := protowire.DecodeBool(bits)
+			*m = AnyValue{BoolValue: &value}
+			return n, nil
+		case num == 3 && typ == protowire.VarintType:
+			bits, n := protowire.ConsumeVarint(b)
+			value := Int64(bits)
+			*m = AnyValue{IntValue: &value}
+			return n, nil
+		case num == 4 && typ == protowire.Fixed64Type:
+			bits, n := protowire.ConsumeFixed64(b)
+			value := Double(math.Float64frombits(bits))
+			*m = AnyValue{DoubleValue: &value}
+			return n, nil
+		case num == 5 && typ == protowire.BytesType:
+			*m = AnyValue{ArrayValue: &ArrayValue{}}
+			return decodeEmbedded(b, "array_value", func(data []byte) error {
+				return decodeMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+					if num == 1 && typ == protowire.BytesType {
+						return decodeEmbedded(b, "value", func(data []byte) error {
+							var value AnyValue
+							if err := value.Unmarshal(data); err != nil {
+								return err
+							}
+							m.ArrayValue.Values = append(m.ArrayValue.Values, value)
+							return nil
+						})
+					}
+					return protowire.ConsumeFieldValue(num, typ, b), nil
+				})
+			})
+		case num == 6 && typ == protowire.BytesType:
+			*m = AnyValue{KvlistValue: &KeyValueList{}}
+			return decodeEmbedded(b, "kvlist_value", func(data []byte) error {
+				return decodeMessage(data, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+					if num == 1 && typ == protowire.BytesType {
+						return decodeEmbedded(b, "value", func(data []byte) error {
+							return appendKeyValue(&m.KvlistValue.Values, data)
+						})
+					}
+					return protowire.ConsumeFieldValue(num, typ, b), nil
+				})
+			})
+		case num == 7 && typ == protowire.BytesType:
+			value, n := protowire.ConsumeBytes(b)
+			*m = AnyValue{BytesValue: append([]byte{}, value...)}
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+func appendKeyValue(values *[]KeyValue, data []byte) error {
+	var kv KeyValue
+	if err := kv.Unmarshal(data); err != nil {
+		return err
+	}
+	*values = append(*values, kv)
+	return nil
+}
+
+func appendDataPoint(points *[]NumberDataPoint, data []byte) error {
+	var point NumberDataPoint
+	if err := point.Unmarshal(data); err != nil {
+		return err
+	}
+	*points = append(*points, point)
+	return nil
+}
+
+// decodeEmbedded consumes a length-delimited field and decodes it as a message.
+func decodeEmbedded(b []byte, name string, decode func(data []byte) error) (int, error) {
+	data, n := protowire.ConsumeBytes(b)
+	if n < 0 {
+		return n, nil
+	}
+	if err := decode(data); err != nil {
+		return 0, fmt.Errorf("%s: %w", name, err)
+	}
+	return n, nil
+}
+
+// decodeMessage walks the fields of a message. The field function consumes the value
+// of a field and returns its length, or a negative length on malformed input.
+func decodeMessage(b []byte, field func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		n, err := field(num, typ, b)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return fmt.Errorf("field %d: %w", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+	return nil
+}
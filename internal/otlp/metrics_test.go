@@ -0,0 +1,281 @@
+package otlp
+
+import (
+	"encoding/json"
+	"math"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+func appendMessage(b []byte, num protowire.Number, message []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, message)
+}
+
+func appendString(b []byte, num protowire.Number, value string) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
+
+func appendFixed64(b []byte, num protowire.Number, value uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.Fixed64Type)
+	return protowire.AppendFixed64(b, value)
+}
+
+func appendVarint(b []byte, num protowire.Number, value uint64) []byte {
+	b = protowire.AppendTag(b, num, protowire.VarintType)
+	return protowire.AppendVarint(b, value)
+}
+
+// encodeRequest wraps the metrics into a request with a single resource and scope.
+func encodeRequest(resourceAttributes []byte, metrics ...[]byte) []byte {
+	var scope []byte
+	scope = appendMessage(scope, 1, appendString(nil, 1, "scope-name")) // Skipped
+	for _, metric := range metrics {
+		scope = appendMessage(scope, 2, metric)
+	}
+	resourceMetrics := appendMessage(nil, 1, resourceAttributes)
+	resourceMetrics = appendMessage(resourceMetrics, 2, scope)
+	return appendMessage(nil, 1, resourceMetrics)
+}
+
+func encodeKeyValue(key string, value []byte) []byte {
+	return appendMessage(appendString(nil, 1, key), 2, value)
+}
+
+func doublePtr(v float64) *Double { d := Double(v); return &d }
+func int64Ptr(v int64) *Int64     { i := Int64(v); return &i }
+func stringPtr(v string) *string  { return &v }
+func boolPtr(v bool) *bool        { return &v }
+
+func TestExportMetricsServiceRequestUnmarshal(t *testing.T) {
+	resource := appendMessage(nil, 1, encodeKeyValue("service.name", appendString(nil, 1, "api")))
+
+	gaugePoint := appendFixed64(nil, 3, 1700000000000000000)
+	gaugePoint = appendFixed64(gaugePoint, 4, math.Float64bits(0.25))
+	gaugePoint = appendMessage(gaugePoint, 7, encodeKeyValue("cpu", appendVarint(nil, 3, 2)))
+	gauge := appendString(nil, 1, "cpu.utilization")
+	gauge = appendString(gauge, 2, "description") // Skipped
+	gauge = appendMessage(gauge, 5, appendMessage(nil, 1, gaugePoint))
+
+	sumPoint := appendFixed64(nil, 6, 42)
+	sumPoint = appendMessage(sumPoint, 7, encodeKeyValue("ok", appendVarint(nil, 2, 1)))
+	sumData := appendMessage(nil, 1, sumPoint)
+	sumData = appendVarint(sumData, 2, uint64(AggregationTemporalityCumulative))
+	sumData = appendVarint(sumData, 3, 1)
+	sum := appendString(nil, 1, "http.requests")
+	sum = appendMessage(sum, 7, sumData)
+
+	histogram := appendString(nil, 1, "latency")
+	histogram = appendMessage(histogram, 9, appendVarint(nil, 2, 1)) // Unsupported kind
+
+	array := appendMessage(nil, 1, appendString(nil, 1, "a"))
+	array = appendMessage(array, 1, appendFixed64(nil, 4, math.Float64bits(1.5)))
+	arrayPoint := appendFixed64(nil, 4, 0)
+	arrayPoint = appendMessage(arrayPoint, 7, encodeKeyValue("tags", appendMessage(nil, 5, array)))
+	withAttributes := appendString(nil, 1, "attributes")
+	withAttributes = appendMessage(withAttributes, 5, appendMessage(nil, 1, arrayPoint))
+
+	tests := []struct {
+		name  string
+		input []byte
+		want  []Metric
+	}{
+		{
+			name:  "gauge with a double value",
+			input: encodeRequest(resource, gauge),
+			want: []Metric{{
+				Name: "cpu.utilization",
+				Gauge: &Gauge{DataPoints: []NumberDataPoint{{
+					Attributes:   []KeyValue{{Key: "cpu", Value: AnyValue{IntValue: int64Ptr(2)}}},
+					TimeUnixNano: 1700000000000000000,
+					AsDouble:     doublePtr(0.25),
+				}}},
+			}},
+		},
+		{
+			name:  "cumulative monotonic sum with an integer value",
+			input: encodeRequest(resource, sum),
+			want: []Metric{{
+				Name: "http.requests",
+				Sum: &Sum{
+					DataPoints: []NumberDataPoint{{
+						Attributes: []KeyValue{{Key: "ok", Value: AnyValue{BoolValue: boolPtr(true)}}},
+						AsInt:      int64Ptr(42),
+					}},
+					AggregationTemporality: AggregationTemporalityCumulative,
+					IsMonotonic:            true,
+				},
+			}},
+		},
+		{
+			name:  "unsupported kind",
+			input: encodeRequest(resource, histogram),
+			want:  []Metric{{Name: "latency"}},
+		},
+		{
+			name:  "array attribute",
+			input: encodeRequest(resource, withAttributes),
+			want: []Metric{{
+				Name: "attributes",
+				Gauge: &Gauge{DataPoints: []NumberDataPoint{{
+					AsDouble: doublePtr(0),
+					Attributes: []KeyValue{{Key: "tags", Value: AnyValue{ArrayValue: &ArrayValue{Values: []AnyValue{
+						{StringValue: stringPtr("a")},
+						{DoubleValue: doublePtr(1.5)},
+					}}}}},
+				}}},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request ExportMetricsServiceRequest
+			if err := request.Unmarshal(tt.input); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if len(request.ResourceMetrics) != 1 || len(request.ResourceMetrics[0].ScopeMetrics) != 1 {
+				t.Fatalf("Unmarshal() = %+v, want a single resource and scope", request)
+			}
+
+			wantResource := Resource{Attributes: []KeyValue{{Key: "service.name", Value: AnyValue{StringValue: stringPtr("api")}}}}
+			if got := request.ResourceMetrics[0].Resource; !reflect.DeepEqual(got, wantResource) {
+				t.Errorf("Resource = %+v, want %+v", got, wantResource)
+			}
+			if got := request.ResourceMetrics[0].ScopeMetrics[0].Metrics; !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Metrics = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExportMetricsServiceRequestUnmarshalErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{name: "truncated tag", input: []byte{0x80}},
+		{name: "truncated resource metrics", input: []byte{0x0a, 0x05, 0x12}},
+		{name: "malformed metric", input: encodeRequest(nil, []byte{0x0a, 0x09})},
+		{name: "truncated data point", input: encodeRequest(nil, appendMessage(nil, 5, appendMessage(nil, 1, []byte{0x21, 0x00})))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request ExportMetricsServiceRequest
+			if err := request.Unmarshal(tt.input); err == nil {
+				t.Errorf("Unmarshal() = %+v, want an error", request)
+			}
+		})
+	}
+}
+
+func TestExportMetricsServiceRequestJSON(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  NumberDataPoint
+	}{
+		{
+			name:  "integer as a string",
+			input: `{"timeUnixNano": "1700000000000000000", "asInt": "42"}`,
+			want:  NumberDataPoint{TimeUnixNano: 1700000000000000000, AsInt: int64Ptr(42)},
+		},
+		{
+			name:  "double as a number",
+			input: `{"asDouble": 1.5, "attributes": [{"key": "host", "value": {"stringValue": "a"}}]}`,
+			want: NumberDataPoint{
+				AsDouble:   doublePtr(1.5),
+				Attributes: []KeyValue{{Key: "host", Value: AnyValue{StringValue: stringPtr("a")}}},
+			},
+		},
+		{
+			name:  "infinite double as a string",
+			input: `{"asDouble": "-Infinity"}`,
+			want:  NumberDataPoint{AsDouble: doublePtr(math.Inf(-1))},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var point NumberDataPoint
+			if err := json.Unmarshal([]byte(tt.input), &point); err != nil {
+				t.Fatalf("json.Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(point, tt.want) {
+				t.Errorf("json.Unmarshal() = %+v, want %+v", point, tt.want)
+			}
+		})
+	}
+
+	for _, input := range []string{`{"asInt": "4.2"}`, `{"asDouble": "lots"}`} {
+		var point NumberDataPoint
+		if err := json.Unmarshal([]byte(input), &point); err == nil {
+			t.Errorf("json.Unmarshal(%s) = %+v, want an error", input, point)
+		}
+	}
+}
+
+func TestNumberDataPointValue(t *testing.T) {
+	tests := []struct {
+		name   string
+		point  NumberDataPoint
+		want   float64
+		wantOK bool
+	}{
+		{name: "double", point: NumberDataPoint{AsDouble: doublePtr(2.5)}, want: 2.5, wantOK: true},
+		{name: "integer", point: NumberDataPoint{AsInt: int64Ptr(-3)}, want: -3, wantOK: true},
+		{name: "no value", point: NumberDataPoint{}, want: 0, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := tt.point.Value()
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("Value() = %v, %v, want %v, %v", got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
+
+func TestAnyValueString(t *testing.T) {
+	tests := []struct {
+		name  string
+		value AnyValue
+		want  string
+	}{
+		{name: "string", value: AnyValue{StringValue: stringPtr("a b")}, want: "a b"},
+		{name: "bool", value: AnyValue{BoolValue: boolPtr(false)}, want: "false"},
+		{name: "integer", value: AnyValue{IntValue: int64Ptr(-7)}, want: "-7"},
+		{name: "double", value: AnyValue{DoubleValue: doublePtr(0.5)}, want: "0.5"},
+		{name: "bytes", value: AnyValue{BytesValue: []byte{1, 2}}, want: "AQI="},
+		{
+			name: "array",
+			value: AnyValue{ArrayValue: &ArrayValue{Values: []AnyValue{
+				{StringValue: stringPtr("x")},
+				{IntValue: int64Ptr(1)},
+			}}},
+			want: `["x","1"]`,
+		},
+		{
+			name: "key-value list",
+			value: AnyValue{KvlistValue: &KeyValueList{Values: []KeyValue{
+				{Key: "b", Value: AnyValue{BoolValue: boolPtr(true)}},
+				{Key: "a", Value: AnyValue{ArrayValue: &ArrayValue{}}},
+			}}},
+			want: `{"a":[],"b":"true"}`,
+		},
+		{name: "empty", value: AnyValue{}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.value.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+// Package prompb decodes the protobuf messages of the Prometheus remote write protocol.
+// Only the fields needed to ingest float samples are decoded, everything else is skipped.
+package prompb
+
+import (
+	"fmt"
+	"math"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// MetricType is the type of a metric family announced in the request metadata.
+type MetricType int32
+
+const (
+	MetricTypeUnknown        MetricType = 0
+	MetricTypeCounter        MetricType = 1
+	MetricTypeGauge          MetricType = 2
+	MetricTypeHistogram      MetricType = 3
+	MetricTypeGaugeHistogram MetricType = 4
+	MetricTypeSummary        MetricType = 5
+	MetricTypeInfo           MetricType = 6
+	MetricTypeStateset       MetricType = 7
+)
+
+// MetricNameLabel is the label holding the metric name of a series.
+const MetricNameLabel = "__name__"
+
+type WriteRequest struct {
+	Timeseries []TimeSeries
+	Metadata   []MetricMetadata
+}
+
+type TimeSeries struct {
+	Labels  []Label
+	Samples []Sample
+}
+
+type Label struct {
+	Name  string
+	Value string
+}
+
+type Sample struct {
+	Value     float64
+	Timestamp int64 // Milliseconds since the epoch
+}
+
+type MetricMetadata struct {
+	Type             MetricType
+	MetricFamilyName string
+}
+
+// Unmarshal decodes a WriteRequest message.
+func (m *WriteRequest) Unmarshal(b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			data, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var ts TimeSeries
+			if err := ts.Unmarshal(data); err != nil {
+				return 0, fmt.Errorf("timeseries: %w", err)
+			}
+			m.Timeseries = append(m.Timeseries, ts)
+			return n, nil
+		case num == 3 && typ == protowire.BytesType:
+			data, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var md MetricMetadata
+			if err := md.Unmarshal(data); err != nil {
+				return 0, fmt.Errorf("metadata: %w", err)
+			}
+			m.Metadata = append(m.Metadata, md)
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+// Unmarshal decodes a TimeSeries message, skipping exemplars and native histograms.
+func (m *TimeSeries) Unmarshal(b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.BytesType:
+			data, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var label Label
+			if err := label.Unmarshal(data); err != nil {
+				return 0, fmt.Errorf("label: %w", err)
+			}
+			m.Labels = append(m.Labels, label)
+			return n, nil
+		case num == 2 && typ == protowire.BytesType:
+			data, n := protowire.ConsumeBytes(b)
+			if n < 0 {
+				return n, nil
+			}
+			var sample Sample
+			if err := sample.Unmarshal(data); err != nil {
+				return 0, fmt.Errorf("sample: %w", err)
+			}
+			m.Samples = append(m.Samples, sample)
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+// Unmarshal decodes a Label message.
+func (m *Label) Unmarshal(b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		if (num == 1 || num == 2) && typ == protowire.BytesType {
+			value, n := protowire.ConsumeString(b)
+			if num == 1 {
+				m.Name = value
+			} else {
+				m.Value = value
+			}
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+// Unmarshal decodes a Sample message.
+func (m *Sample) Unmarshal(b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.Fixed64Type:
+			bits, n := protowire.ConsumeFixed64(b)
+			m.Value = math.Float64frombits(bits)
+			return n, nil
+		case num == 2 && typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b)
+			m.Timestamp = int64(value)
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+// Unmarshal decodes a MetricMetadata message.
+func (m *MetricMetadata) Unmarshal(b []byte) error {
+	return decodeMessage(b, func(num protowire.Number, typ protowire.Type, b []byte) (int, error) {
+		switch {
+		case num == 1 && typ == protowire.VarintType:
+			value, n := protowire.ConsumeVarint(b)
+			m.Type = MetricType(value)
+			return n, nil
+		case num == 2 && typ == protowire.BytesType:
+			value, n := protowire.ConsumeString(b)
+			m.MetricFamilyName = value
+			return n, nil
+		}
+		return protowire.ConsumeFieldValue(num, typ, b), nil
+	})
+}
+
+// Name returns the value of the __name__ label of the series.
+func (m *TimeSeries) Name() string {
+	for _, label := range m.Labels {
+		if label.Name == MetricNameLabel {
+			return label.Value
+		}
+	}
+	return ""
+}
+
+// decodeMessage walks the fields of a message. The field function consumes the value
+// of a field and returns its length, or a negative length on malformed input.
+func decodeMessage(b []byte, field func(num protowire.Number, typ protowire.Type, b []byte) (int, error)) error {
+	for len(b) > 0 {
+		num, typ, n := protowire.ConsumeTag(b)
+		if n < 0 {
+			return protowire.ParseError(n)
+		}
+		b = b[n:]
+
+		n, err := field(num, typ, b)
+		if err != nil {
+			return err
+		}
+		if n < 0 {
+			return fmt.Errorf("field %d: %w", num, protowire.ParseError(n))
+		}
+		b = b[n:]
+	}
+	return nil
+}
@@ -0,0 +1,137 @@
+package prompb
+
+import (
+	"math"
+	"reflect"
+	"testing"
+
+	"google.golang.org/protobuf/encoding/protowire"
+)
+
+// appendMessage appends an embedded message field.
+func appendMessage(b []byte, num protowire.Number, message []byte) []byte {
+	b = protowire.AppendTag(b, num, protowire.BytesType)
+	return protowire.AppendBytes(b, message)
+}
+
+func encodeLabel(name, value string) []byte {
+	b := protowire.AppendTag(nil, 1, protowire.BytesType)
+	b = protowire.AppendString(b, name)
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	return protowire.AppendString(b, value)
+}
+
+func encodeSample(value float64, timestamp int64) []byte {
+	b := protowire.AppendTag(nil, 1, protowire.Fixed64Type)
+	b = protowire.AppendFixed64(b, math.Float64bits(value))
+	b = protowire.AppendTag(b, 2, protowire.VarintType)
+	return protowire.AppendVarint(b, uint64(timestamp))
+}
+
+func encodeMetadata(metricType MetricType, family string) []byte {
+	b := protowire.AppendTag(nil, 1, protowire.VarintType)
+	b = protowire.AppendVarint(b, uint64(metricType))
+	b = protowire.AppendTag(b, 2, protowire.BytesType)
+	return protowire.AppendString(b, family)
+}
+
+func TestWriteRequestUnmarshal(t *testing.T) {
+	series := appendMessage(nil, 1, encodeLabel(MetricNameLabel, "http_requests_total"))
+	series = appendMessage(series, 1, encodeLabel("job", "api"))
+	series = appendMessage(series, 2, encodeSample(5, 1000))
+	series = appendMessage(series, 2, encodeSample(7.5, 2000))
+
+	// Fields the decoder does not know, like exemplars and the help text, are skipped
+	unknown := protowire.AppendTag(nil, 9, protowire.VarintType)
+	unknown = protowire.AppendVarint(unknown, 1)
+	unknown = protowire.AppendTag(unknown, 10, protowire.BytesType)
+	unknown = protowire.AppendString(unknown, "skipped")
+	label := append(encodeLabel("a", "b"), unknown...)
+	withUnknown := append(appendMessage(nil, 1, append(appendMessage(nil, 1, label), unknown...)), unknown...)
+
+	tests := []struct {
+		name  string
+		input []byte
+		want  WriteRequest
+	}{
+		{
+			name:  "empty request",
+			input: nil,
+			want:  WriteRequest{},
+		},
+		{
+			name:  "series with labels and samples",
+			input: appendMessage(nil, 1, series),
+			want: WriteRequest{Timeseries: []TimeSeries{{
+				Labels:  []Label{{Name: MetricNameLabel, Value: "http_requests_total"}, {Name: "job", Value: "api"}},
+				Samples: []Sample{{Value: 5, Timestamp: 1000}, {Value: 7.5, Timestamp: 2000}},
+			}}},
+		},
+		{
+			name:  "metadata",
+			input: appendMessage(nil, 3, encodeMetadata(MetricTypeCounter, "http_requests_total")),
+			want:  WriteRequest{Metadata: []MetricMetadata{{Type: MetricTypeCounter, MetricFamilyName: "http_requests_total"}}},
+		},
+		{
+			name:  "unknown fields",
+			input: withUnknown,
+			want:  WriteRequest{Timeseries: []TimeSeries{{Labels: []Label{{Name: "a", Value: "b"}}}}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got WriteRequest
+			if err := got.Unmarshal(tt.input); err != nil {
+				t.Fatalf("Unmarshal() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Unmarshal() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWriteRequestUnmarshalErrors(t *testing.T) {
+	truncatedSample := encodeSample(1, 1)
+	truncatedSample = truncatedSample[:len(truncatedSample)-1]
+
+	tests := []struct {
+		name  string
+		input []byte
+	}{
+		{name: "truncated tag", input: []byte{0x80}},
+		{name: "truncated length", input: []byte{0x0a, 0x05, 0x01}},
+		{name: "malformed nested sample", input: appendMessage(nil, 1, appendMessage(nil, 2, truncatedSample))},
+		{name: "malformed nested label", input: appendMessage(nil, 1, appendMessage(nil, 1, []byte{0x0a, 0x09}))},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var request WriteRequest
+			if err := request.Unmarshal(tt.input); err == nil {
+				t.Errorf("Unmarshal() = %+v, want an error", request)
+			}
+		})
+	}
+}
+
+func TestTimeSeriesName(t *testing.T) {
+	tests := []struct {
+		name   string
+		labels []Label
+		want   string
+	}{
+		{name: "name label", labels: []Label{{Name: "job", Value: "api"}, {Name: MetricNameLabel, Value: "up"}}, want: "up"},
+		{name: "no name label", labels: []Label{{Name: "job", Value: "api"}}, want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			series := TimeSeries{Labels: tt.labels}
+			if got := series.Name(); got != tt.want {
+				t.Errorf("Name() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
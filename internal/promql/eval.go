@@ -0,0 +1,256 @@
+package promql
+
+import (
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/types"
+	"math"
+	"sort"
+	"time"
+)
+
+// LookbackDelta is how far back an instant selector looks for the latest sample of a series.
+const LookbackDelta = 5 * time.Minute
+
+// MaxPoints limits the number of steps of a range query.
+const MaxPoints = 11000
+
+// ErrTooManyPoints is returned when a range query would produce more than MaxPoints steps.
+var ErrTooManyPoints = errors.New("exceeded maximum resolution of 11,000 points per timeseries, try decreasing the query resolution")
+
+// Series is a labeled series with its samples in ascending time order.
+type Series struct {
+	Labels  map[string]string
+	Samples []types.Sample
+}
+
+// Value is the result of an instant query: a Scalar or a Vector.
+type Value interface {
+	Type() ValueType
+}
+
+// Scalar is a single number.
+type Scalar float64
+
+// Sample is the value of a series at the evaluation time.
+type Sample struct {
+	Labels map[string]string
+	Value  float64
+}
+
+// Vector holds one sample per series.
+type Vector []Sample
+
+// Matrix holds the samples of every series of a range query, one per step.
+type Matrix []*Series
+
+func (Scalar) Type() ValueType { return ValueTypeScalar }
+func (Vector) Type() ValueType { return ValueTypeVector }
+func (Matrix) Type() ValueType { return ValueTypeMatrix }
+
+// Eval evaluates a parsed query at the given time against the series.
+func Eval(node Node, series []*Series, t time.Time) (Value, error) {
+	if literal, ok := node.(*NumberLiteral); ok {
+		return Scalar(literal.Value), nil
+	}
+	vector, err := evalVector(node, series, t)
+	if err != nil {
+		return nil, err
+	}
+	return vector, nil
+}
+
+// EvalRange evaluates a parsed query at every step from start to end. Scalars become
+// a single series without labels.
+func EvalRange(node Node, series []*Series, start, end time.Time, step time.Duration) (Matrix, error) {
+	if step <= 0 || end.Before(start) {
+		return nil, fmt.Errorf("invalid range")
+	}
+	if end.Sub(start)/step+1 > MaxPoints {
+		return nil, ErrTooManyPoints
+	}
+
+	byKey := make(map[string]*Series)
+	for t := start; !t.After(end); t = t.Add(step) {
+		value, err := Eval(node, series, t)
+		if err != nil {
+			return nil, err
+		}
+
+		var samples Vector
+		switch v := value.(type) {
+		case Scalar:
+			samples = Vector{{Labels: map[string]string{}, Value: float64(v)}}
+		case Vector:
+			samples = v
+		}
+		for _, sample := range samples {
+			key := labelsKey(sample.Labels)
+			result, exists := byKey[key]
+			if !exists {
+				result = &Series{Labels: sample.Labels}
+				byKey[key] = result
+			}
+			result.Samples = append(result.Samples, types.Sample{Timestamp: t, Value: sample.Value})
+		}
+	}
+
+	matrix := make(Matrix, 0, len(byKey))
+	for _, result := range byKey {
+		matrix = append(matrix, result)
+	}
+	sort.Slice(matrix, func(i, j int) bool { return labelsKey(matrix[i].Labels) < labelsKey(matrix[j].Labels) })
+	return matrix, nil
+}
+
+func evalVector(node Node, series []*Series, t time.Time) (Vector, error) {
+	var vector Vector
+	switch n := node.(type) {
+	case *VectorSelector:
+		for _, s := range series {
+			if !n.Matches(s.Labels) {
+				continue
+			}
+			samples := samplesBetween(s.Samples, t.Add(-LookbackDelta), t)
+			if len(samples) > 0 {
+				vector = append(vector, Sample{Labels: s.Labels, Value: samples[len(samples)-1].Value})
+			}
+		}
+
+	case *Call:
+		for _, s := range series {
+			if !n.Arg.Vector.Matches(s.Labels) {
+				continue
+			}
+			samples := samplesBetween(s.Samples, t.Add(-n.Arg.Range), t)
+			if len(samples) < 2 {
+				continue
+			}
+			value := increase(samples)
+			if n.Func == "rate" {
+				value /= n.Arg.Range.Seconds()
+			}
+			vector = append(vector, Sample{Labels: dropName(s.Labels), Value: value})
+		}
+
+	case *AggregateExpr:
+		input, err := evalVector(n.Expr, series, t)
+		if err != nil {
+			return nil, err
+		}
+		vector = aggregate(n, input)
+
+	default:
+		return nil, fmt.Errorf("unexpected %T in instant vector", node)
+	}
+
+	sort.Slice(vector, func(i, j int) bool { return labelsKey(vector[i].Labels) < labelsKey(vector[j].Labels) })
+	return vector, nil
+}
+
+// samplesBetween returns the samples in the interval (from, to].
+func samplesBetween(samples []types.Sample, from, to time.Time) []types.Sample {
+	start := sort.Search(len(samples), func(i int) bool { return samples[i].Timestamp.After(from) })
+	end := sort.Search(len(samples), func(i int) bool { return samples[i].Timestamp.After(to) })
+	return samples[start:end]
+}
+
+// increase sums the growth of a counter between consecutive samples, treating
+// a drop as a reset after which the whole new value counts as growth.
+func increase(samples []types.Sample) float64 {
+	var total float64
+	for i := 1; i < len(samples); i++ {
+		if samples[i].Value >= samples[i-1].Value {
+			total += samples[i].Value - samples[i-1].Value
+		} else {
+			total += samples[i].Value
+		}
+	}
+	return total
+}
+
+// aggregate groups the samples and applies the aggregation operator to every group.
+func aggregate(n *AggregateExpr, input Vector) Vector {
+	type group struct {
+		labels map[string]string
+		values []float64
+	}
+
+	var order []string
+	groups := make(map[string]*group)
+	for _, sample := range input {
+		labels := groupLabels(n, sample.Labels)
+		key := labelsKey(labels)
+		g, exists := groups[key]
+		if !exists {
+			g = &group{labels: labels}
+			groups[key] = g
+			order = append(order, key)
+		}
+		g.values = append(g.values, sample.Value)
+	}
+
+	result := make(Vector, 0, len(groups))
+	for _, key := range order {
+		g := groups[key]
+		var value float64
+		switch n.Op {
+		case "sum", "avg":
+			for _, v := range g.values {
+				value += v
+			}
+			if n.Op == "avg" {
+				value /= float64(len(g.values))
+			}
+		case "min":
+			value = math.Inf(1)
+			for _, v := range g.values {
+				value = math.Min(value, v)
+			}
+		case "max":
+			value = math.Inf(-1)
+			for _, v := range g.values {
+				value = math.Max(value, v)
+			}
+		case "count":
+			value = float64(len(g.values))
+		}
+		result = append(result, Sample{Labels: g.labels, Value: value})
+	}
+	return result
+}
+
+// groupLabels returns the labels identifying the group of a series. The metric name
+// is dropped unless it is listed in a by clause.
+func groupLabels(n *AggregateExpr, labels map[string]string) map[string]string {
+	grouped := make(map[string]string)
+	if n.Without {
+		for name, value := range labels {
+			if name != MetricNameLabel && !contains(n.Grouping, name) {
+				grouped[name] = value
+			}
+		}
+		return grouped
+	}
+
+	for _, name := range n.Grouping {
+		if value, exists := labels[name]; exists {
+			grouped[name] = value
+		}
+	}
+	return grouped
+}
+
+func dropName(labels map[string]string) map[string]string {
+	result := make(map[string]string, len(labels))
+	for name, value := range labels {
+		if name != MetricNameLabel {
+			result[name] = value
+		}
+	}
+	return result
+}
+
+func labelsKey(labels map[string]string) string {
+	return types.FormatMetricName("", labels)
+}
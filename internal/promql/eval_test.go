@@ -0,0 +1,198 @@
+package promql
+
+import (
+	"errors"
+	"go-metrics-alerting/internal/types"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestEval(t *testing.T) {
+	now := time.Unix(1700000000, 0)
+	at := func(seconds int, value float64) types.Sample {
+		return types.Sample{Timestamp: now.Add(time.Duration(seconds) * time.Second), Value: value}
+	}
+
+	series := []*Series{
+		{
+			Labels:  map[string]string{MetricNameLabel: "requests", "job": "api", "instance": "a"},
+			Samples: []types.Sample{at(-120, 10), at(-60, 40), at(0, 70)},
+		},
+		{
+			// The counter resets between the last two samples
+			Labels:  map[string]string{MetricNameLabel: "requests", "job": "api", "instance": "b"},
+			Samples: []types.Sample{at(-120, 50), at(-60, 80), at(0, 20)},
+		},
+		{
+			Labels:  map[string]string{MetricNameLabel: "requests", "job": "web", "instance": "a"},
+			Samples: []types.Sample{at(-60, 5)},
+		},
+		{
+			// Outside of the lookback window
+			Labels:  map[string]string{MetricNameLabel: "requests", "job": "old", "instance": "a"},
+			Samples: []types.Sample{at(-600, 1)},
+		},
+	}
+
+	tests := []struct {
+		name  string
+		query string
+		want  Value
+	}{
+		{name: "scalar", query: "2.5", want: Scalar(2.5)},
+		{
+			name:  "latest sample within the lookback window",
+			query: `requests{instance="a"}`,
+			want: Vector{
+				{Labels: series[0].Labels, Value: 70},
+				{Labels: series[2].Labels, Value: 5},
+			},
+		},
+		{
+			name:  "increase over a reset",
+			query: `increase(requests{job="api"}[3m])`,
+			want: Vector{
+				{Labels: map[string]string{"job": "api", "instance": "a"}, Value: 60},
+				{Labels: map[string]string{"job": "api", "instance": "b"}, Value: 50},
+			},
+		},
+		{
+			name:  "rate of series with a single sample is empty",
+			query: `rate(requests{job="web"}[5m])`,
+			want:  Vector(nil),
+		},
+		{
+			name:  "rate divides by the range",
+			query: `rate(requests{instance="a",job="api"}[2m])`,
+			want:  Vector{{Labels: map[string]string{"job": "api", "instance": "a"}, Value: 0.25}},
+		},
+		{
+			name:  "sum by job",
+			query: "sum by (job) (requests)",
+			want: Vector{
+				{Labels: map[string]string{"job": "api"}, Value: 90},
+				{Labels: map[string]string{"job": "web"}, Value: 5},
+			},
+		},
+		{
+			name:  "sum without instance drops the name",
+			query: "sum without (instance) (requests)",
+			want: Vector{
+				{Labels: map[string]string{"job": "api"}, Value: 90},
+				{Labels: map[string]string{"job": "web"}, Value: 5},
+			},
+		},
+		{name: "min", query: "min(requests)", want: Vector{{Labels: map[string]string{}, Value: 5}}},
+		{name: "max", query: "max(requests)", want: Vector{{Labels: map[string]string{}, Value: 70}}},
+		{name: "avg", query: `avg(requests{job="api"})`, want: Vector{{Labels: map[string]string{}, Value: 45}}},
+		{name: "count", query: "count(requests)", want: Vector{{Labels: map[string]string{}, Value: 3}}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, err := Eval(node, series, now)
+			if err != nil {
+				t.Fatalf("Eval() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Eval() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalRange(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	series := []*Series{{
+		Labels: map[string]string{MetricNameLabel: "temperature", "room": "a"},
+		Samples: []types.Sample{
+			{Timestamp: start, Value: 20},
+			{Timestamp: start.Add(2 * time.Minute), Value: 22},
+		},
+	}}
+
+	tests := []struct {
+		name  string
+		query string
+		end   time.Time
+		step  time.Duration
+		want  Matrix
+	}{
+		{
+			name:  "vector",
+			query: "temperature",
+			end:   start.Add(2 * time.Minute),
+			step:  time.Minute,
+			want: Matrix{{
+				Labels: series[0].Labels,
+				Samples: []types.Sample{
+					{Timestamp: start, Value: 20},
+					{Timestamp: start.Add(time.Minute), Value: 20},
+					{Timestamp: start.Add(2 * time.Minute), Value: 22},
+				},
+			}},
+		},
+		{
+			name:  "scalar",
+			query: "1",
+			end:   start.Add(time.Minute),
+			step:  time.Minute,
+			want: Matrix{{
+				Labels: map[string]string{},
+				Samples: []types.Sample{
+					{Timestamp: start, Value: 1},
+					{Timestamp: start.Add(time.Minute), Value: 1},
+				},
+			}},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.query)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			got, err := EvalRange(node, series, start, tt.end, tt.step)
+			if err != nil {
+				t.Fatalf("EvalRange() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("EvalRange() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestEvalRangeErrors(t *testing.T) {
+	start := time.Unix(1700000000, 0)
+	node := &NumberLiteral{Value: 1}
+
+	tests := []struct {
+		name string
+		end  time.Time
+		step time.Duration
+		want error
+	}{
+		{name: "zero step", end: start, step: 0},
+		{name: "end before start", end: start.Add(-time.Second), step: time.Second},
+		{name: "too many points", end: start.Add(MaxPoints * time.Second), step: time.Second, want: ErrTooManyPoints},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := EvalRange(node, nil, start, tt.end, tt.step)
+			if err == nil {
+				t.Fatal("EvalRange() error = nil, want an error")
+			}
+			if tt.want != nil && !errors.Is(err, tt.want) {
+				t.Errorf("EvalRange() error = %v, want %v", err, tt.want)
+			}
+		})
+	}
+}
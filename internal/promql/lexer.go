@@ -0,0 +1,90 @@
+package promql
+
+import "go-metrics-alerting/internal/scanner"
+
+type tokenKind int
+
+const (
+	tokenEOF tokenKind = iota
+	tokenNumber
+	tokenIdent
+	tokenString
+	tokenDuration
+	tokenMatchOp
+	tokenLParen
+	tokenRParen
+	tokenLBrace
+	tokenRBrace
+	tokenLBracket
+	tokenRBracket
+	tokenComma
+)
+
+type token struct {
+	kind tokenKind
+	text string // Unquoted value for strings
+	pos  int    // Byte offset in the source
+}
+
+// Error is a syntax or type error pointing at a position in the query.
+type Error = scanner.Error
+
+var errorf = scanner.Errorf
+
+// syntax describes the tokens of queries. The contents of brackets are a single duration token.
+var syntax = &scanner.Syntax{
+	Operators:   []string{"=~", "!~", "!=", "="},
+	Punctuation: "(){}[],",
+	Quotes:      "\"'`",
+	Raw:         map[byte]byte{'[': ']'},
+	IdentStart:  isIdentStart,
+	IdentPart:   isIdentPart,
+}
+
+// punctuation maps single characters to their token kinds.
+var punctuation = map[string]tokenKind{
+	"(": tokenLParen,
+	")": tokenRParen,
+	"{": tokenLBrace,
+	"}": tokenRBrace,
+	"[": tokenLBracket,
+	"]": tokenRBracket,
+	",": tokenComma,
+}
+
+// tokenize splits the query into tokens.
+func tokenize(src string) ([]token, error) {
+	scanned, err := scanner.Scan(src, syntax)
+	if err != nil {
+		return nil, err
+	}
+
+	tokens := make([]token, 0, len(scanned))
+	for _, tok := range scanned {
+		var kind tokenKind
+		switch tok.Kind {
+		case scanner.Number:
+			kind = tokenNumber
+		case scanner.Ident:
+			kind = tokenIdent
+		case scanner.String:
+			kind = tokenString
+		case scanner.Raw:
+			kind = tokenDuration
+		case scanner.Operator:
+			kind = tokenMatchOp
+		case scanner.Punct:
+			kind = punctuation[tok.Text]
+		}
+		tokens = append(tokens, token{kind: kind, text: tok.Text, pos: tok.Pos})
+	}
+	return tokens, nil
+}
+
+func isIdentStart(c rune) bool {
+	return (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || c == '_' || c == ':'
+}
+
+func isIdentPart(c rune) bool {
+	return isIdentStart(c) || (c >= '0' && c <= '9')
+}
@@ -0,0 +1,490 @@
+// Package promql parses and evaluates the subset of PromQL needed by dashboards querying the
+// server as a Prometheus data source: instant vector selectors with label matchers, the
+// rate and increase functions over range selectors, and the sum, min, max, avg and count
+// aggregations with by or without clauses.
+package promql
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// MetricNameLabel is the label holding the metric name of a series.
+const MetricNameLabel = "__name__"
+
+// ValueType is the type of the result of an expression.
+type ValueType string
+
+const (
+	ValueTypeScalar ValueType = "scalar"
+	ValueTypeVector ValueType = "vector"
+	ValueTypeMatrix ValueType = "matrix"
+)
+
+// Node is a node of a parsed query.
+type Node interface {
+	Pos() int
+	Type() ValueType
+}
+
+// NumberLiteral is a numeric constant.
+type NumberLiteral struct {
+	Value  float64
+	Offset int
+}
+
+// VectorSelector selects the latest sample of every matching series.
+type VectorSelector struct {
+	Matchers []*LabelMatcher // Includes the __name__ matcher of the metric name
+	Offset   int
+}
+
+// MatrixSelector selects the samples of every matching series within the range.
+type MatrixSelector struct {
+	Vector *VectorSelector
+	Range  time.Duration
+	Offset int
+}
+
+// Call is a function applied to a range selector.
+type Call struct {
+	Func   string
+	Arg    *MatrixSelector
+	Offset int
+}
+
+// AggregateExpr aggregates a vector, grouping by the listed labels, or by all other labels with Without.
+type AggregateExpr struct {
+	Op       string
+	Expr     Node
+	Grouping []string
+	Without  bool
+	Offset   int
+}
+
+func (n *NumberLiteral) Pos() int  { return n.Offset }
+func (n *VectorSelector) Pos() int { return n.Offset }
+func (n *MatrixSelector) Pos() int { return n.Offset }
+func (n *Call) Pos() int           { return n.Offset }
+func (n *AggregateExpr) Pos() int  { return n.Offset }
+
+func (n *NumberLiteral) Type() ValueType  { return ValueTypeScalar }
+func (n *VectorSelector) Type() ValueType { return ValueTypeVector }
+func (n *MatrixSelector) Type() ValueType { return ValueTypeMatrix }
+func (n *Call) Type() ValueType           { return ValueTypeVector }
+func (n *AggregateExpr) Type() ValueType  { return ValueTypeVector }
+
+// MatchType is the operator of a label matcher.
+type MatchType string
+
+const (
+	MatchEqual     MatchType = "="
+	MatchNotEqual  MatchType = "!="
+	MatchRegexp    MatchType = "=~"
+	MatchNotRegexp MatchType = "!~"
+)
+
+// LabelMatcher matches the value of a label. Missing labels match as empty values.
+type LabelMatcher struct {
+	Name  string
+	Type  MatchType
+	Value string
+	re    *regexp.Regexp
+}
+
+// Functions lists the supported functions.
+var Functions = []string{"rate", "increase"}
+
+// Aggregations lists the supported aggregation operators.
+var Aggregations = []string{"sum", "min", "max", "avg", "count"}
+
+// Parse parses a query that evaluates to a scalar or an instant vector.
+func Parse(src string) (Node, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+
+	node, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, errorf(tok.pos, "unexpected %q", tok.text)
+	}
+	if node.Type() == ValueTypeMatrix {
+		return nil, errorf(node.Pos(), "range selectors are only supported as the argument of %s", strings.Join(Functions, " or "))
+	}
+
+	return node, nil
+}
+
+// ParseSelector parses a series selector as used by the match[] parameter.
+func ParseSelector(src string) (*VectorSelector, error) {
+	p, err := newParser(src)
+	if err != nil {
+		return nil, err
+	}
+
+	selector, err := p.parseVectorSelector()
+	if err != nil {
+		return nil, err
+	}
+	if tok := p.peek(); tok.kind != tokenEOF {
+		return nil, errorf(tok.pos, "unexpected %q", tok.text)
+	}
+	return selector, nil
+}
+
+// Selectors returns the selectors of the query, including those of range selectors.
+func Selectors(node Node) []*VectorSelector {
+	switch n := node.(type) {
+	case *VectorSelector:
+		return []*VectorSelector{n}
+	case *MatrixSelector:
+		return []*VectorSelector{n.Vector}
+	case *Call:
+		return []*VectorSelector{n.Arg.Vector}
+	case *AggregateExpr:
+		return Selectors(n.Expr)
+	}
+	return nil
+}
+
+// Window returns how far before the evaluation time the query reads samples.
+func Window(node Node) time.Duration {
+	switch n := node.(type) {
+	case *VectorSelector:
+		return LookbackDelta
+	case *MatrixSelector:
+		return n.Range
+	case *Call:
+		return n.Arg.Range
+	case *AggregateExpr:
+		return Window(n.Expr)
+	}
+	return 0
+}
+
+// Matches reports whether the series labels satisfy all matchers of the selector.
+func (n *VectorSelector) Matches(labels map[string]string) bool {
+	for _, matcher := range n.Matchers {
+		if !matcher.Matches(labels[matcher.Name]) {
+			return false
+		}
+	}
+	return true
+}
+
+// Matches reports whether the label value satisfies the matcher.
+func (m *LabelMatcher) Matches(value string) bool {
+	switch m.Type {
+	case MatchEqual:
+		return value == m.Value
+	case MatchNotEqual:
+		return value != m.Value
+	case MatchRegexp:
+		return m.re.MatchString(value)
+	case MatchNotRegexp:
+		return !m.re.MatchString(value)
+	}
+	return false
+}
+
+type parser struct {
+	tokens []token
+	pos    int
+}
+
+func newParser(src string) (*parser, error) {
+	tokens, err := tokenize(src)
+	if err != nil {
+		return nil, err
+	}
+	return &parser{tokens: tokens}, nil
+}
+
+func (p *parser) peek() token {
+	return p.tokens[p.pos]
+}
+
+func (p *parser) next() token {
+	tok := p.tokens[p.pos]
+	if tok.kind != tokenEOF {
+		p.pos++
+	}
+	return tok
+}
+
+// expect consumes a token of the kind or fails with the description of the expected token.
+func (p *parser) expect(kind tokenKind, what string) (token, error) {
+	tok := p.next()
+	if tok.kind != kind {
+		return tok, errorf(tok.pos, "expected %s", what)
+	}
+	return tok, nil
+}
+
+func (p *parser) parseExpr() (Node, error) {
+	tok := p.peek()
+	switch tok.kind {
+	case tokenNumber:
+		p.next()
+		value, err := strconv.ParseFloat(tok.text, 64)
+		if err != nil {
+			return nil, errorf(tok.pos, "invalid number %q", tok.text)
+		}
+		return &NumberLiteral{Value: value, Offset: tok.pos}, nil
+
+	case tokenLParen:
+		p.next()
+		node, err := p.parseExpr()
+		if err != nil {
+			return nil, err
+		}
+		if _, err := p.expect(tokenRParen, "\")\""); err != nil {
+			return nil, err
+		}
+		return node, nil
+
+	case tokenIdent:
+		following := p.tokens[p.pos+1]
+		if contains(Aggregations, tok.text) && (following.kind == tokenLParen || isGroupingKeyword(following)) {
+			return p.parseAggregate()
+		}
+		if following.kind == tokenLParen {
+			return p.parseCall()
+		}
+		return p.parseSelector()
+
+	case tokenLBrace:
+		return p.parseSelector()
+
+	case tokenEOF:
+		return nil, errorf(tok.pos, "unexpected end of query")
+	}
+	return nil, errorf(tok.pos, "unexpected %q", tok.text)
+}
+
+// parseAggregate parses "op [by|without (labels)] (expr) [by|without (labels)]".
+func (p *parser) parseAggregate() (Node, error) {
+	tok := p.next()
+	node := &AggregateExpr{Op: tok.text, Offset: tok.pos}
+
+	grouped := false
+	if isGroupingKeyword(p.peek()) {
+		if err := p.parseGrouping(node); err != nil {
+			return nil, err
+		}
+		grouped = true
+	}
+
+	if _, err := p.expect(tokenLParen, "\"(\""); err != nil {
+		return nil, err
+	}
+	expr, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	if expr.Type() != ValueTypeVector {
+		return nil, errorf(expr.Pos(), "%s expects an instant vector", node.Op)
+	}
+	node.Expr = expr
+	if _, err := p.expect(tokenRParen, "\")\""); err != nil {
+		return nil, err
+	}
+
+	if !grouped && isGroupingKeyword(p.peek()) {
+		if err := p.parseGrouping(node); err != nil {
+			return nil, err
+		}
+	}
+	return node, nil
+}
+
+// parseGrouping parses "by (label, ...)" or "without (label, ...)".
+func (p *parser) parseGrouping(node *AggregateExpr) error {
+	node.Without = p.next().text == "without"
+	if _, err := p.expect(tokenLParen, "\"(\""); err != nil {
+		return err
+	}
+
+	node.Grouping = []string{}
+	for p.peek().kind != tokenRParen {
+		label, err := p.expect(tokenIdent, "label name")
+		if err != nil {
+			return err
+		}
+		node.Grouping = append(node.Grouping, label.text)
+		if p.peek().kind != tokenComma {
+			break
+		}
+		p.next()
+	}
+	_, err := p.expect(tokenRParen, "\")\"")
+	return err
+}
+
+// parseCall parses "func(range selector)".
+func (p *parser) parseCall() (Node, error) {
+	tok := p.next()
+	if !contains(Functions, tok.text) {
+		return nil, errorf(tok.pos, "unknown function %q", tok.text)
+	}
+	p.next() // The opening parenthesis checked by parseExpr
+
+	arg, err := p.parseExpr()
+	if err != nil {
+		return nil, err
+	}
+	matrix, ok := arg.(*MatrixSelector)
+	if !ok {
+		return nil, errorf(arg.Pos(), "%s expects a range selector, e.g. metric[5m]", tok.text)
+	}
+	if _, err := p.expect(tokenRParen, "\")\""); err != nil {
+		return nil, err
+	}
+	return &Call{Func: tok.text, Arg: matrix, Offset: tok.pos}, nil
+}
+
+// parseSelector parses "name{matchers}", "name" or "{matchers}", optionally followed by a range.
+func (p *parser) parseSelector() (Node, error) {
+	selector, err := p.parseVectorSelector()
+	if err != nil {
+		return nil, err
+	}
+	if p.peek().kind != tokenLBracket {
+		return selector, nil
+	}
+
+	bracket := p.next()
+	tok := p.next()
+	if tok.kind != tokenDuration || tok.text == "" {
+		return nil, errorf(bracket.pos, "expected a range, e.g. [5m]")
+	}
+	duration, err := ParseDuration(tok.text)
+	if err != nil || duration <= 0 {
+		return nil, errorf(tok.pos, "invalid range %q", tok.text)
+	}
+	if _, err := p.expect(tokenRBracket, "\"]\""); err != nil {
+		return nil, err
+	}
+	return &MatrixSelector{Vector: selector, Range: duration, Offset: selector.Offset}, nil
+}
+
+func (p *parser) parseVectorSelector() (*VectorSelector, error) {
+	start := p.peek()
+	selector := &VectorSelector{Offset: start.pos}
+
+	if start.kind == tokenIdent {
+		p.next()
+		selector.Matchers = append(selector.Matchers, &LabelMatcher{Name: MetricNameLabel, Type: MatchEqual, Value: start.text})
+	}
+
+	if p.peek().kind == tokenLBrace {
+		p.next()
+		for p.peek().kind != tokenRBrace {
+			matcher, err := p.parseMatcher()
+			if err != nil {
+				return nil, err
+			}
+			selector.Matchers = append(selector.Matchers, matcher)
+			if p.peek().kind != tokenComma {
+				break
+			}
+			p.next()
+		}
+		if _, err := p.expect(tokenRBrace, "\"}\""); err != nil {
+			return nil, err
+		}
+	} else if start.kind != tokenIdent {
+		return nil, errorf(start.pos, "expected a metric name or \"{\"")
+	}
+
+	// Selecting every series would be accidental, so one matcher must reject empty values
+	for _, matcher := range selector.Matchers {
+		if !matcher.Matches("") {
+			return selector, nil
+		}
+	}
+	return nil, errorf(start.pos, "selector must contain at least one matcher that does not match the empty string")
+}
+
+func (p *parser) parseMatcher() (*LabelMatcher, error) {
+	name, err := p.expect(tokenIdent, "label name")
+	if err != nil {
+		return nil, err
+	}
+	op, err := p.expect(tokenMatchOp, "label matcher, e.g. =")
+	if err != nil {
+		return nil, err
+	}
+	value, err := p.expect(tokenString, "quoted label value")
+	if err != nil {
+		return nil, err
+	}
+
+	matcher := &LabelMatcher{Name: name.text, Type: MatchType(op.text), Value: value.text}
+	if matcher.Type == MatchRegexp || matcher.Type == MatchNotRegexp {
+		// Regular expressions are anchored at both ends
+		matcher.re, err = regexp.Compile("^(?:" + value.text + ")$")
+		if err != nil {
+			return nil, errorf(value.pos, "invalid regular expression: %v", err)
+		}
+	}
+	return matcher, nil
+}
+
+// ParseDuration parses a Prometheus duration such as 30s, 5m or 1h30m.
+// The units are ms, s, m, h, d (24h), w (7d) and y (365d).
+func ParseDuration(s string) (time.Duration, error) {
+	units := map[string]time.Duration{
+		"ms": time.Millisecond,
+		"s":  time.Second,
+		"m":  time.Minute,
+		"h":  time.Hour,
+		"d":  24 * time.Hour,
+		"w":  7 * 24 * time.Hour,
+		"y":  365 * 24 * time.Hour,
+	}
+
+	if s == "" {
+		return 0, fmt.Errorf("empty duration")
+	}
+	src := s
+	var total time.Duration
+	for s != "" {
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		j := i
+		for j < len(s) && (s[j] < '0' || s[j] > '9') {
+			j++
+		}
+		number, err := strconv.ParseInt(s[:i], 10, 64)
+		unit, known := units[s[i:j]]
+		if err != nil || !known {
+			return 0, fmt.Errorf("invalid duration %q", src)
+		}
+		total += time.Duration(number) * unit
+		s = s[j:]
+	}
+	return total, nil
+}
+
+func isGroupingKeyword(tok token) bool {
+	return tok.kind == tokenIdent && (tok.text == "by" || tok.text == "without")
+}
+
+func contains(items []string, item string) bool {
+	for _, candidate := range items {
+		if candidate == item {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,226 @@
+package promql
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+)
+
+// describe renders a parsed query in a canonical form for comparisons.
+func describe(node Node) string {
+	switch n := node.(type) {
+	case *NumberLiteral:
+		return fmt.Sprint(n.Value)
+	case *VectorSelector:
+		matchers := make([]string, 0, len(n.Matchers))
+		for _, m := range n.Matchers {
+			matchers = append(matchers, fmt.Sprintf("%s%s%q", m.Name, m.Type, m.Value))
+		}
+		return "{" + strings.Join(matchers, ",") + "}"
+	case *MatrixSelector:
+		return fmt.Sprintf("%s[%s]", describe(n.Vector), n.Range)
+	case *Call:
+		return fmt.Sprintf("%s(%s)", n.Func, describe(n.Arg))
+	case *AggregateExpr:
+		keyword := "by"
+		if n.Without {
+			keyword = "without"
+		}
+		return fmt.Sprintf("%s %s (%s) (%s)", n.Op, keyword, strings.Join(n.Grouping, ","), describe(n.Expr))
+	}
+	return fmt.Sprintf("%T", node)
+}
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{name: "number", input: "1.5", want: "1.5"},
+		{name: "metric name", input: "up", want: `{__name__="up"}`},
+		{
+			name:  "metric name with matchers",
+			input: `http_requests_total{job="api", code!="500", path=~"/v1/.*", method!~"GET|HEAD"}`,
+			want:  `{__name__="http_requests_total",job="api",code!="500",path=~"/v1/.*",method!~"GET|HEAD"}`,
+		},
+		{name: "matchers only", input: `{job="api"}`, want: `{job="api"}`},
+		{name: "parentheses", input: "((up))", want: `{__name__="up"}`},
+		{name: "rate", input: "rate(requests[5m])", want: `rate({__name__="requests"}[5m0s])`},
+		{name: "increase", input: "increase(requests[1h30m])", want: `increase({__name__="requests"}[1h30m0s])`},
+		{name: "aggregation without grouping", input: "sum(up)", want: `sum by () ({__name__="up"})`},
+		{name: "grouping before", input: "max by (job, instance) (up)", want: `max by (job,instance) ({__name__="up"})`},
+		{name: "grouping after", input: "avg(up) without (instance)", want: `avg without (instance) ({__name__="up"})`},
+		{
+			name:  "nested aggregation of a function",
+			input: "count(sum by (job) (rate(requests[1m])))",
+			want:  `count by () (sum by (job) (rate({__name__="requests"}[1m0s])))`,
+		},
+		{name: "aggregation name as metric", input: "sum", want: `{__name__="sum"}`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := describe(node); got != tt.want {
+				t.Errorf("Parse() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		pos   int
+	}{
+		{name: "empty query", input: "", pos: 0},
+		{name: "range selector at the top level", input: "up[5m]", pos: 0},
+		{name: "unknown function", input: "irate(up[5m])", pos: 0},
+		{name: "function without a range", input: "rate(up)", pos: 5},
+		{name: "aggregation of a range", input: "sum(up[5m])", pos: 4},
+		{name: "missing range", input: "rate(up[])", pos: 7},
+		{name: "zero range", input: "rate(up[0s])", pos: 8},
+		{name: "unclosed matchers", input: `up{job="api"`, pos: 12},
+		{name: "unquoted label value", input: "up{job=api}", pos: 7},
+		{name: "invalid regular expression", input: `up{job=~"("}`, pos: 8},
+		{name: "empty matching selector", input: `{job=~".*"}`, pos: 0},
+		{name: "trailing tokens", input: "up up", pos: 3},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Parse(tt.input)
+			var parseErr *Error
+			if !errors.As(err, &parseErr) {
+				t.Fatalf("Parse() error = %v, want an *Error", err)
+			}
+			if parseErr.Pos != tt.pos {
+				t.Errorf("Error.Pos = %d, want %d (%v)", parseErr.Pos, tt.pos, err)
+			}
+		})
+	}
+}
+
+func TestParseSelector(t *testing.T) {
+	tests := []struct {
+		name    string
+		input   string
+		want    string
+		wantErr bool
+	}{
+		{name: "metric name", input: "up", want: `{__name__="up"}`},
+		{name: "matchers", input: `{__name__=~"http_.*",job="api"}`, want: `{__name__=~"http_.*",job="api"}`},
+		{name: "range", input: "up[5m]", wantErr: true},
+		{name: "function", input: "rate(up[5m])", wantErr: true},
+		{name: "empty matching selector", input: `{job!="api"}`, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := ParseSelector(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Errorf("ParseSelector() = %s, want an error", describe(selector))
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("ParseSelector() error = %v", err)
+			}
+			if got := describe(selector); got != tt.want {
+				t.Errorf("ParseSelector() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseDuration(t *testing.T) {
+	tests := []struct {
+		input   string
+		want    time.Duration
+		wantErr bool
+	}{
+		{input: "30s", want: 30 * time.Second},
+		{input: "250ms", want: 250 * time.Millisecond},
+		{input: "1h30m", want: 90 * time.Minute},
+		{input: "2d", want: 48 * time.Hour},
+		{input: "1w1d", want: 8 * 24 * time.Hour},
+		{input: "1y", want: 365 * 24 * time.Hour},
+		{input: "", wantErr: true},
+		{input: "5", wantErr: true},
+		{input: "m", wantErr: true},
+		{input: "1.5h", wantErr: true},
+		{input: "3mo", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			got, err := ParseDuration(tt.input)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("ParseDuration() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("ParseDuration() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestWindow(t *testing.T) {
+	tests := []struct {
+		input string
+		want  time.Duration
+	}{
+		{input: "1", want: 0},
+		{input: "up", want: LookbackDelta},
+		{input: "rate(requests[10m])", want: 10 * time.Minute},
+		{input: "sum by (job) (increase(requests[1h]))", want: time.Hour},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.input, func(t *testing.T) {
+			node, err := Parse(tt.input)
+			if err != nil {
+				t.Fatalf("Parse() error = %v", err)
+			}
+			if got := Window(node); got != tt.want {
+				t.Errorf("Window() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVectorSelectorMatches(t *testing.T) {
+	tests := []struct {
+		name     string
+		selector string
+		labels   map[string]string
+		want     bool
+	}{
+		{name: "equal", selector: `up{job="api"}`, labels: map[string]string{MetricNameLabel: "up", "job": "api"}, want: true},
+		{name: "other name", selector: `up{job="api"}`, labels: map[string]string{MetricNameLabel: "down", "job": "api"}, want: false},
+		{name: "not equal to a missing label", selector: `up{env!="prod"}`, labels: map[string]string{MetricNameLabel: "up"}, want: true},
+		{name: "anchored regexp", selector: `{job=~"ap"}`, labels: map[string]string{"job": "api"}, want: false},
+		{name: "alternation", selector: `{job=~"api|web"}`, labels: map[string]string{"job": "web"}, want: true},
+		{name: "negative regexp", selector: `up{job!~"a.*"}`, labels: map[string]string{MetricNameLabel: "up", "job": "api"}, want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			selector, err := ParseSelector(tt.selector)
+			if err != nil {
+				t.Fatalf("ParseSelector() error = %v", err)
+			}
+			if got := selector.Matches(tt.labels); got != tt.want {
+				t.Errorf("Matches() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
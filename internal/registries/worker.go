@@ -3,6 +3,7 @@ package registries
 import (
 	"context"
 	"fmt"
+	"sync"
 )
 
 // Интерфейс Worker, который реализуют воркеры
@@ -28,12 +29,25 @@ func (r *WorkerRegistry) Register(worker Worker) error {
 	return nil
 }
 
-// Метод для запуска всех зарегистрированных воркеров
+// Метод для запуска всех зарегистрированных воркеров.
+// Воркеры запускаются параллельно, метод ждет завершения всех и возвращает первую ошибку.
 func (r *WorkerRegistry) StartAll(ctx context.Context) error {
+	var wg sync.WaitGroup
+	errs := make(chan error, len(r.workers))
+
 	for _, worker := range r.workers {
-		if err := worker.Start(ctx); err != nil {
-			return fmt.Errorf("error starting worker: %v", err)
-		}
+		wg.Add(1)
+		go func(worker Worker) {
+			defer wg.Done()
+			if err := worker.Start(ctx); err != nil {
+				errs <- fmt.Errorf("error starting worker: %v", err)
+			}
+		}(worker)
 	}
-	return nil
+
+	wg.Wait()
+	close(errs)
+
+	// Возвращаем первую ошибку, если она была
+	return <-errs
 }
@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+)
+
+// AnomalyModelRepository holds the three anomaly model repositories.
+type AnomalyModelRepository struct {
+	DBRepo     *AnomalyModelDBRepository
+	FileRepo   *AnomalyModelFileRepository
+	MemoryRepo *AnomalyModelMemoryRepository
+}
+
+// NewAnomalyModelRepository creates a new instance of AnomalyModelRepository, containing all three repositories.
+func NewAnomalyModelRepository(c *configs.ServerConfig, db *sql.DB) *AnomalyModelRepository {
+	var dbRepo *AnomalyModelDBRepository
+	var fileRepo *AnomalyModelFileRepository
+
+	// Initialize DB repository if DatabaseDSN is provided
+	if c.DatabaseDSN != "" {
+		dbRepo = NewAnomalyModelDBRepository(c, db)
+	}
+
+	// Initialize File repository if FileStoragePath is provided
+	if c.FileStoragePath != "" {
+		fileRepo = NewAnomalyModelFileRepository(c)
+	}
+
+	return &AnomalyModelRepository{
+		DBRepo:     dbRepo,
+		FileRepo:   fileRepo,
+		MemoryRepo: NewAnomalyModelMemoryRepository(),
+	}
+}
+
+// AnomalyModelRepo defines the common methods for all anomaly model repositories.
+type AnomalyModelRepo interface {
+	SaveModels(ctx context.Context, models []*types.AnomalyModel) error
+	ListModels(ctx context.Context) ([]*types.AnomalyModel, error)
+}
+
+// GetMainRepository returns the repository with the highest priority (db -> file -> memory), based on ServerConfig.
+func (ar *AnomalyModelRepository) GetMainRepository(c *configs.ServerConfig) AnomalyModelRepo {
+	if ar.DBRepo != nil && c.DatabaseDSN != "" {
+		return ar.DBRepo
+	}
+
+	if ar.FileRepo != nil && c.FileStoragePath != "" {
+		return ar.FileRepo
+	}
+
+	if ar.MemoryRepo != nil {
+		return ar.MemoryRepo
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+)
+
+type AnomalyModelDBRepository struct {
+	db *sql.DB
+	c  *configs.ServerConfig
+}
+
+// NewAnomalyModelDBRepository creates a new instance of AnomalyModelDBRepository.
+func NewAnomalyModelDBRepository(c *configs.ServerConfig, db *sql.DB) *AnomalyModelDBRepository {
+	createAnomalyModelsTable(db)
+	return &AnomalyModelDBRepository{
+		db: db,
+		c:  c,
+	}
+}
+
+// SaveModels creates or replaces the models in a single transaction.
+func (ar *AnomalyModelDBRepository) SaveModels(ctx context.Context, models []*types.AnomalyModel) error {
+	if len(models) == 0 {
+		return nil
+	}
+
+	tx, err := ar.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO anomaly_models (metric_id, metric_type, model) VALUES ($1, $2, $3)
+			  ON CONFLICT (metric_id, metric_type) DO UPDATE SET model = EXCLUDED.model`
+
+	for _, model := range models {
+		data, err := json.Marshal(model)
+		if err != nil {
+			return fmt.Errorf("failed to marshal anomaly model: %v", err)
+		}
+		if _, err := tx.ExecContext(ctx, query, model.Metric.ID, model.Metric.Type, data); err != nil {
+			return fmt.Errorf("failed to save anomaly model: %v", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListModels lists all models stored in the database.
+func (ar *AnomalyModelDBRepository) ListModels(ctx context.Context) ([]*types.AnomalyModel, error) {
+	rows, err := ar.db.QueryContext(ctx, "SELECT model FROM anomaly_models")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query anomaly models: %v", err)
+	}
+	defer rows.Close()
+
+	var models []*types.AnomalyModel
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan anomaly model: %v", err)
+		}
+
+		var model types.AnomalyModel
+		if err := json.Unmarshal(data, &model); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal anomaly model: %v", err)
+		}
+		models = append(models, &model)
+	}
+
+	// Handle any row iteration errors
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed during row iteration: %v", err)
+	}
+
+	return models, nil
+}
+
+// createAnomalyModelsTable stores every model as a JSON document keyed by its metric.
+func createAnomalyModelsTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS anomaly_models (
+		metric_id TEXT NOT NULL,
+		metric_type VARCHAR(50) NOT NULL,
+		model JSONB NOT NULL,
+		PRIMARY KEY (metric_id, metric_type)
+	)`
+
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+	if err := widenMetricIDColumn(db, "anomaly_models", "metric_id"); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// AnomalyModelFileName is the name of the anomaly models file stored alongside the metrics file.
+const AnomalyModelFileName = "anomaly_models.json"
+
+type AnomalyModelFileRepository struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewAnomalyModelFileRepository creates a new instance of AnomalyModelFileRepository.
+func NewAnomalyModelFileRepository(c *configs.ServerConfig) *AnomalyModelFileRepository {
+	return &AnomalyModelFileRepository{
+		path: filepath.Join(filepath.Dir(c.FileStoragePath), AnomalyModelFileName),
+	}
+}
+
+// SaveModels creates or replaces the models in the file.
+func (ar *AnomalyModelFileRepository) SaveModels(ctx context.Context, models []*types.AnomalyModel) error {
+	if len(models) == 0 {
+		return nil
+	}
+
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	stored, err := ar.readModels()
+	if err != nil {
+		return err
+	}
+	for _, model := range models {
+		stored[model.Metric] = model
+	}
+
+	return ar.writeModels(stored)
+}
+
+// ListModels lists all models stored in the file.
+func (ar *AnomalyModelFileRepository) ListModels(ctx context.Context) ([]*types.AnomalyModel, error) {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	stored, err := ar.readModels()
+	if err != nil {
+		return nil, err
+	}
+
+	var models []*types.AnomalyModel
+	for _, model := range stored {
+		models = append(models, model)
+	}
+
+	return models, nil
+}
+
+// readModels reads all models from the file, treating a missing file as empty.
+func (ar *AnomalyModelFileRepository) readModels() (map[types.MetricID]*types.AnomalyModel, error) {
+	models := make(map[types.MetricID]*types.AnomalyModel)
+
+	data, err := os.ReadFile(ar.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return models, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read anomaly models file: %v", err)
+	}
+	if len(data) == 0 {
+		return models, nil
+	}
+
+	var list []*types.AnomalyModel
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal anomaly models: %v", err)
+	}
+	for _, model := range list {
+		models[model.Metric] = model
+	}
+
+	return models, nil
+}
+
+// writeModels overwrites the file with the given models.
+func (ar *AnomalyModelFileRepository) writeModels(models map[types.MetricID]*types.AnomalyModel) error {
+	list := make([]*types.AnomalyModel, 0, len(models))
+	for _, model := range models {
+		list = append(list, model)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Metric.Type != list[j].Metric.Type {
+			return list[i].Metric.Type < list[j].Metric.Type
+		}
+		return list[i].Metric.ID < list[j].Metric.ID
+	})
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("failed to marshal anomaly models: %v", err)
+	}
+
+	if err := os.WriteFile(ar.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write anomaly models file: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"context"
+	"go-metrics-alerting/internal/types"
+	"sync"
+)
+
+type AnomalyModelMemoryRepository struct {
+	data map[types.MetricID]*types.AnomalyModel
+	mu   sync.RWMutex
+}
+
+// NewAnomalyModelMemoryRepository creates a new instance of AnomalyModelMemoryRepository.
+func NewAnomalyModelMemoryRepository() *AnomalyModelMemoryRepository {
+	return &AnomalyModelMemoryRepository{
+		data: make(map[types.MetricID]*types.AnomalyModel),
+	}
+}
+
+// SaveModels creates or replaces the models in the in-memory storage.
+func (ar *AnomalyModelMemoryRepository) SaveModels(ctx context.Context, models []*types.AnomalyModel) error {
+	ar.mu.Lock()
+	defer ar.mu.Unlock()
+
+	for _, model := range models {
+		ar.data[model.Metric] = model
+	}
+	return nil
+}
+
+// ListModels lists all models stored in memory.
+func (ar *AnomalyModelMemoryRepository) ListModels(ctx context.Context) ([]*types.AnomalyModel, error) {
+	ar.mu.RLock()
+	defer ar.mu.RUnlock()
+
+	var models []*types.AnomalyModel
+	for _, model := range ar.data {
+		models = append(models, model)
+	}
+	return models, nil
+}
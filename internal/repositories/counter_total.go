@@ -0,0 +1,60 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+)
+
+// CounterTotalRepository holds the three counter total repositories.
+type CounterTotalRepository struct {
+	DBRepo     *CounterTotalDBRepository
+	FileRepo   *CounterTotalFileRepository
+	MemoryRepo *CounterTotalMemoryRepository
+}
+
+// NewCounterTotalRepository creates a new instance of CounterTotalRepository, containing all three repositories.
+func NewCounterTotalRepository(c *configs.ServerConfig, db *sql.DB) *CounterTotalRepository {
+	var dbRepo *CounterTotalDBRepository
+	var fileRepo *CounterTotalFileRepository
+
+	// Initialize DB repository if DatabaseDSN is provided
+	if c.DatabaseDSN != "" {
+		dbRepo = NewCounterTotalDBRepository(c, db)
+	}
+
+	// Initialize File repository if FileStoragePath is provided
+	if c.FileStoragePath != "" {
+		fileRepo = NewCounterTotalFileRepository(c)
+	}
+
+	return &CounterTotalRepository{
+		DBRepo:     dbRepo,
+		FileRepo:   fileRepo,
+		MemoryRepo: NewCounterTotalMemoryRepository(),
+	}
+}
+
+// CounterTotalRepo defines the common methods for all counter total repositories.
+type CounterTotalRepo interface {
+	SaveTotals(ctx context.Context, totals []*types.CounterTotal) error
+	ListTotals(ctx context.Context) ([]*types.CounterTotal, error)
+}
+
+// GetMainRepository returns the repository with the highest priority (db -> file -> memory), based on ServerConfig.
+func (cr *CounterTotalRepository) GetMainRepository(c *configs.ServerConfig) CounterTotalRepo {
+	if cr.DBRepo != nil && c.DatabaseDSN != "" {
+		return cr.DBRepo
+	}
+
+	if cr.FileRepo != nil && c.FileStoragePath != "" {
+		return cr.FileRepo
+	}
+
+	if cr.MemoryRepo != nil {
+		return cr.MemoryRepo
+	}
+
+	return nil
+}
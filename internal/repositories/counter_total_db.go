@@ -0,0 +1,91 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+)
+
+type CounterTotalDBRepository struct {
+	db *sql.DB
+	c  *configs.ServerConfig
+}
+
+// NewCounterTotalDBRepository creates a new instance of CounterTotalDBRepository.
+func NewCounterTotalDBRepository(c *configs.ServerConfig, db *sql.DB) *CounterTotalDBRepository {
+	createCounterTotalsTable(db)
+	return &CounterTotalDBRepository{
+		db: db,
+		c:  c,
+	}
+}
+
+// SaveTotals creates or replaces the totals in a single transaction.
+func (cr *CounterTotalDBRepository) SaveTotals(ctx context.Context, totals []*types.CounterTotal) error {
+	if len(totals) == 0 {
+		return nil
+	}
+
+	tx, err := cr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO counter_totals (metric_id, metric_type, total) VALUES ($1, $2, $3)
+			  ON CONFLICT (metric_id, metric_type) DO UPDATE SET total = EXCLUDED.total`
+
+	for _, total := range totals {
+		if _, err := tx.ExecContext(ctx, query, total.Metric.ID, total.Metric.Type, total.Total); err != nil {
+			return fmt.Errorf("failed to save counter total: %v", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListTotals lists all totals stored in the database.
+func (cr *CounterTotalDBRepository) ListTotals(ctx context.Context) ([]*types.CounterTotal, error) {
+	rows, err := cr.db.QueryContext(ctx, "SELECT metric_id, metric_type, total FROM counter_totals")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query counter totals: %v", err)
+	}
+	defer rows.Close()
+
+	var totals []*types.CounterTotal
+	for rows.Next() {
+		var total types.CounterTotal
+		if err := rows.Scan(&total.Metric.ID, &total.Metric.Type, &total.Total); err != nil {
+			return nil, fmt.Errorf("failed to scan counter total: %v", err)
+		}
+		totals = append(totals, &total)
+	}
+
+	// Handle any row iteration errors
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed during row iteration: %v", err)
+	}
+
+	return totals, nil
+}
+
+// createCounterTotalsTable stores the last running total of every cumulative counter.
+func createCounterTotalsTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS counter_totals (
+		metric_id TEXT NOT NULL,
+		metric_type VARCHAR(50) NOT NULL,
+		total BIGINT NOT NULL,
+		PRIMARY KEY (metric_id, metric_type)
+	)`
+
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+	if err := widenMetricIDColumn(db, "counter_totals", "metric_id"); err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,118 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// CounterTotalFileName is the name of the counter totals file stored alongside the metrics file.
+const CounterTotalFileName = "counter_totals.json"
+
+type CounterTotalFileRepository struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewCounterTotalFileRepository creates a new instance of CounterTotalFileRepository.
+func NewCounterTotalFileRepository(c *configs.ServerConfig) *CounterTotalFileRepository {
+	return &CounterTotalFileRepository{
+		path: filepath.Join(filepath.Dir(c.FileStoragePath), CounterTotalFileName),
+	}
+}
+
+// SaveTotals creates or replaces the totals in the file.
+func (cr *CounterTotalFileRepository) SaveTotals(ctx context.Context, totals []*types.CounterTotal) error {
+	if len(totals) == 0 {
+		return nil
+	}
+
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	stored, err := cr.readTotals()
+	if err != nil {
+		return err
+	}
+	for _, total := range totals {
+		stored[total.Metric] = total
+	}
+
+	return cr.writeTotals(stored)
+}
+
+// ListTotals lists all totals stored in the file.
+func (cr *CounterTotalFileRepository) ListTotals(ctx context.Context) ([]*types.CounterTotal, error) {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	stored, err := cr.readTotals()
+	if err != nil {
+		return nil, err
+	}
+
+	var totals []*types.CounterTotal
+	for _, total := range stored {
+		totals = append(totals, total)
+	}
+
+	return totals, nil
+}
+
+// readTotals reads all totals from the file, treating a missing file as empty.
+func (cr *CounterTotalFileRepository) readTotals() (map[types.MetricID]*types.CounterTotal, error) {
+	totals := make(map[types.MetricID]*types.CounterTotal)
+
+	data, err := os.ReadFile(cr.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return totals, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read counter totals file: %v", err)
+	}
+	if len(data) == 0 {
+		return totals, nil
+	}
+
+	var list []*types.CounterTotal
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal counter totals: %v", err)
+	}
+	for _, total := range list {
+		totals[total.Metric] = total
+	}
+
+	return totals, nil
+}
+
+// writeTotals overwrites the file with the given totals.
+func (cr *CounterTotalFileRepository) writeTotals(totals map[types.MetricID]*types.CounterTotal) error {
+	list := make([]*types.CounterTotal, 0, len(totals))
+	for _, total := range totals {
+		list = append(list, total)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].Metric.Type != list[j].Metric.Type {
+			return list[i].Metric.Type < list[j].Metric.Type
+		}
+		return list[i].Metric.ID < list[j].Metric.ID
+	})
+
+	data, err := json.Marshal(list)
+	if err != nil {
+		return fmt.Errorf("failed to marshal counter totals: %v", err)
+	}
+
+	if err := os.WriteFile(cr.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write counter totals file: %v", err)
+	}
+
+	return nil
+}
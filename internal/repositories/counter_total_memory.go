@@ -0,0 +1,42 @@
+package repositories
+
+import (
+	"context"
+	"go-metrics-alerting/internal/types"
+	"sync"
+)
+
+type CounterTotalMemoryRepository struct {
+	data map[types.MetricID]*types.CounterTotal
+	mu   sync.RWMutex
+}
+
+// NewCounterTotalMemoryRepository creates a new instance of CounterTotalMemoryRepository.
+func NewCounterTotalMemoryRepository() *CounterTotalMemoryRepository {
+	return &CounterTotalMemoryRepository{
+		data: make(map[types.MetricID]*types.CounterTotal),
+	}
+}
+
+// SaveTotals creates or replaces the totals in the in-memory storage.
+func (cr *CounterTotalMemoryRepository) SaveTotals(ctx context.Context, totals []*types.CounterTotal) error {
+	cr.mu.Lock()
+	defer cr.mu.Unlock()
+
+	for _, total := range totals {
+		cr.data[total.Metric] = total
+	}
+	return nil
+}
+
+// ListTotals lists all totals stored in memory.
+func (cr *CounterTotalMemoryRepository) ListTotals(ctx context.Context) ([]*types.CounterTotal, error) {
+	cr.mu.RLock()
+	defer cr.mu.RUnlock()
+
+	var totals []*types.CounterTotal
+	for _, total := range cr.data {
+		totals = append(totals, total)
+	}
+	return totals, nil
+}
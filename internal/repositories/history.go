@@ -0,0 +1,93 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+	"sort"
+)
+
+// AlertHistoryRepository holds the three alert history repositories.
+type AlertHistoryRepository struct {
+	DBRepo     *AlertHistoryDBRepository
+	FileRepo   *AlertHistoryFileRepository
+	MemoryRepo *AlertHistoryMemoryRepository
+}
+
+// NewAlertHistoryRepository creates a new instance of AlertHistoryRepository, containing all three repositories.
+func NewAlertHistoryRepository(c *configs.ServerConfig, db *sql.DB) *AlertHistoryRepository {
+	var dbRepo *AlertHistoryDBRepository
+	var fileRepo *AlertHistoryFileRepository
+
+	// Initialize DB repository if DatabaseDSN is provided
+	if c.DatabaseDSN != "" {
+		dbRepo = NewAlertHistoryDBRepository(c, db)
+	}
+
+	// Initialize File repository if FileStoragePath is provided
+	if c.FileStoragePath != "" {
+		fileRepo = NewAlertHistoryFileRepository(c)
+	}
+
+	return &AlertHistoryRepository{
+		DBRepo:     dbRepo,
+		FileRepo:   fileRepo,
+		MemoryRepo: NewAlertHistoryMemoryRepository(),
+	}
+}
+
+// AlertHistoryRepo defines the common methods for all alert history repositories.
+type AlertHistoryRepo interface {
+	SaveTransitions(ctx context.Context, transitions []*types.AlertTransition) error
+	FilterTransitions(ctx context.Context, filter types.AlertHistoryFilter) ([]*types.AlertTransition, error)
+}
+
+// GetMainRepository returns the repository with the highest priority (db -> file -> memory), based on ServerConfig.
+func (hr *AlertHistoryRepository) GetMainRepository(c *configs.ServerConfig) AlertHistoryRepo {
+	if hr.DBRepo != nil && c.DatabaseDSN != "" {
+		return hr.DBRepo
+	}
+
+	if hr.FileRepo != nil && c.FileStoragePath != "" {
+		return hr.FileRepo
+	}
+
+	if hr.MemoryRepo != nil {
+		return hr.MemoryRepo
+	}
+
+	return nil
+}
+
+// filterTransitions applies the filter to transitions kept in memory or read from a file,
+// returning the requested page ordered from newest to oldest.
+func filterTransitions(transitions []*types.AlertTransition, filter types.AlertHistoryFilter) []*types.AlertTransition {
+	var matched []*types.AlertTransition
+	for _, transition := range transitions {
+		if filter.RuleID != "" && transition.RuleID != filter.RuleID {
+			continue
+		}
+		if !filter.Since.IsZero() && transition.Timestamp.Before(filter.Since) {
+			continue
+		}
+		if !filter.Until.IsZero() && transition.Timestamp.After(filter.Until) {
+			continue
+		}
+		matched = append(matched, transition)
+	}
+
+	sort.SliceStable(matched, func(i, j int) bool {
+		return matched[i].Timestamp.After(matched[j].Timestamp)
+	})
+
+	if filter.Offset >= len(matched) {
+		return nil
+	}
+	matched = matched[filter.Offset:]
+	if filter.Limit > 0 && filter.Limit < len(matched) {
+		matched = matched[:filter.Limit]
+	}
+
+	return matched
+}
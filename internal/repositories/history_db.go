@@ -0,0 +1,117 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+)
+
+type AlertHistoryDBRepository struct {
+	db *sql.DB
+	c  *configs.ServerConfig
+}
+
+// NewAlertHistoryDBRepository creates a new instance of AlertHistoryDBRepository.
+func NewAlertHistoryDBRepository(c *configs.ServerConfig, db *sql.DB) *AlertHistoryDBRepository {
+	createAlertEventsTable(db)
+	return &AlertHistoryDBRepository{
+		db: db,
+		c:  c,
+	}
+}
+
+// SaveTransitions inserts the transitions into the alert_events table.
+func (hr *AlertHistoryDBRepository) SaveTransitions(ctx context.Context, transitions []*types.AlertTransition) error {
+	if len(transitions) == 0 {
+		return nil
+	}
+
+	query := `INSERT INTO alert_events (rule_id, metric_id, metric_type, from_state, to_state, value, timestamp) 
+			  VALUES `
+	var args []interface{}
+	for i, t := range transitions {
+		args = append(args, t.RuleID, t.Metric.ID, t.Metric.Type, string(t.FromState), string(t.ToState), t.Value, t.Timestamp)
+
+		query += fmt.Sprintf("($%d, $%d, $%d, $%d, $%d, $%d, $%d)",
+			i*7+1, i*7+2, i*7+3, i*7+4, i*7+5, i*7+6, i*7+7)
+		if i < len(transitions)-1 {
+			query += ", "
+		}
+	}
+
+	_, err := hr.db.ExecContext(ctx, query, args...)
+	return err
+}
+
+// FilterTransitions returns a page of the alert_events table matching the filter, newest first.
+func (hr *AlertHistoryDBRepository) FilterTransitions(ctx context.Context, filter types.AlertHistoryFilter) ([]*types.AlertTransition, error) {
+	query := "SELECT rule_id, metric_id, metric_type, from_state, to_state, value, timestamp FROM alert_events WHERE TRUE"
+	var args []interface{}
+	if filter.RuleID != "" {
+		args = append(args, filter.RuleID)
+		query += fmt.Sprintf(" AND rule_id = $%d", len(args))
+	}
+	if !filter.Since.IsZero() {
+		args = append(args, filter.Since)
+		query += fmt.Sprintf(" AND timestamp >= $%d", len(args))
+	}
+	if !filter.Until.IsZero() {
+		args = append(args, filter.Until)
+		query += fmt.Sprintf(" AND timestamp <= $%d", len(args))
+	}
+	query += " ORDER BY timestamp DESC, id DESC"
+	if filter.Limit > 0 {
+		args = append(args, filter.Limit)
+		query += fmt.Sprintf(" LIMIT $%d", len(args))
+	}
+	args = append(args, filter.Offset)
+	query += fmt.Sprintf(" OFFSET $%d", len(args))
+
+	rows, err := hr.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query alert events: %v", err)
+	}
+	defer rows.Close()
+
+	var transitions []*types.AlertTransition
+	for rows.Next() {
+		var t types.AlertTransition
+		if err := rows.Scan(&t.RuleID, &t.Metric.ID, &t.Metric.Type, &t.FromState, &t.ToState, &t.Value, &t.Timestamp); err != nil {
+			return nil, fmt.Errorf("failed to scan alert event: %v", err)
+		}
+		transitions = append(transitions, &t)
+	}
+
+	// Handle any row iteration errors
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed during row iteration: %v", err)
+	}
+
+	return transitions, nil
+}
+
+func createAlertEventsTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS alert_events (
+		id BIGSERIAL PRIMARY KEY,
+		rule_id VARCHAR(255) NOT NULL,
+		metric_id TEXT NOT NULL,
+		metric_type VARCHAR(255) NOT NULL,
+		from_state VARCHAR(32) NOT NULL,
+		to_state VARCHAR(32) NOT NULL,
+		value DOUBLE PRECISION NOT NULL,
+		timestamp TIMESTAMPTZ NOT NULL
+	)`
+
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+	if err := widenMetricIDColumn(db, "alert_events", "metric_id"); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS alert_events_rule_timestamp_idx ON alert_events (rule_id, timestamp)`)
+	return err
+}
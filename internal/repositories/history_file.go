@@ -0,0 +1,87 @@
+package repositories
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+	"os"
+	"path/filepath"
+	"sync"
+)
+
+// AlertHistoryFileName is the name of the append-only history log stored alongside the metrics file.
+const AlertHistoryFileName = "alert_events.jsonl"
+
+type AlertHistoryFileRepository struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewAlertHistoryFileRepository creates a new instance of AlertHistoryFileRepository.
+func NewAlertHistoryFileRepository(c *configs.ServerConfig) *AlertHistoryFileRepository {
+	return &AlertHistoryFileRepository{
+		path: filepath.Join(filepath.Dir(c.FileStoragePath), AlertHistoryFileName),
+	}
+}
+
+// SaveTransitions appends the transitions to the log, one JSON document per line.
+func (hr *AlertHistoryFileRepository) SaveTransitions(ctx context.Context, transitions []*types.AlertTransition) error {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	file, err := os.OpenFile(hr.path, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open history file: %v", err)
+	}
+	defer file.Close()
+
+	for _, transition := range transitions {
+		data, err := json.Marshal(transition)
+		if err != nil {
+			return fmt.Errorf("failed to marshal transition: %v", err)
+		}
+
+		_, err = file.Write(append(data, '\n'))
+		if err != nil {
+			return fmt.Errorf("failed to write transition to file: %v", err)
+		}
+	}
+
+	return nil
+}
+
+// FilterTransitions reads the log and returns a page matching the filter, newest first.
+func (hr *AlertHistoryFileRepository) FilterTransitions(ctx context.Context, filter types.AlertHistoryFilter) ([]*types.AlertTransition, error) {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	file, err := os.Open(hr.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open history file: %v", err)
+	}
+	defer file.Close()
+
+	var transitions []*types.AlertTransition
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Skip lines that cannot be decoded, such as a line cut short by a crash
+		var transition types.AlertTransition
+		if err := json.Unmarshal(scanner.Bytes(), &transition); err != nil {
+			continue
+		}
+		transitions = append(transitions, &transition)
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read file: %v", err)
+	}
+
+	return filterTransitions(transitions, filter), nil
+}
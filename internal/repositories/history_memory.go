@@ -0,0 +1,34 @@
+package repositories
+
+import (
+	"context"
+	"go-metrics-alerting/internal/types"
+	"sync"
+)
+
+type AlertHistoryMemoryRepository struct {
+	data []*types.AlertTransition
+	mu   sync.RWMutex
+}
+
+// NewAlertHistoryMemoryRepository creates a new instance of AlertHistoryMemoryRepository.
+func NewAlertHistoryMemoryRepository() *AlertHistoryMemoryRepository {
+	return &AlertHistoryMemoryRepository{}
+}
+
+// SaveTransitions appends the transitions to the in-memory log.
+func (hr *AlertHistoryMemoryRepository) SaveTransitions(ctx context.Context, transitions []*types.AlertTransition) error {
+	hr.mu.Lock()
+	defer hr.mu.Unlock()
+
+	hr.data = append(hr.data, transitions...)
+	return nil
+}
+
+// FilterTransitions returns a page of the log matching the filter, newest first.
+func (hr *AlertHistoryMemoryRepository) FilterTransitions(ctx context.Context, filter types.AlertHistoryFilter) ([]*types.AlertTransition, error) {
+	hr.mu.RLock()
+	defer hr.mu.RUnlock()
+
+	return filterTransitions(hr.data, filter), nil
+}
@@ -6,6 +6,7 @@ import (
 	"go-metrics-alerting/internal/configs"
 	"go-metrics-alerting/internal/types"
 	"os"
+	"time"
 )
 
 // MetricRepository holds the three repositories.
@@ -51,6 +52,12 @@ type MetricRepo interface {
 	SaveMetrics(ctx context.Context, metrics []*types.Metrics) error
 	FilterMetricsByTypeAndID(ctx context.Context, metricIDs []types.MetricID) ([]*types.Metrics, error)
 	ListMetrics(ctx context.Context) ([]*types.Metrics, error)
+	SaveSamples(ctx context.Context, samples []*types.MetricSample) error
+	ListSamples(ctx context.Context, id types.MetricID, from, to time.Time) ([]types.Sample, error)
+	SaveRollups(ctx context.Context, rollups []*types.MetricRollup) error
+	ListRollups(ctx context.Context, id types.MetricID, resolution time.Duration, from, to time.Time) ([]*types.MetricRollup, error)
+	DeleteSamples(ctx context.Context, cutoff types.RetentionCutoff) (int64, error)
+	DeleteRollups(ctx context.Context, resolution time.Duration, cutoff types.RetentionCutoff) (int64, error)
 }
 
 // GetMainRepository returns the repository with the highest priority (db -> file -> memory), based on ServerConfig.
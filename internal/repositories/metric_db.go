@@ -18,6 +18,8 @@ type MetricDBRepository struct {
 // NewMetricDBRepository creates a new instance of MetricDBRepository.
 func NewMetricDBRepository(c *configs.ServerConfig, db *sql.DB) *MetricDBRepository {
 	createMetricsTable(db)
+	createMetricSamplesTable(db)
+	createMetricRollupsTable(db)
 	return &MetricDBRepository{
 		db: db,
 		c:  c,
@@ -127,7 +129,7 @@ func (mr *MetricDBRepository) ListMetrics(ctx context.Context) ([]*types.Metrics
 
 func createMetricsTable(db *sql.DB) error {
 	query := `CREATE TABLE IF NOT EXISTS metrics (
-		id VARCHAR(255) NOT NULL,
+		id TEXT NOT NULL,
 		type VARCHAR(255) NOT NULL,
 		delta BIGINT,
 		value DOUBLE PRECISION,
@@ -138,5 +140,15 @@ func createMetricsTable(db *sql.DB) error {
 	if err != nil {
 		return err
 	}
+	if err := widenMetricIDColumn(db, "metrics", "id"); err != nil {
+		return err
+	}
 	return nil
 }
+
+// widenMetricIDColumn migrates a metric ID column created as VARCHAR(255) by earlier versions
+// to TEXT, as IDs built from labels, e.g. by remote write and OTLP, exceed 255 characters.
+func widenMetricIDColumn(db *sql.DB, table, column string) error {
+	_, err := db.Exec(fmt.Sprintf("ALTER TABLE %s ALTER COLUMN %s TYPE TEXT", table, column))
+	return err
+}
@@ -12,9 +12,10 @@ import (
 )
 
 type MetricFileRepository struct {
-	file *os.File
-	c    *configs.ServerConfig
-	mu   sync.Mutex
+	file      *os.File
+	c         *configs.ServerConfig
+	mu        sync.Mutex
+	samplesMu sync.Mutex // Guards the sample and rollup segments
 }
 
 // NewMetricFileRepository creates a new instance of MetricFileRepository with the provided file and ServerConfig.
@@ -3,33 +3,45 @@ package repositories
 import (
 	"context"
 	"go-metrics-alerting/internal/types"
+	"sync"
 )
 
 type MetricMemoryRepository struct {
-	data map[types.MetricID]*types.Metrics
+	data    map[types.MetricID]*types.Metrics
+	samples map[types.MetricID]*sampleRing
+	rollups map[rollupKey][]*types.MetricRollup // Sorted by start
+	mu      sync.RWMutex
 }
 
 // NewMetricMemoryRepository creates a new instance of MetricMemoryRepository.
 func NewMetricMemoryRepository() *MetricMemoryRepository {
 	return &MetricMemoryRepository{
-		data: make(map[types.MetricID]*types.Metrics), // Initialize the map
+		data:    make(map[types.MetricID]*types.Metrics), // Initialize the map
+		samples: make(map[types.MetricID]*sampleRing),
+		rollups: make(map[rollupKey][]*types.MetricRollup),
 	}
 }
 
 // SaveMetrics saves a list of metrics in the in-memory storage.
 func (mr *MetricMemoryRepository) SaveMetrics(ctx context.Context, metrics []*types.Metrics) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
 	for _, metric := range metrics {
 		// Create a MetricID for the key
 		metricID := types.MetricID{ID: metric.ID, Type: metric.Type}
 
-		// Store the metric in memory using MetricID as the key
-		mr.data[metricID] = metric
+		// Store a copy so that callers cannot change the stored metric without the lock
+		mr.data[metricID] = copyMetric(metric)
 	}
 	return nil
 }
 
 // FilterMetricsByTypeAndID filters metrics by their IDs and types, and returns matching metrics.
 func (mr *MetricMemoryRepository) FilterMetricsByTypeAndID(ctx context.Context, metricIDs []types.MetricID) ([]*types.Metrics, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
 	var result []*types.Metrics
 
 	// Iterate through the list of MetricID objects
@@ -37,7 +49,7 @@ func (mr *MetricMemoryRepository) FilterMetricsByTypeAndID(ctx context.Context,
 		// Retrieve the metric from memory using MetricID as the key
 		metric, exists := mr.data[metricID]
 		if exists {
-			result = append(result, metric)
+			result = append(result, copyMetric(metric))
 		}
 	}
 
@@ -46,12 +58,29 @@ func (mr *MetricMemoryRepository) FilterMetricsByTypeAndID(ctx context.Context,
 
 // ListMetrics lists all metrics stored in the in-memory storage.
 func (mr *MetricMemoryRepository) ListMetrics(ctx context.Context) ([]*types.Metrics, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
 	var result []*types.Metrics
 
 	// Iterate through the in-memory map and collect all metrics
 	for _, metric := range mr.data {
-		result = append(result, metric)
+		result = append(result, copyMetric(metric))
 	}
 
 	return result, nil
 }
+
+// copyMetric returns a deep copy of the metric, including its value pointers.
+func copyMetric(metric *types.Metrics) *types.Metrics {
+	copied := *metric
+	if metric.Delta != nil {
+		delta := *metric.Delta
+		copied.Delta = &delta
+	}
+	if metric.Value != nil {
+		value := *metric.Value
+		copied.Value = &value
+	}
+	return &copied
+}
@@ -0,0 +1,122 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"fmt"
+	"go-metrics-alerting/internal/types"
+	"time"
+)
+
+// SaveRollups creates or replaces the rollups in a single transaction.
+func (mr *MetricDBRepository) SaveRollups(ctx context.Context, rollups []*types.MetricRollup) error {
+	if len(rollups) == 0 {
+		return nil
+	}
+
+	tx, err := mr.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %v", err)
+	}
+	defer tx.Rollback()
+
+	query := `INSERT INTO metric_rollups (metric_id, metric_type, resolution, start_ts, rollup) VALUES ($1, $2, $3, $4, $5)
+			  ON CONFLICT (metric_id, metric_type, resolution, start_ts) DO UPDATE SET rollup = EXCLUDED.rollup`
+
+	for _, rollup := range rollups {
+		data, err := json.Marshal(rollup)
+		if err != nil {
+			return fmt.Errorf("failed to marshal metric rollup: %v", err)
+		}
+		if _, err := tx.ExecContext(ctx, query, rollup.ID, rollup.Type, rollup.Resolution, rollup.Start, data); err != nil {
+			return fmt.Errorf("failed to save metric rollup: %v", err)
+		}
+	}
+
+	return tx.Commit()
+}
+
+// ListRollups returns the rollups of the metric at the resolution starting from from to to, inclusive, in time order.
+func (mr *MetricDBRepository) ListRollups(ctx context.Context, id types.MetricID, resolution time.Duration, from, to time.Time) ([]*types.MetricRollup, error) {
+	query := `SELECT rollup FROM metric_rollups
+			  WHERE metric_id = $1 AND metric_type = $2 AND resolution = $3 AND start_ts >= $4 AND start_ts <= $5
+			  ORDER BY start_ts`
+	rows, err := mr.db.QueryContext(ctx, query, id.ID, id.Type, int64(resolution.Seconds()), from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metric rollups: %v", err)
+	}
+	defer rows.Close()
+
+	var rollups []*types.MetricRollup
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan metric rollup: %v", err)
+		}
+
+		var rollup types.MetricRollup
+		if err := json.Unmarshal(data, &rollup); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal metric rollup: %v", err)
+		}
+		rollups = append(rollups, &rollup)
+	}
+
+	// Handle any row iteration errors
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed during row iteration: %v", err)
+	}
+
+	return rollups, nil
+}
+
+// DeleteRollups removes the rollups at the resolution that expired according to the cutoff
+// and returns how many were removed.
+func (mr *MetricDBRepository) DeleteRollups(ctx context.Context, resolution time.Duration, cutoff types.RetentionCutoff) (int64, error) {
+	seconds := int64(resolution.Seconds())
+	ids, err := mr.listMetricIDs(ctx, `SELECT DISTINCT metric_id, metric_type FROM metric_rollups WHERE resolution = $1 AND start_ts < $2`,
+		seconds, cutoff.Latest)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, id := range ids {
+		before := cutoff.Before(id)
+		if before.IsZero() {
+			continue
+		}
+		result, err := mr.db.ExecContext(ctx, `DELETE FROM metric_rollups WHERE metric_id = $1 AND metric_type = $2 AND resolution = $3 AND start_ts < $4`,
+			id.ID, id.Type, seconds, before)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete metric rollups: %v", err)
+		}
+		count, _ := result.RowsAffected()
+		deleted += count
+	}
+	return deleted, nil
+}
+
+// createMetricRollupsTable stores every rollup as a JSON document keyed by metric, resolution and bucket start.
+// The index on resolution and start lets the retention find the expired rollups without a full scan.
+func createMetricRollupsTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS metric_rollups (
+		metric_id TEXT NOT NULL,
+		metric_type VARCHAR(255) NOT NULL,
+		resolution BIGINT NOT NULL,
+		start_ts TIMESTAMPTZ NOT NULL,
+		rollup JSONB NOT NULL,
+		PRIMARY KEY (metric_id, metric_type, resolution, start_ts)
+	)`
+
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+	if err := widenMetricIDColumn(db, "metric_rollups", "metric_id"); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS metric_rollups_resolution_start_ts_idx ON metric_rollups (resolution, start_ts)`)
+	return err
+}
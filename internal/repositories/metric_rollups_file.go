@@ -0,0 +1,90 @@
+package repositories
+
+import (
+	"context"
+	"go-metrics-alerting/internal/types"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"time"
+)
+
+// Rollup segments are stored per resolution in a directory alongside the metrics file,
+// every segment holding the rollups of one metric starting within one RollupSegmentDuration.
+// Saving a rollup again appends it; the last written version wins.
+const (
+	RollupSegmentDirName  = "rollups"
+	RollupSegmentDuration = 24 * time.Hour
+)
+
+// SaveRollups appends the rollups to the segments covering their starts.
+func (mr *MetricFileRepository) SaveRollups(ctx context.Context, rollups []*types.MetricRollup) error {
+	type segmentKey struct {
+		dir   string
+		start int64
+	}
+	segments := make(map[segmentKey][]*types.MetricRollup)
+	for _, rollup := range rollups {
+		key := segmentKey{
+			dir:   metricSegmentDir(mr.rollupDir(rollup.Resolution), types.MetricID{ID: rollup.ID, Type: rollup.Type}),
+			start: segmentStart(rollup.Start, RollupSegmentDuration),
+		}
+		segments[key] = append(segments[key], rollup)
+	}
+
+	mr.samplesMu.Lock()
+	defer mr.samplesMu.Unlock()
+
+	for key, rollups := range segments {
+		if err := appendSegment(key.dir, key.start, rollups); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListRollups returns the rollups of the metric at the resolution starting from from to to, inclusive, in time order.
+func (mr *MetricFileRepository) ListRollups(ctx context.Context, id types.MetricID, resolution time.Duration, from, to time.Time) ([]*types.MetricRollup, error) {
+	mr.samplesMu.Lock()
+	defer mr.samplesMu.Unlock()
+
+	dir := metricSegmentDir(mr.rollupDir(int64(resolution.Seconds())), id)
+	starts, err := listSegments(dir, RollupSegmentDuration, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	byStart := make(map[int64]*types.MetricRollup)
+	for _, start := range starts {
+		err := readSegment(dir, start, func(rollup *types.MetricRollup) {
+			if rollup.ID == id.ID && rollup.Type == id.Type &&
+				!rollup.Start.Before(from) && !rollup.Start.After(to) {
+				byStart[rollup.Start.UnixNano()] = rollup
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	rollups := make([]*types.MetricRollup, 0, len(byStart))
+	for _, rollup := range byStart {
+		rollups = append(rollups, rollup)
+	}
+	sort.Slice(rollups, func(i, j int) bool { return rollups[i].Start.Before(rollups[j].Start) })
+	return rollups, nil
+}
+
+// DeleteRollups removes the rollups at the resolution that expired according to the cutoff
+// from the segments and returns how many were removed, counting superseded versions.
+func (mr *MetricFileRepository) DeleteRollups(ctx context.Context, resolution time.Duration, cutoff types.RetentionCutoff) (int64, error) {
+	timestamp := func(rollup *types.MetricRollup) time.Time { return rollup.Start }
+	metric := func(rollup *types.MetricRollup) types.MetricID {
+		return types.MetricID{ID: rollup.ID, Type: rollup.Type}
+	}
+	return expireSegments(&mr.samplesMu, mr.rollupDir(int64(resolution.Seconds())), RollupSegmentDuration, cutoff, timestamp, metric)
+}
+
+func (mr *MetricFileRepository) rollupDir(resolution int64) string {
+	return filepath.Join(filepath.Dir(mr.c.FileStoragePath), RollupSegmentDirName, strconv.FormatInt(resolution, 10))
+}
@@ -0,0 +1,81 @@
+package repositories
+
+import (
+	"context"
+	"go-metrics-alerting/internal/types"
+	"sort"
+	"time"
+)
+
+// rollupKey identifies the rollups of a metric at one resolution.
+type rollupKey struct {
+	metric     types.MetricID
+	resolution int64
+}
+
+// SaveRollups creates or replaces the rollups, keyed by metric, resolution and start.
+func (mr *MetricMemoryRepository) SaveRollups(ctx context.Context, rollups []*types.MetricRollup) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	for _, rollup := range rollups {
+		key := rollupKey{metric: types.MetricID{ID: rollup.ID, Type: rollup.Type}, resolution: rollup.Resolution}
+		stored := mr.rollups[key]
+
+		i := sort.Search(len(stored), func(i int) bool { return !stored[i].Start.Before(rollup.Start) })
+		if i < len(stored) && stored[i].Start.Equal(rollup.Start) {
+			stored[i] = rollup
+			continue
+		}
+		stored = append(stored, nil)
+		copy(stored[i+1:], stored[i:])
+		stored[i] = rollup
+		mr.rollups[key] = stored
+	}
+	return nil
+}
+
+// ListRollups returns the rollups of the metric at the resolution starting from from to to, inclusive, in time order.
+func (mr *MetricMemoryRepository) ListRollups(ctx context.Context, id types.MetricID, resolution time.Duration, from, to time.Time) ([]*types.MetricRollup, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	var result []*types.MetricRollup
+	for _, rollup := range mr.rollups[rollupKey{metric: id, resolution: int64(resolution.Seconds())}] {
+		if !rollup.Start.Before(from) && !rollup.Start.After(to) {
+			result = append(result, rollup)
+		}
+	}
+	return result, nil
+}
+
+// DeleteRollups removes the rollups at the resolution that expired according to the cutoff
+// and returns how many were removed.
+func (mr *MetricMemoryRepository) DeleteRollups(ctx context.Context, resolution time.Duration, cutoff types.RetentionCutoff) (int64, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	var deleted int64
+	for key, stored := range mr.rollups {
+		if key.resolution != int64(resolution.Seconds()) {
+			continue
+		}
+		before := cutoff.Before(key.metric)
+		if before.IsZero() {
+			continue
+		}
+
+		// Rollups are sorted by start, so the expired ones form a prefix
+		i := sort.Search(len(stored), func(i int) bool { return !stored[i].Start.Before(before) })
+		if i == 0 {
+			continue
+		}
+		deleted += int64(i)
+		if i == len(stored) {
+			delete(mr.rollups, key)
+		} else {
+			mr.rollups[key] = append([]*types.MetricRollup(nil), stored[i:]...)
+		}
+	}
+	return deleted, nil
+}
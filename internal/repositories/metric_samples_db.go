@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"go-metrics-alerting/internal/types"
+	"strings"
+	"time"
+)
+
+// DBSampleInsertBatch is how many samples are inserted with a single statement,
+// keeping the number of placeholders well below the Postgres limit.
+const DBSampleInsertBatch = 1000
+
+// SaveSamples inserts the samples into the metric_samples table. A sample of a metric
+// at an already stored time replaces the stored value.
+func (mr *MetricDBRepository) SaveSamples(ctx context.Context, samples []*types.MetricSample) error {
+	for start := 0; start < len(samples); start += DBSampleInsertBatch {
+		end := min(start+DBSampleInsertBatch, len(samples))
+
+		var query strings.Builder
+		query.WriteString("INSERT INTO metric_samples (metric_id, metric_type, ts, value) VALUES ")
+		var args []interface{}
+		for i, sample := range samples[start:end] {
+			if i > 0 {
+				query.WriteString(", ")
+			}
+			fmt.Fprintf(&query, "($%d, $%d, $%d, $%d)", i*4+1, i*4+2, i*4+3, i*4+4)
+			args = append(args, sample.ID, sample.Type, sample.Timestamp, sample.Value)
+		}
+		query.WriteString(" ON CONFLICT (metric_id, metric_type, ts) DO UPDATE SET value = EXCLUDED.value")
+
+		if _, err := mr.db.ExecContext(ctx, query.String(), args...); err != nil {
+			return fmt.Errorf("failed to save metric samples: %v", err)
+		}
+	}
+	return nil
+}
+
+// ListSamples returns the samples of the metric taken from from to to, inclusive, in time order.
+func (mr *MetricDBRepository) ListSamples(ctx context.Context, id types.MetricID, from, to time.Time) ([]types.Sample, error) {
+	query := `SELECT ts, value FROM metric_samples
+			  WHERE metric_id = $1 AND metric_type = $2 AND ts >= $3 AND ts <= $4
+			  ORDER BY ts`
+	rows, err := mr.db.QueryContext(ctx, query, id.ID, id.Type, from, to)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metric samples: %v", err)
+	}
+	defer rows.Close()
+
+	var samples []types.Sample
+	for rows.Next() {
+		var sample types.Sample
+		if err := rows.Scan(&sample.Timestamp, &sample.Value); err != nil {
+			return nil, fmt.Errorf("failed to scan metric sample: %v", err)
+		}
+		samples = append(samples, sample)
+	}
+
+	// Handle any row iteration errors
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed during row iteration: %v", err)
+	}
+
+	return samples, nil
+}
+
+// DeleteSamples removes the samples that expired according to the cutoff and returns how many were removed.
+func (mr *MetricDBRepository) DeleteSamples(ctx context.Context, cutoff types.RetentionCutoff) (int64, error) {
+	ids, err := mr.listMetricIDs(ctx, `SELECT DISTINCT metric_id, metric_type FROM metric_samples WHERE ts < $1`, cutoff.Latest)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, id := range ids {
+		before := cutoff.Before(id)
+		if before.IsZero() {
+			continue
+		}
+		result, err := mr.db.ExecContext(ctx, `DELETE FROM metric_samples WHERE metric_id = $1 AND metric_type = $2 AND ts < $3`,
+			id.ID, id.Type, before)
+		if err != nil {
+			return deleted, fmt.Errorf("failed to delete metric samples: %v", err)
+		}
+		count, _ := result.RowsAffected()
+		deleted += count
+	}
+	return deleted, nil
+}
+
+// listMetricIDs runs a query selecting metric IDs and types.
+func (mr *MetricDBRepository) listMetricIDs(ctx context.Context, query string, args ...interface{}) ([]types.MetricID, error) {
+	rows, err := mr.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to query metric IDs: %v", err)
+	}
+	defer rows.Close()
+
+	var ids []types.MetricID
+	for rows.Next() {
+		var id types.MetricID
+		if err := rows.Scan(&id.ID, &id.Type); err != nil {
+			return nil, fmt.Errorf("failed to scan metric ID: %v", err)
+		}
+		ids = append(ids, id)
+	}
+
+	// Handle any row iteration errors
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed during row iteration: %v", err)
+	}
+
+	return ids, nil
+}
+
+// createMetricSamplesTable stores the history of every metric; the primary key serves range reads
+// and the index on the time lets the retention find the expired samples without a full scan.
+func createMetricSamplesTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS metric_samples (
+		metric_id TEXT NOT NULL,
+		metric_type VARCHAR(255) NOT NULL,
+		ts TIMESTAMPTZ NOT NULL,
+		value DOUBLE PRECISION NOT NULL,
+		PRIMARY KEY (metric_id, metric_type, ts)
+	)`
+
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+	if err := widenMetricIDColumn(db, "metric_samples", "metric_id"); err != nil {
+		return err
+	}
+
+	_, err = db.Exec(`CREATE INDEX IF NOT EXISTS metric_samples_ts_idx ON metric_samples (ts)`)
+	return err
+}
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"go-metrics-alerting/internal/types"
+	"path/filepath"
+	"sort"
+	"time"
+)
+
+// Sample segments are stored in a directory alongside the metrics file, every segment
+// holding the samples of one metric taken within one SampleSegmentDuration.
+const (
+	SampleSegmentDirName  = "samples"
+	SampleSegmentDuration = time.Hour
+)
+
+// SaveSamples appends the samples to the segments of their metrics covering their timestamps.
+func (mr *MetricFileRepository) SaveSamples(ctx context.Context, samples []*types.MetricSample) error {
+	type segmentKey struct {
+		dir   string
+		start int64
+	}
+	segments := make(map[segmentKey][]*types.MetricSample)
+	for _, sample := range samples {
+		key := segmentKey{
+			dir:   metricSegmentDir(mr.sampleDir(), types.MetricID{ID: sample.ID, Type: sample.Type}),
+			start: segmentStart(sample.Timestamp, SampleSegmentDuration),
+		}
+		segments[key] = append(segments[key], sample)
+	}
+
+	mr.samplesMu.Lock()
+	defer mr.samplesMu.Unlock()
+
+	for key, samples := range segments {
+		if err := appendSegment(key.dir, key.start, samples); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// ListSamples returns the samples of the metric taken from from to to, inclusive, in time order.
+func (mr *MetricFileRepository) ListSamples(ctx context.Context, id types.MetricID, from, to time.Time) ([]types.Sample, error) {
+	mr.samplesMu.Lock()
+	defer mr.samplesMu.Unlock()
+
+	dir := metricSegmentDir(mr.sampleDir(), id)
+	starts, err := listSegments(dir, SampleSegmentDuration, from, to)
+	if err != nil {
+		return nil, err
+	}
+
+	var samples []types.Sample
+	for _, start := range starts {
+		err := readSegment(dir, start, func(sample *types.MetricSample) {
+			if sample.ID == id.ID && sample.Type == id.Type &&
+				!sample.Timestamp.Before(from) && !sample.Timestamp.After(to) {
+				samples = append(samples, types.Sample{Timestamp: sample.Timestamp, Value: sample.Value})
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	sort.SliceStable(samples, func(i, j int) bool { return samples[i].Timestamp.Before(samples[j].Timestamp) })
+	return samples, nil
+}
+
+// DeleteSamples removes the samples that expired according to the cutoff from the segments
+// and returns how many were removed.
+func (mr *MetricFileRepository) DeleteSamples(ctx context.Context, cutoff types.RetentionCutoff) (int64, error) {
+	timestamp := func(sample *types.MetricSample) time.Time { return sample.Timestamp }
+	metric := func(sample *types.MetricSample) types.MetricID {
+		return types.MetricID{ID: sample.ID, Type: sample.Type}
+	}
+	return expireSegments(&mr.samplesMu, mr.sampleDir(), SampleSegmentDuration, cutoff, timestamp, metric)
+}
+
+func (mr *MetricFileRepository) sampleDir() string {
+	return filepath.Join(filepath.Dir(mr.c.FileStoragePath), SampleSegmentDirName)
+}
@@ -0,0 +1,104 @@
+package repositories
+
+import (
+	"context"
+	"go-metrics-alerting/internal/types"
+	"sort"
+	"time"
+)
+
+// MemorySampleCapacity is how many samples the memory repository keeps per metric,
+// a day of samples at the default agent report interval of 10 seconds.
+const MemorySampleCapacity = 8640
+
+// sampleRing keeps the latest samples of a metric, overwriting the oldest once full.
+type sampleRing struct {
+	samples []types.Sample
+	next    int // Index of the oldest sample once the ring is full
+}
+
+func (r *sampleRing) add(sample types.Sample) {
+	if len(r.samples) < MemorySampleCapacity {
+		r.samples = append(r.samples, sample)
+		return
+	}
+	r.samples[r.next] = sample
+	r.next = (r.next + 1) % MemorySampleCapacity
+}
+
+// between returns the samples taken from from to to, inclusive, in time order.
+func (r *sampleRing) between(from, to time.Time) []types.Sample {
+	var result []types.Sample
+	for i := range r.samples {
+		sample := r.samples[(r.next+i)%len(r.samples)]
+		if !sample.Timestamp.Before(from) && !sample.Timestamp.After(to) {
+			result = append(result, sample)
+		}
+	}
+	sort.SliceStable(result, func(i, j int) bool { return result[i].Timestamp.Before(result[j].Timestamp) })
+	return result
+}
+
+// dropBefore removes the samples taken before the cutoff and returns how many were removed.
+func (r *sampleRing) dropBefore(cutoff time.Time) int {
+	kept := make([]types.Sample, 0, len(r.samples))
+	for i := range r.samples {
+		sample := r.samples[(r.next+i)%len(r.samples)]
+		if !sample.Timestamp.Before(cutoff) {
+			kept = append(kept, sample)
+		}
+	}
+	dropped := len(r.samples) - len(kept)
+	if dropped > 0 {
+		r.samples, r.next = kept, 0
+	}
+	return dropped
+}
+
+// SaveSamples appends the samples to the ring buffers of their metrics.
+func (mr *MetricMemoryRepository) SaveSamples(ctx context.Context, samples []*types.MetricSample) error {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	for _, sample := range samples {
+		id := types.MetricID{ID: sample.ID, Type: sample.Type}
+		ring, exists := mr.samples[id]
+		if !exists {
+			ring = &sampleRing{}
+			mr.samples[id] = ring
+		}
+		ring.add(types.Sample{Timestamp: sample.Timestamp, Value: sample.Value})
+	}
+	return nil
+}
+
+// ListSamples returns the samples of the metric taken from from to to, inclusive, in time order.
+func (mr *MetricMemoryRepository) ListSamples(ctx context.Context, id types.MetricID, from, to time.Time) ([]types.Sample, error) {
+	mr.mu.RLock()
+	defer mr.mu.RUnlock()
+
+	ring, exists := mr.samples[id]
+	if !exists {
+		return nil, nil
+	}
+	return ring.between(from, to), nil
+}
+
+// DeleteSamples removes the samples that expired according to the cutoff and returns how many were removed.
+func (mr *MetricMemoryRepository) DeleteSamples(ctx context.Context, cutoff types.RetentionCutoff) (int64, error) {
+	mr.mu.Lock()
+	defer mr.mu.Unlock()
+
+	var deleted int64
+	for id, ring := range mr.samples {
+		before := cutoff.Before(id)
+		if before.IsZero() {
+			continue
+		}
+		deleted += int64(ring.dropBefore(before))
+		if len(ring.samples) == 0 {
+			delete(mr.samples, id)
+		}
+	}
+	return deleted, nil
+}
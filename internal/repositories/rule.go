@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+)
+
+// AlertRuleRepository holds the three alert rule repositories.
+type AlertRuleRepository struct {
+	DBRepo     *AlertRuleDBRepository
+	FileRepo   *AlertRuleFileRepository
+	MemoryRepo *AlertRuleMemoryRepository
+}
+
+// NewAlertRuleRepository creates a new instance of AlertRuleRepository, containing all three repositories.
+func NewAlertRuleRepository(c *configs.ServerConfig, db *sql.DB) *AlertRuleRepository {
+	var dbRepo *AlertRuleDBRepository
+	var fileRepo *AlertRuleFileRepository
+
+	// Initialize DB repository if DatabaseDSN is provided
+	if c.DatabaseDSN != "" {
+		dbRepo = NewAlertRuleDBRepository(c, db)
+	}
+
+	// Initialize File repository if FileStoragePath is provided
+	if c.FileStoragePath != "" {
+		fileRepo = NewAlertRuleFileRepository(c)
+	}
+
+	return &AlertRuleRepository{
+		DBRepo:     dbRepo,
+		FileRepo:   fileRepo,
+		MemoryRepo: NewAlertRuleMemoryRepository(),
+	}
+}
+
+// AlertRuleRepo defines the common methods for all alert rule repositories.
+type AlertRuleRepo interface {
+	SaveRule(ctx context.Context, rule *types.AlertRule) error
+	GetRuleByID(ctx context.Context, id string) (*types.AlertRule, error)
+	ListRules(ctx context.Context) ([]*types.AlertRule, error)
+	DeleteRule(ctx context.Context, id string) error
+}
+
+// GetMainRepository returns the repository with the highest priority (db -> file -> memory), based on ServerConfig.
+func (rr *AlertRuleRepository) GetMainRepository(c *configs.ServerConfig) AlertRuleRepo {
+	if rr.DBRepo != nil && c.DatabaseDSN != "" {
+		return rr.DBRepo
+	}
+
+	if rr.FileRepo != nil && c.FileStoragePath != "" {
+		return rr.FileRepo
+	}
+
+	if rr.MemoryRepo != nil {
+		return rr.MemoryRepo
+	}
+
+	return nil
+}
@@ -0,0 +1,108 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+)
+
+type AlertRuleDBRepository struct {
+	db *sql.DB
+	c  *configs.ServerConfig
+}
+
+// NewAlertRuleDBRepository creates a new instance of AlertRuleDBRepository.
+func NewAlertRuleDBRepository(c *configs.ServerConfig, db *sql.DB) *AlertRuleDBRepository {
+	createRulesTable(db)
+	return &AlertRuleDBRepository{
+		db: db,
+		c:  c,
+	}
+}
+
+// SaveRule creates or replaces the rule in the database.
+func (rr *AlertRuleDBRepository) SaveRule(ctx context.Context, rule *types.AlertRule) error {
+	data, err := json.Marshal(rule)
+	if err != nil {
+		return fmt.Errorf("failed to marshal rule: %v", err)
+	}
+
+	query := `INSERT INTO rules (id, rule) VALUES ($1, $2)
+			  ON CONFLICT (id) DO UPDATE SET rule = EXCLUDED.rule`
+
+	_, err = rr.db.ExecContext(ctx, query, rule.ID, data)
+	return err
+}
+
+// GetRuleByID returns the rule with the given ID or nil if it does not exist.
+func (rr *AlertRuleDBRepository) GetRuleByID(ctx context.Context, id string) (*types.AlertRule, error) {
+	var data []byte
+	err := rr.db.QueryRowContext(ctx, "SELECT rule FROM rules WHERE id = $1", id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rule: %v", err)
+	}
+
+	var rule types.AlertRule
+	if err := json.Unmarshal(data, &rule); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rule: %v", err)
+	}
+
+	return &rule, nil
+}
+
+// ListRules lists all rules stored in the database ordered by ID.
+func (rr *AlertRuleDBRepository) ListRules(ctx context.Context) ([]*types.AlertRule, error) {
+	rows, err := rr.db.QueryContext(ctx, "SELECT rule FROM rules ORDER BY id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query rules: %v", err)
+	}
+	defer rows.Close()
+
+	var rules []*types.AlertRule
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan rule: %v", err)
+		}
+
+		var rule types.AlertRule
+		if err := json.Unmarshal(data, &rule); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal rule: %v", err)
+		}
+		rules = append(rules, &rule)
+	}
+
+	// Handle any row iteration errors
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed during row iteration: %v", err)
+	}
+
+	return rules, nil
+}
+
+// DeleteRule removes the rule from the database.
+func (rr *AlertRuleDBRepository) DeleteRule(ctx context.Context, id string) error {
+	_, err := rr.db.ExecContext(ctx, "DELETE FROM rules WHERE id = $1", id)
+	return err
+}
+
+// createRulesTable stores every rule as a JSON document so new rule fields need no migration.
+func createRulesTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS rules (
+		id VARCHAR(255) NOT NULL PRIMARY KEY,
+		rule JSONB NOT NULL
+	)`
+
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,136 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// AlertRuleFileName is the name of the rules file stored alongside the metrics file.
+const AlertRuleFileName = "rules.json"
+
+type AlertRuleFileRepository struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewAlertRuleFileRepository creates a new instance of AlertRuleFileRepository.
+// Unlike the metrics file, the rules file is never truncated on start so rules survive restarts.
+func NewAlertRuleFileRepository(c *configs.ServerConfig) *AlertRuleFileRepository {
+	return &AlertRuleFileRepository{
+		path: filepath.Join(filepath.Dir(c.FileStoragePath), AlertRuleFileName),
+	}
+}
+
+// SaveRule creates or replaces the rule in the file.
+func (rr *AlertRuleFileRepository) SaveRule(ctx context.Context, rule *types.AlertRule) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	rules, err := rr.readRules()
+	if err != nil {
+		return err
+	}
+	rules[rule.ID] = rule
+
+	return rr.writeRules(rules)
+}
+
+// GetRuleByID returns the rule with the given ID or nil if it does not exist.
+func (rr *AlertRuleFileRepository) GetRuleByID(ctx context.Context, id string) (*types.AlertRule, error) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	rules, err := rr.readRules()
+	if err != nil {
+		return nil, err
+	}
+
+	return rules[id], nil
+}
+
+// ListRules lists all rules stored in the file ordered by ID.
+func (rr *AlertRuleFileRepository) ListRules(ctx context.Context) ([]*types.AlertRule, error) {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	rules, err := rr.readRules()
+	if err != nil {
+		return nil, err
+	}
+
+	var result []*types.AlertRule
+	for _, rule := range rules {
+		result = append(result, rule)
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+
+	return result, nil
+}
+
+// DeleteRule removes the rule from the file.
+func (rr *AlertRuleFileRepository) DeleteRule(ctx context.Context, id string) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	rules, err := rr.readRules()
+	if err != nil {
+		return err
+	}
+	delete(rules, id)
+
+	return rr.writeRules(rules)
+}
+
+// readRules reads all rules from the file, treating a missing file as empty.
+func (rr *AlertRuleFileRepository) readRules() (map[string]*types.AlertRule, error) {
+	rules := make(map[string]*types.AlertRule)
+
+	data, err := os.ReadFile(rr.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return rules, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read rules file: %v", err)
+	}
+	if len(data) == 0 {
+		return rules, nil
+	}
+
+	var list []*types.AlertRule
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal rules: %v", err)
+	}
+	for _, rule := range list {
+		rules[rule.ID] = rule
+	}
+
+	return rules, nil
+}
+
+// writeRules overwrites the file with the given rules.
+func (rr *AlertRuleFileRepository) writeRules(rules map[string]*types.AlertRule) error {
+	list := make([]*types.AlertRule, 0, len(rules))
+	for _, rule := range rules {
+		list = append(list, rule)
+	}
+	sort.Slice(list, func(i, j int) bool { return list[i].ID < list[j].ID })
+
+	data, err := json.MarshalIndent(list, "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal rules: %v", err)
+	}
+
+	if err := os.WriteFile(rr.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write rules file: %v", err)
+	}
+
+	return nil
+}
@@ -0,0 +1,73 @@
+package repositories
+
+import (
+	"context"
+	"go-metrics-alerting/internal/types"
+	"maps"
+	"sort"
+	"sync"
+)
+
+type AlertRuleMemoryRepository struct {
+	data map[string]*types.AlertRule
+	mu   sync.RWMutex
+}
+
+// NewAlertRuleMemoryRepository creates a new instance of AlertRuleMemoryRepository.
+func NewAlertRuleMemoryRepository() *AlertRuleMemoryRepository {
+	return &AlertRuleMemoryRepository{
+		data: make(map[string]*types.AlertRule),
+	}
+}
+
+// SaveRule creates or replaces the rule in the in-memory storage.
+func (rr *AlertRuleMemoryRepository) SaveRule(ctx context.Context, rule *types.AlertRule) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	rr.data[rule.ID] = copyRule(rule)
+	return nil
+}
+
+// GetRuleByID returns the rule with the given ID or nil if it does not exist.
+func (rr *AlertRuleMemoryRepository) GetRuleByID(ctx context.Context, id string) (*types.AlertRule, error) {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	rule, exists := rr.data[id]
+	if !exists {
+		return nil, nil
+	}
+	return copyRule(rule), nil
+}
+
+// ListRules lists all rules stored in memory ordered by ID.
+func (rr *AlertRuleMemoryRepository) ListRules(ctx context.Context) ([]*types.AlertRule, error) {
+	rr.mu.RLock()
+	defer rr.mu.RUnlock()
+
+	var result []*types.AlertRule
+	for _, rule := range rr.data {
+		result = append(result, copyRule(rule))
+	}
+	sort.Slice(result, func(i, j int) bool { return result[i].ID < result[j].ID })
+
+	return result, nil
+}
+
+// DeleteRule removes the rule from memory.
+func (rr *AlertRuleMemoryRepository) DeleteRule(ctx context.Context, id string) error {
+	rr.mu.Lock()
+	defer rr.mu.Unlock()
+
+	delete(rr.data, id)
+	return nil
+}
+
+// copyRule returns a deep copy of the rule, including its label and template maps.
+func copyRule(rule *types.AlertRule) *types.AlertRule {
+	copied := *rule
+	copied.Labels = maps.Clone(rule.Labels)
+	copied.Templates = maps.Clone(rule.Templates)
+	return &copied
+}
@@ -0,0 +1,309 @@
+package repositories
+
+import (
+	"bufio"
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Segment files hold the records of one metric written within a fixed time window, one JSON
+// object per line, and are named after the Unix time their window starts at. Every metric
+// has its own directory of segments, so that reading its history skips other metrics.
+const (
+	segmentPrefix = "segment-"
+	segmentSuffix = ".jsonl"
+)
+
+func segmentPath(dir string, start int64) string {
+	return filepath.Join(dir, segmentPrefix+strconv.FormatInt(start, 10)+segmentSuffix)
+}
+
+// metricSegmentDir returns the directory below dir holding the segments of the metric. It is
+// named after a hash, as metric IDs may contain any character.
+func metricSegmentDir(dir string, id types.MetricID) string {
+	sum := sha256.Sum256([]byte(id.Type + "\x00" + id.ID))
+	return filepath.Join(dir, hex.EncodeToString(sum[:16]))
+}
+
+// listMetricSegmentDirs returns the metric directories below dir.
+func listMetricSegmentDirs(dir string) ([]string, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list segment directories: %v", err)
+	}
+
+	var dirs []string
+	for _, entry := range entries {
+		if entry.IsDir() {
+			dirs = append(dirs, filepath.Join(dir, entry.Name()))
+		}
+	}
+	return dirs, nil
+}
+
+// expireSegments deletes the records that expired according to the cutoff from the metric
+// directories below dir and returns how many were removed. Segments ending before the expiry of
+// their metric are removed without decoding them and later segments are not read at all, so
+// only the segment holding the expiry is rewritten. The lock is held for one metric at a time,
+// so that writers are not blocked for the whole run.
+func expireSegments[T any](mu *sync.Mutex, dir string, window time.Duration, cutoff types.RetentionCutoff, timestamp func(record *T) time.Time, metric func(record *T) types.MetricID) (int64, error) {
+	dirs, err := listMetricSegmentDirs(dir)
+	if err != nil {
+		return 0, err
+	}
+
+	var deleted int64
+	for _, metricDir := range dirs {
+		mu.Lock()
+		removed, err := expireMetricSegments(metricDir, window, cutoff, timestamp, metric)
+		mu.Unlock()
+		deleted += removed
+		if err != nil {
+			return deleted, err
+		}
+	}
+	return deleted, nil
+}
+
+// expireMetricSegments deletes the expired records from the segments of a single metric.
+// Called with the lock held.
+func expireMetricSegments[T any](dir string, window time.Duration, cutoff types.RetentionCutoff, timestamp func(record *T) time.Time, metric func(record *T) types.MetricID) (int64, error) {
+	starts, err := listSegments(dir, window, time.Time{}, cutoff.Latest)
+	if err != nil || len(starts) == 0 {
+		return 0, err
+	}
+
+	// All records of the directory belong to one metric, so any of them tells its expiry
+	var expiry time.Time
+	for _, start := range starts {
+		record, err := readFirstRecord[T](dir, start)
+		if err != nil {
+			return 0, err
+		}
+		if record != nil {
+			expiry = cutoff.Before(metric(record))
+			break
+		}
+	}
+	if expiry.IsZero() {
+		return 0, nil
+	}
+
+	var deleted int64
+	for _, start := range starts {
+		begin := time.Unix(start, 0)
+		if !begin.Before(expiry) {
+			break
+		}
+
+		var removed int64
+		if begin.Add(window).After(expiry) {
+			removed, err = compactSegment(dir, start, func(record *T) bool { return !timestamp(record).Before(expiry) })
+		} else {
+			removed, err = removeSegment(dir, start)
+		}
+		deleted += removed
+		if err != nil {
+			return deleted, err
+		}
+	}
+
+	return deleted, removeEmptyDir(dir)
+}
+
+// readFirstRecord returns the first record of the segment that can be decoded, or nil if there is none.
+func readFirstRecord[T any](dir string, start int64) (*T, error) {
+	file, err := os.Open(segmentPath(dir, start))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to open segment: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record T
+		if err := json.Unmarshal(scanner.Bytes(), &record); err == nil {
+			return &record, nil
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, fmt.Errorf("failed to read segment: %v", err)
+	}
+	return nil, nil
+}
+
+// removeSegment deletes the segment and returns how many records it held, counting the
+// complete lines rather than decoding them.
+func removeSegment(dir string, start int64) (int64, error) {
+	path := segmentPath(dir, start)
+	data, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, fmt.Errorf("failed to read segment: %v", err)
+	}
+	if err := os.Remove(path); err != nil {
+		return 0, fmt.Errorf("failed to remove segment: %v", err)
+	}
+	return int64(bytes.Count(data, []byte{'\n'})), nil
+}
+
+// removeEmptyDir removes the directory once compaction deleted all of its segments.
+func removeEmptyDir(dir string) error {
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return fmt.Errorf("failed to list segments: %v", err)
+	}
+	if len(entries) > 0 {
+		return nil
+	}
+	if err := os.Remove(dir); err != nil {
+		return fmt.Errorf("failed to remove segment directory: %v", err)
+	}
+	return nil
+}
+
+// segmentStart returns the start of the segment of the given window length holding the time.
+func segmentStart(t time.Time, window time.Duration) int64 {
+	return t.Truncate(window).Unix()
+}
+
+// appendSegment appends the records to the segment, creating the directory and file as needed.
+func appendSegment[T any](dir string, start int64, records []T) error {
+	if err := os.MkdirAll(dir, 0755); err != nil {
+		return fmt.Errorf("failed to create segment directory: %v", err)
+	}
+	return writeRecords(segmentPath(dir, start), os.O_CREATE|os.O_WRONLY|os.O_APPEND, records)
+}
+
+// writeRecords writes the records to the file opened with the flags, one JSON object per line.
+func writeRecords[T any](path string, flag int, records []T) error {
+	file, err := os.OpenFile(path, flag, 0644)
+	if err != nil {
+		return fmt.Errorf("failed to open segment: %v", err)
+	}
+	defer file.Close()
+
+	// Write the lines at once so that a failed write leaves at most one partial line
+	var data []byte
+	for _, record := range records {
+		line, err := json.Marshal(record)
+		if err != nil {
+			return fmt.Errorf("failed to marshal segment record: %v", err)
+		}
+		data = append(append(data, line...), '\n')
+	}
+	if _, err := file.Write(data); err != nil {
+		return fmt.Errorf("failed to write segment: %v", err)
+	}
+	return nil
+}
+
+// readSegment calls fn for every record of the segment in write order, skipping lines
+// that cannot be decoded, such as a line cut short by a crash.
+func readSegment[T any](dir string, start int64, fn func(record *T)) error {
+	file, err := os.Open(segmentPath(dir, start))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil
+	}
+	if err != nil {
+		return fmt.Errorf("failed to open segment: %v", err)
+	}
+	defer file.Close()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var record T
+		if err := json.Unmarshal(scanner.Bytes(), &record); err != nil {
+			continue
+		}
+		fn(&record)
+	}
+	if err := scanner.Err(); err != nil {
+		return fmt.Errorf("failed to read segment: %v", err)
+	}
+	return nil
+}
+
+// compactSegment rewrites the segment with the records passing keep and returns how many were
+// removed. A segment without records left is deleted.
+func compactSegment[T any](dir string, start int64, keep func(record *T) bool) (int64, error) {
+	var kept []*T
+	var removed int64
+	err := readSegment(dir, start, func(record *T) {
+		if keep(record) {
+			kept = append(kept, record)
+		} else {
+			removed++
+		}
+	})
+	if err != nil || removed == 0 {
+		return 0, err
+	}
+
+	path := segmentPath(dir, start)
+	if len(kept) == 0 {
+		if err := os.Remove(path); err != nil {
+			return 0, fmt.Errorf("failed to remove segment: %v", err)
+		}
+		return removed, nil
+	}
+
+	// Write the remaining records next to the segment and swap them in at once
+	if err := writeRecords(path+".tmp", os.O_CREATE|os.O_WRONLY|os.O_TRUNC, kept); err != nil {
+		return 0, err
+	}
+	if err := os.Rename(path+".tmp", path); err != nil {
+		return 0, fmt.Errorf("failed to replace segment: %v", err)
+	}
+	return removed, nil
+}
+
+// listSegments returns the start times of the segments in the directory overlapping
+// the interval from from to to, in ascending order.
+func listSegments(dir string, window time.Duration, from, to time.Time) ([]int64, error) {
+	entries, err := os.ReadDir(dir)
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to list segments: %v", err)
+	}
+
+	var starts []int64
+	for _, entry := range entries {
+		name := entry.Name()
+		if entry.IsDir() || !strings.HasPrefix(name, segmentPrefix) || !strings.HasSuffix(name, segmentSuffix) {
+			continue
+		}
+		start, err := strconv.ParseInt(strings.TrimSuffix(strings.TrimPrefix(name, segmentPrefix), segmentSuffix), 10, 64)
+		if err != nil {
+			continue
+		}
+		if begin := time.Unix(start, 0); begin.After(to) || !begin.Add(window).After(from) {
+			continue
+		}
+		starts = append(starts, start)
+	}
+	sort.Slice(starts, func(i, j int) bool { return starts[i] < starts[j] })
+	return starts, nil
+}
@@ -0,0 +1,62 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+)
+
+// SilenceRepository holds the three silence repositories.
+type SilenceRepository struct {
+	DBRepo     *SilenceDBRepository
+	FileRepo   *SilenceFileRepository
+	MemoryRepo *SilenceMemoryRepository
+}
+
+// NewSilenceRepository creates a new instance of SilenceRepository, containing all three repositories.
+func NewSilenceRepository(c *configs.ServerConfig, db *sql.DB) *SilenceRepository {
+	var dbRepo *SilenceDBRepository
+	var fileRepo *SilenceFileRepository
+
+	// Initialize DB repository if DatabaseDSN is provided
+	if c.DatabaseDSN != "" {
+		dbRepo = NewSilenceDBRepository(c, db)
+	}
+
+	// Initialize File repository if FileStoragePath is provided
+	if c.FileStoragePath != "" {
+		fileRepo = NewSilenceFileRepository(c)
+	}
+
+	return &SilenceRepository{
+		DBRepo:     dbRepo,
+		FileRepo:   fileRepo,
+		MemoryRepo: NewSilenceMemoryRepository(),
+	}
+}
+
+// SilenceRepo defines the common methods for all silence repositories.
+type SilenceRepo interface {
+	SaveSilence(ctx context.Context, silence *types.Silence) error
+	GetSilenceByID(ctx context.Context, id string) (*types.Silence, error)
+	ListSilences(ctx context.Context) ([]*types.Silence, error)
+	DeleteSilence(ctx context.Context, id string) error
+}
+
+// GetMainRepository returns the repository with the highest priority (db -> file -> memory), based on ServerConfig.
+func (sr *SilenceRepository) GetMainRepository(c *configs.ServerConfig) SilenceRepo {
+	if sr.DBRepo != nil && c.DatabaseDSN != "" {
+		return sr.DBRepo
+	}
+
+	if sr.FileRepo != nil && c.FileStoragePath != "" {
+		return sr.FileRepo
+	}
+
+	if sr.MemoryRepo != nil {
+		return sr.MemoryRepo
+	}
+
+	return nil
+}
@@ -0,0 +1,110 @@
+package repositories
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+)
+
+type SilenceDBRepository struct {
+	db *sql.DB
+	c  *configs.ServerConfig
+}
+
+// NewSilenceDBRepository creates a new instance of SilenceDBRepository.
+func NewSilenceDBRepository(c *configs.ServerConfig, db *sql.DB) *SilenceDBRepository {
+	createSilencesTable(db)
+	return &SilenceDBRepository{
+		db: db,
+		c:  c,
+	}
+}
+
+// SaveSilence creates or replaces the silence in the database.
+func (sr *SilenceDBRepository) SaveSilence(ctx context.Context, silence *types.Silence) error {
+	data, err := json.Marshal(silence)
+	if err != nil {
+		return fmt.Errorf("failed to marshal silence: %v", err)
+	}
+
+	query := `INSERT INTO silences (id, starts_at, silence) VALUES ($1, $2, $3)
+			  ON CONFLICT (id) DO UPDATE SET starts_at = EXCLUDED.starts_at, silence = EXCLUDED.silence`
+
+	_, err = sr.db.ExecContext(ctx, query, silence.ID, silence.StartsAt, data)
+	return err
+}
+
+// GetSilenceByID returns the silence with the given ID or nil if it does not exist.
+func (sr *SilenceDBRepository) GetSilenceByID(ctx context.Context, id string) (*types.Silence, error) {
+	var data []byte
+	err := sr.db.QueryRowContext(ctx, "SELECT silence FROM silences WHERE id = $1", id).Scan(&data)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to query silence: %v", err)
+	}
+
+	var silence types.Silence
+	if err := json.Unmarshal(data, &silence); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal silence: %v", err)
+	}
+
+	return &silence, nil
+}
+
+// ListSilences lists all silences stored in the database ordered by start time.
+func (sr *SilenceDBRepository) ListSilences(ctx context.Context) ([]*types.Silence, error) {
+	rows, err := sr.db.QueryContext(ctx, "SELECT silence FROM silences ORDER BY starts_at, id")
+	if err != nil {
+		return nil, fmt.Errorf("failed to query silences: %v", err)
+	}
+	defer rows.Close()
+
+	var silences []*types.Silence
+	for rows.Next() {
+		var data []byte
+		if err := rows.Scan(&data); err != nil {
+			return nil, fmt.Errorf("failed to scan silence: %v", err)
+		}
+
+		var silence types.Silence
+		if err := json.Unmarshal(data, &silence); err != nil {
+			return nil, fmt.Errorf("failed to unmarshal silence: %v", err)
+		}
+		silences = append(silences, &silence)
+	}
+
+	// Handle any row iteration errors
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("failed during row iteration: %v", err)
+	}
+
+	return silences, nil
+}
+
+// DeleteSilence removes the silence from the database.
+func (sr *SilenceDBRepository) DeleteSilence(ctx context.Context, id string) error {
+	_, err := sr.db.ExecContext(ctx, "DELETE FROM silences WHERE id = $1", id)
+	return err
+}
+
+// createSilencesTable stores every silence as a JSON document, with the start time
+// alongside for ordering.
+func createSilencesTable(db *sql.DB) error {
+	query := `CREATE TABLE IF NOT EXISTS silences (
+		id VARCHAR(255) NOT NULL PRIMARY KEY,
+		starts_at TIMESTAMPTZ NOT NULL,
+		silence JSONB NOT NULL
+	)`
+
+	_, err := db.Exec(query)
+	if err != nil {
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,139 @@
+package repositories
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+)
+
+// SilenceFileName is the name of the silences file stored alongside the metrics file.
+const SilenceFileName = "silences.json"
+
+type SilenceFileRepository struct {
+	path string
+	mu   sync.Mutex
+}
+
+// NewSilenceFileRepository creates a new instance of SilenceFileRepository.
+// Like the rules file, the silences file is never truncated on start.
+func NewSilenceFileRepository(c *configs.ServerConfig) *SilenceFileRepository {
+	return &SilenceFileRepository{
+		path: filepath.Join(filepath.Dir(c.FileStoragePath), SilenceFileName),
+	}
+}
+
+// SaveSilence creates or replaces the silence in the file.
+func (sr *SilenceFileRepository) SaveSilence(ctx context.Context, silence *types.Silence) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	silences, err := sr.readSilences()
+	if err != nil {
+		return err
+	}
+	silences[silence.ID] = silence
+
+	return sr.writeSilences(silences)
+}
+
+// GetSilenceByID returns the silence with the given ID or nil if it does not exist.
+func (sr *SilenceFileRepository) GetSilenceByID(ctx context.Context, id string) (*types.Silence, error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	silences, err := sr.readSilences()
+	if err != nil {
+		return nil, err
+	}
+
+	return silences[id], nil
+}
+
+// ListSilences lists all silences stored in the file ordered by start time.
+func (sr *SilenceFileRepository) ListSilences(ctx context.Context) ([]*types.Silence, error) {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	silences, err := sr.readSilences()
+	if err != nil {
+		return nil, err
+	}
+
+	return sortSilences(silences), nil
+}
+
+// DeleteSilence removes the silence from the file.
+func (sr *SilenceFileRepository) DeleteSilence(ctx context.Context, id string) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	silences, err := sr.readSilences()
+	if err != nil {
+		return err
+	}
+	delete(silences, id)
+
+	return sr.writeSilences(silences)
+}
+
+// readSilences reads all silences from the file, treating a missing file as empty.
+func (sr *SilenceFileRepository) readSilences() (map[string]*types.Silence, error) {
+	silences := make(map[string]*types.Silence)
+
+	data, err := os.ReadFile(sr.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return silences, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("failed to read silences file: %v", err)
+	}
+	if len(data) == 0 {
+		return silences, nil
+	}
+
+	var list []*types.Silence
+	if err := json.Unmarshal(data, &list); err != nil {
+		return nil, fmt.Errorf("failed to unmarshal silences: %v", err)
+	}
+	for _, silence := range list {
+		silences[silence.ID] = silence
+	}
+
+	return silences, nil
+}
+
+// writeSilences overwrites the file with the given silences.
+func (sr *SilenceFileRepository) writeSilences(silences map[string]*types.Silence) error {
+	data, err := json.MarshalIndent(sortSilences(silences), "", "  ")
+	if err != nil {
+		return fmt.Errorf("failed to marshal silences: %v", err)
+	}
+
+	if err := os.WriteFile(sr.path, data, 0644); err != nil {
+		return fmt.Errorf("failed to write silences file: %v", err)
+	}
+
+	return nil
+}
+
+// sortSilences lists the silences ordered by start time, then ID.
+func sortSilences(silences map[string]*types.Silence) []*types.Silence {
+	list := make([]*types.Silence, 0, len(silences))
+	for _, silence := range silences {
+		list = append(list, silence)
+	}
+	sort.Slice(list, func(i, j int) bool {
+		if list[i].StartsAt.Equal(list[j].StartsAt) {
+			return list[i].ID < list[j].ID
+		}
+		return list[i].StartsAt.Before(list[j].StartsAt)
+	})
+	return list
+}
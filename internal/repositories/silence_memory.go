@@ -0,0 +1,65 @@
+package repositories
+
+import (
+	"context"
+	"go-metrics-alerting/internal/types"
+	"sort"
+	"sync"
+)
+
+type SilenceMemoryRepository struct {
+	data map[string]*types.Silence
+	mu   sync.RWMutex
+}
+
+// NewSilenceMemoryRepository creates a new instance of SilenceMemoryRepository.
+func NewSilenceMemoryRepository() *SilenceMemoryRepository {
+	return &SilenceMemoryRepository{
+		data: make(map[string]*types.Silence),
+	}
+}
+
+// SaveSilence creates or replaces the silence in the in-memory storage.
+func (sr *SilenceMemoryRepository) SaveSilence(ctx context.Context, silence *types.Silence) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	sr.data[silence.ID] = silence
+	return nil
+}
+
+// GetSilenceByID returns the silence with the given ID or nil if it does not exist.
+func (sr *SilenceMemoryRepository) GetSilenceByID(ctx context.Context, id string) (*types.Silence, error) {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+
+	return sr.data[id], nil
+}
+
+// ListSilences lists all silences stored in memory ordered by start time.
+func (sr *SilenceMemoryRepository) ListSilences(ctx context.Context) ([]*types.Silence, error) {
+	sr.mu.RLock()
+	defer sr.mu.RUnlock()
+
+	var result []*types.Silence
+	for _, silence := range sr.data {
+		result = append(result, silence)
+	}
+	sort.Slice(result, func(i, j int) bool {
+		if result[i].StartsAt.Equal(result[j].StartsAt) {
+			return result[i].ID < result[j].ID
+		}
+		return result[i].StartsAt.Before(result[j].StartsAt)
+	})
+
+	return result, nil
+}
+
+// DeleteSilence removes the silence from memory.
+func (sr *SilenceMemoryRepository) DeleteSilence(ctx context.Context, id string) error {
+	sr.mu.Lock()
+	defer sr.mu.Unlock()
+
+	delete(sr.data, id)
+	return nil
+}
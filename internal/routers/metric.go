@@ -16,6 +16,54 @@ type MetricHandlers interface {
 	GetMetricByTypeAndIDPathHandler(w http.ResponseWriter, r *http.Request)
 	GetMetricByTypeAndIDBodyHandler(w http.ResponseWriter, r *http.Request)
 	ListMetricsHTMLHandler(w http.ResponseWriter, r *http.Request)
+	ListMetricsPrometheusHandler(w http.ResponseWriter, r *http.Request)
+	ListSamplesHandler(w http.ResponseWriter, r *http.Request)
+}
+
+// AlertHandlers defines the set of handler methods required for managing alerts.
+type AlertHandlers interface {
+	CreateAlertRuleHandler(w http.ResponseWriter, r *http.Request)
+	ListAlertRulesHandler(w http.ResponseWriter, r *http.Request)
+	GetAlertRuleHandler(w http.ResponseWriter, r *http.Request)
+	UpdateAlertRuleHandler(w http.ResponseWriter, r *http.Request)
+	DeleteAlertRuleHandler(w http.ResponseWriter, r *http.Request)
+	ListAlertsHandler(w http.ResponseWriter, r *http.Request)
+	ListAlertHistoryHandler(w http.ResponseWriter, r *http.Request)
+	AckAlertHandler(w http.ResponseWriter, r *http.Request)
+}
+
+// SilenceHandlers defines the set of handler methods required for managing silences.
+type SilenceHandlers interface {
+	CreateSilenceHandler(w http.ResponseWriter, r *http.Request)
+	ListSilencesHandler(w http.ResponseWriter, r *http.Request)
+	GetSilenceHandler(w http.ResponseWriter, r *http.Request)
+	DeleteSilenceHandler(w http.ResponseWriter, r *http.Request)
+}
+
+// RouteHandlers defines the set of handler methods required for inspecting alert routing.
+type RouteHandlers interface {
+	TestRouteHandler(w http.ResponseWriter, r *http.Request)
+}
+
+// IngestHandlers defines the set of handler methods receiving metrics pushed by external systems.
+type IngestHandlers interface {
+	RemoteWriteHandler(w http.ResponseWriter, r *http.Request)
+	InfluxWriteHandler(w http.ResponseWriter, r *http.Request)
+	OTLPMetricsHandler(w http.ResponseWriter, r *http.Request)
+}
+
+// QueryHandlers defines the set of handler methods serving the Prometheus query API.
+type QueryHandlers interface {
+	InstantQueryHandler(w http.ResponseWriter, r *http.Request)
+	RangeQueryHandler(w http.ResponseWriter, r *http.Request)
+	SeriesHandler(w http.ResponseWriter, r *http.Request)
+	LabelsHandler(w http.ResponseWriter, r *http.Request)
+	LabelValuesHandler(w http.ResponseWriter, r *http.Request)
+}
+
+// AdminHandlers defines the set of handler methods exposing the server settings to operators.
+type AdminHandlers interface {
+	RetentionSettingsHandler(w http.ResponseWriter, r *http.Request)
 }
 
 type MetricRouter struct {
@@ -24,7 +72,7 @@ type MetricRouter struct {
 }
 
 // NewMetricRouter initializes and returns a new MetricRouter with the provided handlers and config.
-func NewMetricRouter(config *configs.ServerConfig, h MetricHandlers) *MetricRouter {
+func NewMetricRouter(config *configs.ServerConfig, h MetricHandlers, ah AlertHandlers, sh SilenceHandlers, rh RouteHandlers, ih IngestHandlers, qh QueryHandlers, adh AdminHandlers) *MetricRouter {
 	r := chi.NewRouter()
 
 	r.Use(middlewares.LoggingMiddleware())
@@ -37,6 +85,39 @@ func NewMetricRouter(config *configs.ServerConfig, h MetricHandlers) *MetricRout
 	r.Get("/value/{type}/{id}", h.GetMetricByTypeAndIDPathHandler)
 	r.Post("/value/", h.GetMetricByTypeAndIDBodyHandler)
 	r.Get("/", h.ListMetricsHTMLHandler)
+	r.Get("/metrics", h.ListMetricsPrometheusHandler)
+	r.Get("/api/series/{type}/{id}", h.ListSamplesHandler)
+
+	r.Post("/api/v1/write", ih.RemoteWriteHandler)
+	r.Post("/write", ih.InfluxWriteHandler)
+	r.Post("/v1/metrics", ih.OTLPMetricsHandler)
+
+	r.Get("/api/v1/query", qh.InstantQueryHandler)
+	r.Post("/api/v1/query", qh.InstantQueryHandler)
+	r.Get("/api/v1/query_range", qh.RangeQueryHandler)
+	r.Post("/api/v1/query_range", qh.RangeQueryHandler)
+	r.Get("/api/v1/series", qh.SeriesHandler)
+	r.Post("/api/v1/series", qh.SeriesHandler)
+	r.Get("/api/v1/labels", qh.LabelsHandler)
+	r.Post("/api/v1/labels", qh.LabelsHandler)
+	r.Get("/api/v1/label/{name}/values", qh.LabelValuesHandler)
+
+	r.Get("/api/alerts", ah.ListAlertsHandler)
+	r.Get("/api/alerts/history", ah.ListAlertHistoryHandler)
+	r.Post("/api/alerts/{id}/ack", ah.AckAlertHandler)
+	r.Post("/api/alerts/rules", ah.CreateAlertRuleHandler)
+	r.Get("/api/alerts/rules", ah.ListAlertRulesHandler)
+	r.Get("/api/alerts/rules/{id}", ah.GetAlertRuleHandler)
+	r.Put("/api/alerts/rules/{id}", ah.UpdateAlertRuleHandler)
+	r.Delete("/api/alerts/rules/{id}", ah.DeleteAlertRuleHandler)
+	r.Post("/api/alerts/routes/test", rh.TestRouteHandler)
+
+	r.Post("/api/silences", sh.CreateSilenceHandler)
+	r.Get("/api/silences", sh.ListSilencesHandler)
+	r.Get("/api/silences/{id}", sh.GetSilenceHandler)
+	r.Delete("/api/silences/{id}", sh.DeleteSilenceHandler)
+
+	r.Get("/api/admin/retention", adh.RetentionSettingsHandler)
 
 	return &MetricRouter{Mux: r, config: config}
 }
@@ -0,0 +1,179 @@
+// Package scanner splits the source of the alert expressions and of PromQL queries into tokens.
+// Both languages share the numbers, identifiers and operators of C-like languages and only
+// differ in the characters they allow, which a Syntax describes.
+package scanner
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"unicode"
+)
+
+type Kind int
+
+const (
+	EOF Kind = iota
+	Number
+	Ident
+	String
+	Operator
+	Punct // A single character of Syntax.Punctuation
+	Raw   // The contents of a pair of Syntax.Raw delimiters
+)
+
+type Token struct {
+	Kind Kind
+	Text string // Unquoted value for strings
+	Pos  int    // Byte offset in the source
+}
+
+// Error is a syntax or type error pointing at a position in the source.
+type Error struct {
+	Pos int // Byte offset in the source
+	Msg string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("%s at position %d", e.Msg, e.Pos+1)
+}
+
+func Errorf(pos int, format string, args ...interface{}) *Error {
+	return &Error{Pos: pos, Msg: fmt.Sprintf(format, args...)}
+}
+
+// Syntax describes the tokens of a language besides numbers, which are the same in all of them.
+type Syntax struct {
+	Operators   []string      // Matched in order, so longer operators must come first
+	Punctuation string        // Characters that are tokens on their own
+	Quotes      string        // Characters opening strings: " and ' support Go escapes, ` is raw
+	Raw         map[byte]byte // Closing delimiter by opening one; both must be punctuation
+	Qualifier   byte          // Joins two identifiers into one, e.g. ':' in gauge:HeapAlloc; 0 for none
+	IdentStart  func(c rune) bool
+	IdentPart   func(c rune) bool
+}
+
+// Scan splits the source into tokens, ending with an EOF token at the end of the source.
+func Scan(src string, syntax *Syntax) ([]Token, error) {
+	var tokens []Token
+	i := 0
+	for i < len(src) {
+		c := rune(src[i])
+		switch {
+		case unicode.IsSpace(c):
+			i++
+		case strings.IndexByte(syntax.Punctuation, src[i]) >= 0:
+			tokens = append(tokens, Token{Kind: Punct, Text: src[i : i+1], Pos: i})
+			closing, raw := syntax.Raw[src[i]]
+			i++
+			if raw {
+				end := strings.IndexByte(src[i:], closing)
+				if end < 0 {
+					return nil, Errorf(i-1, "unclosed %q", src[i-1:i])
+				}
+				tokens = append(tokens, Token{Kind: Raw, Text: strings.TrimSpace(src[i : i+end]), Pos: i})
+				i += end
+			}
+		case strings.IndexByte(syntax.Quotes, src[i]) >= 0:
+			value, n, err := unquote(src[i:])
+			if err != nil {
+				return nil, Errorf(i, "%v", err)
+			}
+			tokens = append(tokens, Token{Kind: String, Text: value, Pos: i})
+			i += n
+		case unicode.IsDigit(c) || (c == '.' && i+1 < len(src) && unicode.IsDigit(rune(src[i+1]))):
+			start := i
+			for i < len(src) && (unicode.IsDigit(rune(src[i])) || src[i] == '.') {
+				i++
+			}
+			// Exponent, e.g. 1e9 or 2.5E-3
+			if i < len(src) && (src[i] == 'e' || src[i] == 'E') {
+				j := i + 1
+				if j < len(src) && (src[j] == '+' || src[j] == '-') {
+					j++
+				}
+				if j < len(src) && unicode.IsDigit(rune(src[j])) {
+					i = j
+					for i < len(src) && unicode.IsDigit(rune(src[i])) {
+						i++
+					}
+				}
+			}
+			tokens = append(tokens, Token{Kind: Number, Text: src[start:i], Pos: start})
+		case syntax.IdentStart(c):
+			start := i
+			i = scanIdent(src, i, syntax)
+			if syntax.Qualifier != 0 && i+1 < len(src) && src[i] == syntax.Qualifier && syntax.IdentStart(rune(src[i+1])) {
+				i = scanIdent(src, i+1, syntax)
+			}
+			tokens = append(tokens, Token{Kind: Ident, Text: src[start:i], Pos: start})
+		default:
+			matched := false
+			for _, op := range syntax.Operators {
+				if strings.HasPrefix(src[i:], op) {
+					tokens = append(tokens, Token{Kind: Operator, Text: op, Pos: i})
+					i += len(op)
+					matched = true
+					break
+				}
+			}
+			if !matched {
+				return nil, Errorf(i, "unexpected character %q", c)
+			}
+		}
+	}
+
+	tokens = append(tokens, Token{Kind: EOF, Pos: len(src)})
+	return tokens, nil
+}
+
+// scanIdent returns the end of the identifier starting at i.
+func scanIdent(src string, i int, syntax *Syntax) int {
+	for i < len(src) && syntax.IdentPart(rune(src[i])) {
+		i++
+	}
+	return i
+}
+
+// unquote reads the string literal at the start of s and returns its value and length.
+// Double and single quoted strings support Go escapes, backquoted strings are raw.
+func unquote(s string) (string, int, error) {
+	if s[0] == '\'' {
+		// Rewrite as a double quoted string so strconv handles the escapes
+		var b strings.Builder
+		b.WriteByte('"')
+		for i := 1; i < len(s); i++ {
+			switch s[i] {
+			case '\'':
+				b.WriteByte('"')
+				value, err := strconv.Unquote(b.String())
+				if err != nil {
+					return "", 0, fmt.Errorf("invalid string: %v", err)
+				}
+				return value, i + 1, nil
+			case '"':
+				b.WriteString(`\"`)
+			case '\\':
+				if i+1 < len(s) && s[i+1] == '\'' {
+					b.WriteByte('\'')
+				} else if i+1 < len(s) {
+					b.WriteString(s[i : i+2])
+				}
+				i++
+			default:
+				b.WriteByte(s[i])
+			}
+		}
+		return "", 0, fmt.Errorf("unterminated string")
+	}
+
+	quoted, err := strconv.QuotedPrefix(s)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid string")
+	}
+	value, err := strconv.Unquote(quoted)
+	if err != nil {
+		return "", 0, fmt.Errorf("invalid string: %v", err)
+	}
+	return value, len(quoted), nil
+}
@@ -0,0 +1,126 @@
+package scanner
+
+import (
+	"errors"
+	"reflect"
+	"testing"
+	"unicode"
+)
+
+var testSyntax = &Syntax{
+	Operators:   []string{"=~", "==", "=", "+"},
+	Punctuation: "()[],",
+	Quotes:      "\"'`",
+	Raw:         map[byte]byte{'[': ']'},
+	Qualifier:   ':',
+	IdentStart:  func(c rune) bool { return unicode.IsLetter(c) || c == '_' },
+	IdentPart:   func(c rune) bool { return unicode.IsLetter(c) || unicode.IsDigit(c) || c == '_' || c == '.' },
+}
+
+func TestScan(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  []Token
+	}{
+		{
+			name:  "numbers",
+			input: "1 2.5 .5 1e9 2.5E-3 3e",
+			want: []Token{
+				{Kind: Number, Text: "1", Pos: 0},
+				{Kind: Number, Text: "2.5", Pos: 2},
+				{Kind: Number, Text: ".5", Pos: 6},
+				{Kind: Number, Text: "1e9", Pos: 9},
+				{Kind: Number, Text: "2.5E-3", Pos: 13},
+				{Kind: Number, Text: "3", Pos: 20},
+				{Kind: Ident, Text: "e", Pos: 21},
+				{Kind: EOF, Pos: 22},
+			},
+		},
+		{
+			name:  "longest operator first",
+			input: "a==b=~c=d",
+			want: []Token{
+				{Kind: Ident, Text: "a", Pos: 0},
+				{Kind: Operator, Text: "==", Pos: 1},
+				{Kind: Ident, Text: "b", Pos: 3},
+				{Kind: Operator, Text: "=~", Pos: 4},
+				{Kind: Ident, Text: "c", Pos: 6},
+				{Kind: Operator, Text: "=", Pos: 7},
+				{Kind: Ident, Text: "d", Pos: 8},
+				{Kind: EOF, Pos: 9},
+			},
+		},
+		{
+			name:  "qualified identifiers",
+			input: "gauge:cpu.load _b",
+			want: []Token{
+				{Kind: Ident, Text: "gauge:cpu.load", Pos: 0},
+				{Kind: Ident, Text: "_b", Pos: 15},
+				{Kind: EOF, Pos: 17},
+			},
+		},
+		{
+			name:  "strings",
+			input: `"a\"b" 'c\'d' ` + "`e\\f`",
+			want: []Token{
+				{Kind: String, Text: `a"b`, Pos: 0},
+				{Kind: String, Text: `c'd`, Pos: 7},
+				{Kind: String, Text: `e\f`, Pos: 14},
+				{Kind: EOF, Pos: 19},
+			},
+		},
+		{
+			name:  "raw contents",
+			input: "x[ 5m ](",
+			want: []Token{
+				{Kind: Ident, Text: "x", Pos: 0},
+				{Kind: Punct, Text: "[", Pos: 1},
+				{Kind: Raw, Text: "5m", Pos: 2},
+				{Kind: Punct, Text: "]", Pos: 6},
+				{Kind: Punct, Text: "(", Pos: 7},
+				{Kind: EOF, Pos: 8},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := Scan(tt.input, testSyntax)
+			if err != nil {
+				t.Fatalf("Scan() error = %v", err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("Scan() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestScanErrors(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		pos   int
+	}{
+		{name: "unexpected character", input: "a $ b", pos: 2},
+		{name: "qualifier without identifier", input: "gauge: a", pos: 5},
+		{name: "unclosed raw contents", input: "x[5m", pos: 1},
+		{name: "unterminated string", input: `a "b`, pos: 2},
+		{name: "unterminated single quoted string", input: `'b`, pos: 0},
+		{name: "invalid escape", input: `"\q"`, pos: 0},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := Scan(tt.input, testSyntax)
+			var scanErr *Error
+			if !errors.As(err, &scanErr) {
+				t.Fatalf("Scan() error = %v, want an *Error", err)
+			}
+			if scanErr.Pos != tt.pos {
+				t.Errorf("Error.Pos = %d, want %d (%v)", scanErr.Pos, tt.pos, err)
+			}
+		})
+	}
+}
@@ -0,0 +1,441 @@
+package services
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/types"
+	"sort"
+	"sync"
+	"time"
+)
+
+// AlertMetricService defines the metric reads required to evaluate alert rules.
+type AlertMetricService interface {
+	GetMetricByTypeAndID(ctx context.Context, id types.MetricID) (*types.Metrics, error)
+	ListAllMetrics(ctx context.Context) ([]*types.Metrics, error)
+	ListMetricUpdates(ctx context.Context) (map[types.MetricID]time.Time, error)
+	ListCounterSamples(ctx context.Context, id types.MetricID, since time.Time) ([]types.Sample, error)
+	GetAnomalyModel(ctx context.Context, id types.MetricID) (*types.AnomalyModel, error)
+}
+
+// AlertRuleRepository defines the storage of alert rules.
+type AlertRuleRepository interface {
+	SaveRule(ctx context.Context, rule *types.AlertRule) error
+	GetRuleByID(ctx context.Context, id string) (*types.AlertRule, error)
+	ListRules(ctx context.Context) ([]*types.AlertRule, error)
+	DeleteRule(ctx context.Context, id string) error
+}
+
+// AlertHistoryRepository defines the storage of the alert history log.
+type AlertHistoryRepository interface {
+	SaveTransitions(ctx context.Context, transitions []*types.AlertTransition) error
+	FilterTransitions(ctx context.Context, filter types.AlertHistoryFilter) ([]*types.AlertTransition, error)
+}
+
+// AlertSilencer lists the silences muting the notifications of alerts.
+type AlertSilencer interface {
+	ListSilences(ctx context.Context) ([]*types.Silence, error)
+}
+
+type AlertService struct {
+	metrics       AlertMetricService
+	rules         AlertRuleRepository
+	history       AlertHistoryRepository
+	silencer      AlertSilencer
+	lastEvaluated map[string]time.Time
+	lastRules     map[string]*types.AlertRule  // Last evaluated version of every rule
+	expressions   map[string]*parsedExpression // Parsed expressions of the expression rules by rule ID
+	alerts        map[string]*types.Alert
+	startedAt     time.Time
+	mu            sync.Mutex
+}
+
+func NewAlertService(
+	metrics AlertMetricService,
+	rules AlertRuleRepository,
+	history AlertHistoryRepository,
+	silencer AlertSilencer,
+) *AlertService {
+	return &AlertService{
+		metrics:       metrics,
+		rules:         rules,
+		history:       history,
+		silencer:      silencer,
+		lastEvaluated: make(map[string]time.Time),
+		lastRules:     make(map[string]*types.AlertRule),
+		expressions:   make(map[string]*parsedExpression),
+		alerts:        make(map[string]*types.Alert),
+		startedAt:     time.Now(),
+	}
+}
+
+// CreateRule validates and stores a new rule, generating an ID when none is given.
+func (s *AlertService) CreateRule(ctx context.Context, rule *types.AlertRule) (*types.AlertRule, error) {
+	if rule.ID == "" {
+		id, err := newID()
+		if err != nil {
+			return nil, err
+		}
+		rule.ID = id
+	}
+	if err := ValidateAlertRule(rule); err != nil {
+		return nil, err
+	}
+
+	existing, err := s.rules.GetRuleByID(ctx, rule.ID)
+	if err != nil {
+		return nil, err
+	}
+	if existing != nil {
+		return nil, ErrAlertRuleExists
+	}
+
+	if err := s.rules.SaveRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// UpdateRule validates and replaces an existing rule.
+func (s *AlertService) UpdateRule(ctx context.Context, id string, rule *types.AlertRule) (*types.AlertRule, error) {
+	rule.ID = id
+	if err := ValidateAlertRule(rule); err != nil {
+		return nil, err
+	}
+
+	if _, err := s.GetRule(ctx, id); err != nil {
+		return nil, err
+	}
+
+	if err := s.rules.SaveRule(ctx, rule); err != nil {
+		return nil, err
+	}
+
+	return rule, nil
+}
+
+// GetRule fetches a rule by its ID.
+func (s *AlertService) GetRule(ctx context.Context, id string) (*types.AlertRule, error) {
+	rule, err := s.rules.GetRuleByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if rule == nil {
+		return nil, ErrAlertRuleNotFound
+	}
+
+	return rule, nil
+}
+
+// ListRules returns all the rules in the repository.
+func (s *AlertService) ListRules(ctx context.Context) ([]*types.AlertRule, error) {
+	return s.rules.ListRules(ctx)
+}
+
+// DeleteRule removes an existing rule.
+func (s *AlertService) DeleteRule(ctx context.Context, id string) error {
+	if _, err := s.GetRule(ctx, id); err != nil {
+		return err
+	}
+
+	return s.rules.DeleteRule(ctx, id)
+}
+
+// ListAlerts returns a snapshot of the current state of every evaluated rule ordered by rule ID.
+func (s *AlertService) ListAlerts(ctx context.Context) ([]*types.Alert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alerts := make([]*types.Alert, 0, len(s.alerts))
+	for _, alert := range s.alerts {
+		snapshot := *alert
+		alerts = append(alerts, &snapshot)
+	}
+	sort.Slice(alerts, func(i, j int) bool { return alerts[i].RuleID < alerts[j].RuleID })
+
+	return alerts, nil
+}
+
+// GetAlert returns a snapshot of the alert of the rule.
+func (s *AlertService) GetAlert(ctx context.Context, id string) (*types.Alert, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alert, exists := s.alerts[id]
+	if !exists {
+		return nil, ErrAlertNotFound
+	}
+	snapshot := *alert
+	return &snapshot, nil
+}
+
+// AcknowledgeAlert marks a firing alert as handled, which stops its escalation until it resolves.
+func (s *AlertService) AcknowledgeAlert(ctx context.Context, id string, ack *types.AlertAck, now time.Time) (*types.Alert, error) {
+	if ack.By == "" {
+		return nil, fmt.Errorf("%w: missing by", ErrInvalidAck)
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	alert, exists := s.alerts[id]
+	if !exists {
+		return nil, ErrAlertNotFound
+	}
+	if alert.State != types.AlertFiring {
+		return nil, ErrAlertNotFiring
+	}
+
+	alert.Ack = &types.AlertAck{By: ack.By, Comment: ack.Comment, At: now}
+
+	snapshot := *alert
+	return &snapshot, nil
+}
+
+// EvaluateRules checks every rule whose evaluation interval has elapsed, advances
+// the alert state machine and returns an event for every state transition.
+// A rule that fails to evaluate does not stop the others, and failing to record the
+// history does not undo the transitions: the events are returned along with the errors.
+func (s *AlertService) EvaluateRules(ctx context.Context, now time.Time) ([]*types.AlertEvent, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	rules, err := s.rules.ListRules(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	// Collect the rules that are due for evaluation
+	var due []*types.AlertRule
+	known := make(map[string]bool)
+	for _, rule := range rules {
+		known[rule.ID] = true
+		last, evaluated := s.lastEvaluated[rule.ID]
+		if evaluated && now.Sub(last) < time.Duration(rule.Interval)*time.Second {
+			continue
+		}
+		due = append(due, rule)
+	}
+
+	// Forget deleted rules, resolving their firing alerts so that receivers and inhibitions
+	// do not keep them forever
+	var events []*types.AlertEvent
+	for id := range s.lastEvaluated {
+		if known[id] {
+			continue
+		}
+		if alert, exists := s.alerts[id]; exists && alert.State == types.AlertFiring {
+			alert.ResolvedAt = timePtr(now)
+			alert.Ack = nil
+			alert.State = types.AlertResolved
+			events = append(events, &types.AlertEvent{
+				Rule:          s.lastRules[id],
+				Alert:         *alert,
+				PreviousState: types.AlertFiring,
+				Timestamp:     now,
+			})
+		}
+		delete(s.lastEvaluated, id)
+		delete(s.lastRules, id)
+		delete(s.expressions, id)
+		delete(s.alerts, id)
+	}
+
+	var errs []error
+	if len(due) != 0 {
+		evaluated, err := s.evaluateDue(ctx, due, now)
+		events = append(events, evaluated...)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+
+	// Record every transition in the history log
+	if len(events) != 0 {
+		transitions := make([]*types.AlertTransition, 0, len(events))
+		for _, event := range events {
+			transitions = append(transitions, &types.AlertTransition{
+				RuleID:    event.Alert.RuleID,
+				Metric:    event.Alert.Metric,
+				FromState: event.PreviousState,
+				ToState:   event.Alert.State,
+				Value:     event.Alert.Value,
+				Timestamp: event.Timestamp,
+			})
+		}
+		if err := s.history.SaveTransitions(ctx, transitions); err != nil {
+			errs = append(errs, fmt.Errorf("failed to save alert history: %w", err))
+		}
+	}
+
+	return events, errors.Join(errs...)
+}
+
+// evaluateDue evaluates the due rules against a single snapshot of all metrics and silences,
+// collecting the errors of the rules that fail. Called with the lock held.
+func (s *AlertService) evaluateDue(ctx context.Context, due []*types.AlertRule, now time.Time) ([]*types.AlertEvent, error) {
+	snapshot, err := s.takeSnapshot(ctx)
+	if err != nil {
+		return nil, err
+	}
+	silences, err := s.silencer.ListSilences(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	var events []*types.AlertEvent
+	var errs []error
+	for _, rule := range due {
+		s.lastEvaluated[rule.ID] = now
+		s.lastRules[rule.ID] = rule
+
+		// Without data the alert keeps its current state
+		matched, value, ok, err := s.evaluateRule(ctx, rule, snapshot, now)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("rule %s: %w", rule.ID, err))
+			continue
+		}
+		if !ok {
+			continue
+		}
+
+		// Silenced alerts are still evaluated, they are only marked to skip notifications
+		silenced := isSilenced(silences, rule, now)
+		if event := s.transition(rule, matched, value, silenced, now); event != nil {
+			events = append(events, event)
+		}
+	}
+
+	return events, errors.Join(errs...)
+}
+
+// ListHistory returns a page of the alert history log, newest first.
+func (s *AlertService) ListHistory(ctx context.Context, filter types.AlertHistoryFilter) ([]*types.AlertTransition, error) {
+	if filter.Limit <= 0 || filter.Limit > MaxAlertHistoryLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", ErrInvalidHistoryFilter, MaxAlertHistoryLimit)
+	}
+	if filter.Offset < 0 {
+		return nil, fmt.Errorf("%w: negative offset", ErrInvalidHistoryFilter)
+	}
+
+	transitions, err := s.history.FilterTransitions(ctx, filter)
+	if err != nil {
+		return nil, err
+	}
+	if transitions == nil {
+		transitions = []*types.AlertTransition{}
+	}
+
+	return transitions, nil
+}
+
+// transition advances the alert of the rule according to the evaluation result:
+// inactive -> pending -> firing -> resolved. It returns an event if the state changed.
+func (s *AlertService) transition(rule *types.AlertRule, matched bool, value float64, silenced bool, now time.Time) *types.AlertEvent {
+	alert, exists := s.alerts[rule.ID]
+	if !exists {
+		alert = &types.Alert{RuleID: rule.ID, State: types.AlertInactive}
+		s.alerts[rule.ID] = alert
+	}
+	alert.Metric = rule.Metric
+	alert.Labels = alertLabels(rule)
+	alert.Value = value
+	alert.LastEvaluatedAt = now
+	alert.Silenced = silenced
+
+	previous := alert.State
+	holdFor := time.Duration(rule.For) * time.Second
+
+	switch {
+	case matched && (alert.State == types.AlertInactive || alert.State == types.AlertResolved):
+		alert.ActiveAt = timePtr(now)
+		alert.FiredAt = nil
+		alert.ResolvedAt = nil
+		alert.State = types.AlertPending
+		if holdFor == 0 {
+			alert.FiredAt = timePtr(now)
+			alert.State = types.AlertFiring
+		}
+	case matched && alert.State == types.AlertPending:
+		if now.Sub(*alert.ActiveAt) >= holdFor {
+			alert.FiredAt = timePtr(now)
+			alert.State = types.AlertFiring
+		}
+	case !matched && alert.State == types.AlertPending:
+		alert.ActiveAt = nil
+		alert.State = types.AlertInactive
+	case !matched && alert.State == types.AlertFiring:
+		alert.ResolvedAt = timePtr(now)
+		alert.Ack = nil
+		alert.State = types.AlertResolved
+	}
+
+	if alert.State == previous {
+		return nil
+	}
+
+	return &types.AlertEvent{
+		Rule:          rule,
+		Alert:         *alert,
+		PreviousState: previous,
+		Timestamp:     now,
+	}
+}
+
+// newID generates a random identifier for rules and silences.
+func newID() (string, error) {
+	b := make([]byte, 8)
+	if _, err := rand.Read(b); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// alertLabels returns the labels of the metric and the rule extended with the labels identifying the alert.
+// Rule labels take precedence over metric labels of the same name.
+func alertLabels(rule *types.AlertRule) map[string]string {
+	name, metricLabels := types.ParseMetricName(rule.Metric.ID)
+
+	labels := make(map[string]string, len(metricLabels)+len(rule.Labels)+4)
+	for label, value := range metricLabels {
+		labels[label] = value
+	}
+	for label, value := range rule.Labels {
+		labels[label] = value
+	}
+	labels[types.LabelRule] = rule.ID
+	labels[types.LabelMetric] = name
+	labels[types.LabelMetricType] = rule.Metric.Type
+	if rule.Severity != "" {
+		labels[types.LabelSeverity] = rule.Severity
+	}
+	return labels
+}
+
+func timePtr(t time.Time) *time.Time {
+	return &t
+}
+
+// MaxAlertHistoryLimit is the largest page of the alert history log returned at once.
+const MaxAlertHistoryLimit = 1000
+
+var (
+	// ErrInvalidHistoryFilter is returned when the history query is malformed.
+	ErrInvalidHistoryFilter = errors.New("invalid history filter")
+	// ErrInvalidAlertRule is returned when an alert rule fails validation.
+	ErrInvalidAlertRule = errors.New("invalid alert rule")
+	// ErrAlertRuleNotFound is returned when a rule with the given ID does not exist.
+	ErrAlertRuleNotFound = errors.New("alert rule not found")
+	// ErrAlertRuleExists is returned when creating a rule with an ID that is already taken.
+	ErrAlertRuleExists = errors.New("alert rule already exists")
+	// ErrAlertNotFound is returned when the rule of the given ID has not been evaluated yet.
+	ErrAlertNotFound = errors.New("alert not found")
+	// ErrAlertNotFiring is returned when acknowledging an alert that is not firing.
+	ErrAlertNotFiring = errors.New("alert is not firing")
+	// ErrInvalidAck is returned when the acknowledgement is incomplete.
+	ErrInvalidAck = errors.New("invalid acknowledgement")
+)
@@ -0,0 +1,296 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/expressions"
+	"go-metrics-alerting/internal/templates"
+	"go-metrics-alerting/internal/types"
+	"math"
+	"path"
+	"time"
+)
+
+// evaluationSnapshot is a consistent view of the metrics shared by all rules of an evaluation pass.
+type evaluationSnapshot struct {
+	metrics map[types.MetricID]*types.Metrics
+	updates map[types.MetricID]time.Time
+}
+
+// takeSnapshot reads all metrics and their last update times.
+func (s *AlertService) takeSnapshot(ctx context.Context) (*evaluationSnapshot, error) {
+	metrics, err := s.metrics.ListAllMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	updates, err := s.metrics.ListMetricUpdates(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	snapshot := &evaluationSnapshot{
+		metrics: make(map[types.MetricID]*types.Metrics),
+		updates: updates,
+	}
+	for _, metric := range metrics {
+		snapshot.metrics[types.MetricID{ID: metric.ID, Type: metric.Type}] = metric
+	}
+
+	return snapshot, nil
+}
+
+// evaluateRule evaluates the condition of the rule. The returned ok is false
+// when there is no data to evaluate the rule against.
+func (s *AlertService) evaluateRule(ctx context.Context, rule *types.AlertRule, snapshot *evaluationSnapshot, now time.Time) (matched bool, value float64, ok bool, err error) {
+	switch rule.Kind {
+	case "", types.RuleThreshold:
+		metric, exists := snapshot.metrics[rule.Metric]
+		if !exists {
+			return false, 0, false, nil
+		}
+		value, ok := metricValue(metric)
+		if !ok {
+			return false, 0, false, nil
+		}
+		matched, err := compareValues(rule.Operator, value, rule.Threshold)
+		return matched, value, true, err
+
+	case types.RuleAbsent:
+		// The newest update among all matching metrics; metrics never seen since
+		// the start of the server count as updated at the start
+		last := s.startedAt
+		for id, updatedAt := range snapshot.updates {
+			if matchMetricID(rule.Metric, id) && updatedAt.After(last) {
+				last = updatedAt
+			}
+		}
+		age := now.Sub(last)
+		return age >= time.Duration(rule.StaleAfter)*time.Second, age.Seconds(), true, nil
+
+	case types.RuleRate, types.RuleIncrease:
+		window := time.Duration(rule.Window) * time.Second
+		samples, err := s.metrics.ListCounterSamples(ctx, rule.Metric, now.Add(-window))
+		if err != nil {
+			return false, 0, false, err
+		}
+		// The growth needs two samples, and the rate is taken over the time they actually
+		// span, which starts at the last sample before the window
+		if len(samples) < 2 {
+			return false, 0, false, nil
+		}
+		span := samples[len(samples)-1].Timestamp.Sub(samples[0].Timestamp)
+		if span <= 0 {
+			return false, 0, false, nil
+		}
+
+		value := counterIncrease(samples)
+		if rule.Kind == types.RuleRate {
+			value /= span.Seconds()
+		}
+		matched, err := compareValues(rule.Operator, value, rule.Threshold)
+		return matched, value, true, err
+
+	case types.RuleAnomaly:
+		model, err := s.metrics.GetAnomalyModel(ctx, rule.Metric)
+		if err != nil {
+			return false, 0, false, err
+		}
+		// The z-scores of a gauge that stopped reporting describe the past, not the current level
+		staleAfter := max(time.Duration(rule.Interval)*time.Second, MinAnomalyStaleAfter)
+		if model == nil || now.Sub(model.UpdatedAt) > staleAfter {
+			return false, 0, false, nil
+		}
+		zscores, ok := recentZScores(model, int(rule.Samples))
+		if !ok {
+			return false, 0, false, nil
+		}
+
+		matched := true
+		for _, zscore := range zscores {
+			if math.Abs(zscore) < rule.Threshold {
+				matched = false
+				break
+			}
+		}
+		return matched, zscores[len(zscores)-1], true, nil
+
+	case types.RuleExpression:
+		node, err := s.expressionNode(rule)
+		if err != nil {
+			return false, 0, false, err
+		}
+		matched, value, err := expressions.Evaluate(node, snapshot.lookup)
+		if errors.Is(err, expressions.ErrNoData) {
+			return false, 0, false, nil
+		}
+		return matched, value, err == nil, err
+	}
+
+	return false, 0, false, fmt.Errorf("unknown rule kind %q", rule.Kind)
+}
+
+// expressionNode returns the parsed expression of the rule, parsing it only when the rule
+// is new or its expression changed. Called with the lock held.
+func (s *AlertService) expressionNode(rule *types.AlertRule) (expressions.Node, error) {
+	if cached, exists := s.expressions[rule.ID]; exists && cached.src == rule.Expr {
+		return cached.node, nil
+	}
+
+	node, err := expressions.Parse(rule.Expr)
+	if err != nil {
+		return nil, err
+	}
+	s.expressions[rule.ID] = &parsedExpression{src: rule.Expr, node: node}
+	return node, nil
+}
+
+// parsedExpression is the syntax tree of the expression of a rule.
+type parsedExpression struct {
+	src  string
+	node expressions.Node
+}
+
+// lookup resolves a metric reference of an expression. References without
+// a type resolve to the gauge with the ID first, then to the counter.
+func (snapshot *evaluationSnapshot) lookup(id types.MetricID) (float64, bool) {
+	candidates := []types.MetricID{id}
+	if id.Type == "" {
+		candidates = []types.MetricID{
+			{ID: id.ID, Type: string(types.Gauge)},
+			{ID: id.ID, Type: string(types.Counter)},
+		}
+	}
+	for _, candidate := range candidates {
+		if metric, exists := snapshot.metrics[candidate]; exists {
+			return metricValue(metric)
+		}
+	}
+	return 0, false
+}
+
+// matchMetricID reports whether the metric matches the rule pattern.
+// An empty pattern type matches metrics of any type.
+func matchMetricID(pattern, id types.MetricID) bool {
+	if pattern.Type != "" && pattern.Type != id.Type {
+		return false
+	}
+	matched, err := path.Match(pattern.ID, id.ID)
+	return err == nil && matched
+}
+
+// ValidateAlertRule checks that the rule is complete and consistent with its kind.
+func ValidateAlertRule(rule *types.AlertRule) error {
+	if rule.ID == "" {
+		return fmt.Errorf("%w: missing id", ErrInvalidAlertRule)
+	}
+	if rule.Metric.ID == "" && rule.Kind != types.RuleExpression {
+		return fmt.Errorf("%w: missing metric id", ErrInvalidAlertRule)
+	}
+	if rule.Interval < 0 {
+		return fmt.Errorf("%w: negative interval", ErrInvalidAlertRule)
+	}
+	if rule.For < 0 {
+		return fmt.Errorf("%w: negative for duration", ErrInvalidAlertRule)
+	}
+
+	switch rule.Kind {
+	case "", types.RuleThreshold:
+		if err := validateMetricType(rule.Metric.Type); err != nil {
+			return err
+		}
+		if _, err := compareValues(rule.Operator, 0, 0); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidAlertRule, err)
+		}
+	case types.RuleAbsent:
+		if rule.Metric.Type != "" {
+			if err := validateMetricType(rule.Metric.Type); err != nil {
+				return err
+			}
+		}
+		if _, err := path.Match(rule.Metric.ID, ""); err != nil {
+			return fmt.Errorf("%w: invalid metric pattern %q", ErrInvalidAlertRule, rule.Metric.ID)
+		}
+		if rule.StaleAfter <= 0 {
+			return fmt.Errorf("%w: stale_after must be positive", ErrInvalidAlertRule)
+		}
+	case types.RuleRate, types.RuleIncrease:
+		if rule.Metric.Type != string(types.Counter) {
+			return fmt.Errorf("%w: %s rules require a counter metric", ErrInvalidAlertRule, rule.Kind)
+		}
+		if rule.Window <= 0 || time.Duration(rule.Window)*time.Second > CounterHistoryWindow {
+			return fmt.Errorf("%w: window must be between 1 and %d seconds",
+				ErrInvalidAlertRule, int64(CounterHistoryWindow.Seconds()))
+		}
+		if _, err := compareValues(rule.Operator, 0, 0); err != nil {
+			return fmt.Errorf("%w: %v", ErrInvalidAlertRule, err)
+		}
+	case types.RuleAnomaly:
+		if rule.Metric.Type != string(types.Gauge) {
+			return fmt.Errorf("%w: anomaly rules require a gauge metric", ErrInvalidAlertRule)
+		}
+		if rule.Threshold <= 0 {
+			return fmt.Errorf("%w: threshold must be a positive z-score", ErrInvalidAlertRule)
+		}
+		if rule.Samples <= 0 || rule.Samples > MaxAnomalySamples {
+			return fmt.Errorf("%w: samples must be between 1 and %d", ErrInvalidAlertRule, MaxAnomalySamples)
+		}
+	case types.RuleExpression:
+		if rule.Expr == "" {
+			return fmt.Errorf("%w: missing expr", ErrInvalidAlertRule)
+		}
+		node, err := expressions.Parse(rule.Expr)
+		if err != nil {
+			return fmt.Errorf("%w: expr: %v", ErrInvalidAlertRule, err)
+		}
+		if len(expressions.References(node)) == 0 {
+			return fmt.Errorf("%w: expr does not reference any metric", ErrInvalidAlertRule)
+		}
+	default:
+		return fmt.Errorf("%w: unknown rule kind %q", ErrInvalidAlertRule, rule.Kind)
+	}
+
+	for channel, text := range rule.Templates {
+		if err := templates.Validate(channel, text); err != nil {
+			return fmt.Errorf("%w: %s template: %v", ErrInvalidAlertRule, channel, err)
+		}
+	}
+	return nil
+}
+
+func validateMetricType(metricType string) error {
+	if metricType != string(types.Gauge) && metricType != string(types.Counter) {
+		return fmt.Errorf("%w: unknown metric type %q", ErrInvalidAlertRule, metricType)
+	}
+	return nil
+}
+
+// metricValue returns the numeric value of a metric regardless of its type.
+func metricValue(metric *types.Metrics) (float64, bool) {
+	if metric.Type == string(types.Gauge) && metric.Value != nil {
+		return *metric.Value, true
+	}
+	if metric.Type == string(types.Counter) && metric.Delta != nil {
+		return float64(*metric.Delta), true
+	}
+	return 0, false
+}
+
+// compareValues applies the rule operator to the value and the threshold.
+func compareValues(op types.AlertOperator, value, threshold float64) (bool, error) {
+	switch op {
+	case types.OperatorGreater:
+		return value > threshold, nil
+	case types.OperatorGreaterEqual:
+		return value >= threshold, nil
+	case types.OperatorLess:
+		return value < threshold, nil
+	case types.OperatorLessEqual:
+		return value <= threshold, nil
+	case types.OperatorEqual:
+		return value == threshold, nil
+	case types.OperatorNotEqual:
+		return value != threshold, nil
+	}
+	return false, fmt.Errorf("unknown operator %q", op)
+}
@@ -0,0 +1,408 @@
+package services
+
+import (
+	"context"
+	"go-metrics-alerting/internal/repositories"
+	"go-metrics-alerting/internal/types"
+	"testing"
+	"time"
+)
+
+type notSilenced struct{}
+
+func (notSilenced) ListSilences(ctx context.Context) ([]*types.Silence, error) {
+	return nil, nil
+}
+
+// newTestAlertService returns an alert service reading metrics from memory, along with the
+// metric service used to update them and the history repository.
+func newTestAlertService() (*AlertService, *MetricService, *repositories.AlertHistoryMemoryRepository) {
+	metrics := NewMetricService(repositories.NewMetricMemoryRepository(), repositories.NewAnomalyModelMemoryRepository())
+	history := repositories.NewAlertHistoryMemoryRepository()
+	alerts := NewAlertService(metrics, repositories.NewAlertRuleMemoryRepository(), history, notSilenced{})
+	return alerts, metrics, history
+}
+
+func setGauge(t *testing.T, metrics *MetricService, id string, value float64) {
+	t.Helper()
+	if _, err := metrics.UpdatesMetric(context.Background(), []*types.Metrics{{ID: id, Type: string(types.Gauge), Value: &value}}); err != nil {
+		t.Fatalf("UpdatesMetric() error = %v", err)
+	}
+}
+
+func TestAlertServiceEvaluateRules(t *testing.T) {
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+
+	// Every step sets the gauge, evaluates the rules at start plus the offset and expects
+	// the alert state, along with an event from the previous state if it changed.
+	type step struct {
+		at        time.Duration
+		value     float64
+		want      types.AlertState
+		wantEvent bool
+	}
+
+	tests := []struct {
+		name  string
+		for_  int64
+		steps []step
+	}{
+		{
+			name: "fires at once without a for duration",
+			steps: []step{
+				{at: 0, value: 5, want: types.AlertInactive},
+				{at: 10 * time.Second, value: 15, want: types.AlertFiring, wantEvent: true},
+				{at: 20 * time.Second, value: 20, want: types.AlertFiring},
+				{at: 30 * time.Second, value: 5, want: types.AlertResolved, wantEvent: true},
+				{at: 40 * time.Second, value: 5, want: types.AlertResolved},
+			},
+		},
+		{
+			name: "fires again after resolving",
+			steps: []step{
+				{at: 0, value: 15, want: types.AlertFiring, wantEvent: true},
+				{at: 10 * time.Second, value: 5, want: types.AlertResolved, wantEvent: true},
+				{at: 20 * time.Second, value: 15, want: types.AlertFiring, wantEvent: true},
+			},
+		},
+		{
+			name: "pending until the for duration elapsed",
+			for_: 20,
+			steps: []step{
+				{at: 0, value: 15, want: types.AlertPending, wantEvent: true},
+				{at: 10 * time.Second, value: 15, want: types.AlertPending},
+				{at: 20 * time.Second, value: 15, want: types.AlertFiring, wantEvent: true},
+				{at: 30 * time.Second, value: 5, want: types.AlertResolved, wantEvent: true},
+			},
+		},
+		{
+			name: "pending alert cleared before firing",
+			for_: 30,
+			steps: []step{
+				{at: 0, value: 15, want: types.AlertPending, wantEvent: true},
+				{at: 10 * time.Second, value: 5, want: types.AlertInactive, wantEvent: true},
+				{at: 20 * time.Second, value: 15, want: types.AlertPending, wantEvent: true},
+				{at: 40 * time.Second, value: 15, want: types.AlertPending},
+				{at: 50 * time.Second, value: 15, want: types.AlertFiring, wantEvent: true},
+			},
+		},
+		{
+			name: "resolved alert pending again",
+			for_: 10,
+			steps: []step{
+				{at: 0, value: 15, want: types.AlertPending, wantEvent: true},
+				{at: 10 * time.Second, value: 15, want: types.AlertFiring, wantEvent: true},
+				{at: 20 * time.Second, value: 5, want: types.AlertResolved, wantEvent: true},
+				{at: 30 * time.Second, value: 15, want: types.AlertPending, wantEvent: true},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ctx := context.Background()
+			alerts, metrics, history := newTestAlertService()
+			rule := &types.AlertRule{
+				ID:        "high-load",
+				Metric:    types.MetricID{ID: "Load", Type: string(types.Gauge)},
+				Operator:  types.OperatorGreater,
+				Threshold: 10,
+				Interval:  10,
+				For:       tt.for_,
+			}
+			if _, err := alerts.CreateRule(ctx, rule); err != nil {
+				t.Fatalf("CreateRule() error = %v", err)
+			}
+
+			previous := types.AlertInactive
+			var transitions int
+			for _, step := range tt.steps {
+				now := start.Add(step.at)
+				setGauge(t, metrics, "Load", step.value)
+				events, err := alerts.EvaluateRules(ctx, now)
+				if err != nil {
+					t.Fatalf("EvaluateRules() at %s error = %v", step.at, err)
+				}
+
+				alert, err := alerts.GetAlert(ctx, rule.ID)
+				if err != nil {
+					t.Fatalf("GetAlert() at %s error = %v", step.at, err)
+				}
+				if alert.State != step.want || alert.Value != step.value || !alert.LastEvaluatedAt.Equal(now) {
+					t.Errorf("alert at %s = %s with %v evaluated at %s, want %s with %v evaluated at %s",
+						step.at, alert.State, alert.Value, alert.LastEvaluatedAt, step.want, step.value, now)
+				}
+
+				switch {
+				case !step.wantEvent && len(events) != 0:
+					t.Errorf("events at %s = %+v, want none", step.at, events)
+				case step.wantEvent && len(events) != 1:
+					t.Errorf("events at %s = %+v, want one", step.at, events)
+				case step.wantEvent:
+					event := events[0]
+					if event.PreviousState != previous || event.Alert.State != step.want || !event.Timestamp.Equal(now) {
+						t.Errorf("event at %s = %s -> %s at %s, want %s -> %s at %s",
+							step.at, event.PreviousState, event.Alert.State, event.Timestamp, previous, step.want, now)
+					}
+					transitions++
+				}
+				previous = alert.State
+			}
+
+			recorded, err := history.FilterTransitions(ctx, types.AlertHistoryFilter{RuleID: rule.ID, Limit: MaxAlertHistoryLimit})
+			if err != nil {
+				t.Fatalf("FilterTransitions() error = %v", err)
+			}
+			if len(recorded) != transitions {
+				t.Errorf("recorded transitions = %d, want %d", len(recorded), transitions)
+			}
+		})
+	}
+}
+
+func TestAlertServiceEvaluateRulesTimestamps(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	alerts, metrics, _ := newTestAlertService()
+	rule := &types.AlertRule{
+		ID:        "high-load",
+		Metric:    types.MetricID{ID: "Load", Type: string(types.Gauge)},
+		Operator:  types.OperatorGreater,
+		Threshold: 10,
+		Interval:  10,
+		For:       10,
+	}
+	if _, err := alerts.CreateRule(ctx, rule); err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+
+	steps := []struct {
+		at           time.Duration
+		value        float64
+		wantActive   *time.Duration
+		wantFired    *time.Duration
+		wantResolved *time.Duration
+	}{
+		{at: 0, value: 15, wantActive: durationPtr(0)},
+		{at: 10 * time.Second, value: 15, wantActive: durationPtr(0), wantFired: durationPtr(10 * time.Second)},
+		{at: 20 * time.Second, value: 5, wantActive: durationPtr(0), wantFired: durationPtr(10 * time.Second), wantResolved: durationPtr(20 * time.Second)},
+		{at: 30 * time.Second, value: 15, wantActive: durationPtr(30 * time.Second)},
+	}
+
+	for _, step := range steps {
+		setGauge(t, metrics, "Load", step.value)
+		if _, err := alerts.EvaluateRules(ctx, start.Add(step.at)); err != nil {
+			t.Fatalf("EvaluateRules() at %s error = %v", step.at, err)
+		}
+		alert, err := alerts.GetAlert(ctx, rule.ID)
+		if err != nil {
+			t.Fatalf("GetAlert() at %s error = %v", step.at, err)
+		}
+
+		for _, check := range []struct {
+			name string
+			got  *time.Time
+			want *time.Duration
+		}{
+			{name: "ActiveAt", got: alert.ActiveAt, want: step.wantActive},
+			{name: "FiredAt", got: alert.FiredAt, want: step.wantFired},
+			{name: "ResolvedAt", got: alert.ResolvedAt, want: step.wantResolved},
+		} {
+			switch {
+			case check.want == nil && check.got != nil:
+				t.Errorf("%s at %s = %s, want none", check.name, step.at, check.got)
+			case check.want != nil && (check.got == nil || !check.got.Equal(start.Add(*check.want))):
+				t.Errorf("%s at %s = %v, want %s", check.name, step.at, check.got, start.Add(*check.want))
+			}
+		}
+	}
+}
+
+func TestAlertServiceEvaluateRulesInterval(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	alerts, metrics, _ := newTestAlertService()
+	rule := &types.AlertRule{
+		ID:        "high-load",
+		Metric:    types.MetricID{ID: "Load", Type: string(types.Gauge)},
+		Operator:  types.OperatorGreater,
+		Threshold: 10,
+		Interval:  60,
+	}
+	if _, err := alerts.CreateRule(ctx, rule); err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+
+	steps := []struct {
+		at    time.Duration
+		value float64
+		want  types.AlertState
+	}{
+		{at: 0, value: 5, want: types.AlertInactive},
+		// Not due yet, so the higher value is not seen
+		{at: 30 * time.Second, value: 15, want: types.AlertInactive},
+		{at: 60 * time.Second, value: 15, want: types.AlertFiring},
+	}
+	for _, step := range steps {
+		setGauge(t, metrics, "Load", step.value)
+		if _, err := alerts.EvaluateRules(ctx, start.Add(step.at)); err != nil {
+			t.Fatalf("EvaluateRules() at %s error = %v", step.at, err)
+		}
+		alert, err := alerts.GetAlert(ctx, rule.ID)
+		if err != nil {
+			t.Fatalf("GetAlert() at %s error = %v", step.at, err)
+		}
+		if alert.State != step.want {
+			t.Errorf("alert at %s = %s, want %s", step.at, alert.State, step.want)
+		}
+	}
+}
+
+func TestAlertServiceEvaluateRulesNoData(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	alerts, _, _ := newTestAlertService()
+	rule := &types.AlertRule{
+		ID:        "high-load",
+		Metric:    types.MetricID{ID: "Load", Type: string(types.Gauge)},
+		Operator:  types.OperatorGreater,
+		Threshold: 10,
+		Interval:  10,
+	}
+	if _, err := alerts.CreateRule(ctx, rule); err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+
+	events, err := alerts.EvaluateRules(ctx, start)
+	if err != nil {
+		t.Fatalf("EvaluateRules() error = %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("events = %+v, want none", events)
+	}
+	if _, err := alerts.GetAlert(ctx, rule.ID); err == nil {
+		t.Error("GetAlert() error = nil, want no alert without data")
+	}
+}
+
+func TestAlertServiceEvaluateRulesDeletedRule(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	alerts, metrics, history := newTestAlertService()
+	rule := &types.AlertRule{
+		ID:        "high-load",
+		Metric:    types.MetricID{ID: "Load", Type: string(types.Gauge)},
+		Operator:  types.OperatorGreater,
+		Threshold: 10,
+		Interval:  10,
+	}
+	if _, err := alerts.CreateRule(ctx, rule); err != nil {
+		t.Fatalf("CreateRule() error = %v", err)
+	}
+
+	setGauge(t, metrics, "Load", 15)
+	if _, err := alerts.EvaluateRules(ctx, start); err != nil {
+		t.Fatalf("EvaluateRules() error = %v", err)
+	}
+	if err := alerts.DeleteRule(ctx, rule.ID); err != nil {
+		t.Fatalf("DeleteRule() error = %v", err)
+	}
+
+	// The firing alert of the deleted rule is resolved once and then forgotten
+	now := start.Add(10 * time.Second)
+	events, err := alerts.EvaluateRules(ctx, now)
+	if err != nil {
+		t.Fatalf("EvaluateRules() error = %v", err)
+	}
+	if len(events) != 1 || events[0].PreviousState != types.AlertFiring || events[0].Alert.State != types.AlertResolved ||
+		events[0].Rule == nil || events[0].Rule.ID != rule.ID || !events[0].Alert.ResolvedAt.Equal(now) {
+		t.Fatalf("events = %+v, want the resolved alert of the deleted rule", events)
+	}
+	if _, err := alerts.GetAlert(ctx, rule.ID); err == nil {
+		t.Error("GetAlert() error = nil, want the alert forgotten")
+	}
+
+	events, err = alerts.EvaluateRules(ctx, now.Add(10*time.Second))
+	if err != nil {
+		t.Fatalf("EvaluateRules() error = %v", err)
+	}
+	if len(events) != 0 {
+		t.Errorf("events = %+v, want none", events)
+	}
+
+	recorded, err := history.FilterTransitions(ctx, types.AlertHistoryFilter{RuleID: rule.ID, Limit: MaxAlertHistoryLimit})
+	if err != nil {
+		t.Fatalf("FilterTransitions() error = %v", err)
+	}
+	if len(recorded) != 2 {
+		t.Errorf("recorded transitions = %+v, want firing and resolved", recorded)
+	}
+}
+
+// countingSilencer returns the same silences on every call and counts the calls.
+type countingSilencer struct {
+	silences []*types.Silence
+	calls    int
+}
+
+func (s *countingSilencer) ListSilences(ctx context.Context) ([]*types.Silence, error) {
+	s.calls++
+	return s.silences, nil
+}
+
+func TestAlertServiceEvaluateRulesSilences(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	end := start.Add(time.Hour)
+	silencer := &countingSilencer{silences: []*types.Silence{
+		{RuleID: "heap-*", StartsAt: start, EndsAt: &end},
+	}}
+	metrics := NewMetricService(repositories.NewMetricMemoryRepository(), repositories.NewAnomalyModelMemoryRepository())
+	alerts := NewAlertService(metrics, repositories.NewAlertRuleMemoryRepository(), repositories.NewAlertHistoryMemoryRepository(), silencer)
+
+	for _, id := range []string{"heap-high", "heap-low", "load-high"} {
+		rule := &types.AlertRule{
+			ID:        id,
+			Metric:    types.MetricID{ID: "Load", Type: string(types.Gauge)},
+			Operator:  types.OperatorGreater,
+			Threshold: 10,
+			Interval:  10,
+		}
+		if _, err := alerts.CreateRule(ctx, rule); err != nil {
+			t.Fatalf("CreateRule() error = %v", err)
+		}
+	}
+	setGauge(t, metrics, "Load", 15)
+
+	steps := []struct {
+		at        time.Duration
+		wantCalls int
+		want      map[string]bool
+	}{
+		{at: 0, wantCalls: 1, want: map[string]bool{"heap-high": true, "heap-low": true, "load-high": false}},
+		// No rule is due, so the silences are not listed
+		{at: 5 * time.Second, wantCalls: 1},
+		{at: time.Hour, wantCalls: 2, want: map[string]bool{"heap-high": false, "heap-low": false, "load-high": false}},
+	}
+	for _, step := range steps {
+		if _, err := alerts.EvaluateRules(ctx, start.Add(step.at)); err != nil {
+			t.Fatalf("EvaluateRules() at %s error = %v", step.at, err)
+		}
+		if silencer.calls != step.wantCalls {
+			t.Errorf("ListSilences() calls at %s = %d, want %d", step.at, silencer.calls, step.wantCalls)
+		}
+		for id, want := range step.want {
+			alert, err := alerts.GetAlert(ctx, id)
+			if err != nil {
+				t.Fatalf("GetAlert(%s) error = %v", id, err)
+			}
+			if alert.Silenced != want {
+				t.Errorf("alert %s silenced at %s = %v, want %v", id, step.at, alert.Silenced, want)
+			}
+		}
+	}
+}
+
+func durationPtr(d time.Duration) *time.Duration {
+	return &d
+}
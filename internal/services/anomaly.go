@@ -0,0 +1,135 @@
+package services
+
+import (
+	"context"
+	"go-metrics-alerting/internal/types"
+	"math"
+	"time"
+)
+
+const (
+	// AnomalyAlpha is the smoothing factor of the moving mean and variance;
+	// higher values adapt faster to new levels of the gauge.
+	AnomalyAlpha = 0.1
+	// AnomalyWarmupSamples is how many samples a model needs before its z-scores are trusted.
+	AnomalyWarmupSamples = 10
+	// MaxAnomalySamples is how many recent z-scores are kept per gauge.
+	MaxAnomalySamples = 100
+	// MinAnomalyStaleAfter is how long a model may go without updates before anomaly rules
+	// evaluated more often than that treat it as stale.
+	MinAnomalyStaleAfter = time.Minute
+)
+
+// AnomalyModelRepository defines the storage of the anomaly models.
+type AnomalyModelRepository interface {
+	SaveModels(ctx context.Context, models []*types.AnomalyModel) error
+	ListModels(ctx context.Context) ([]*types.AnomalyModel, error)
+}
+
+// updateAnomalyModels feeds the received gauge values into their models. The z-score of a sample is
+// computed against the model before the sample is added, so a spike stands out from the old level.
+func (s *MetricService) updateAnomalyModels(metrics []*types.Metrics, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, metric := range metrics {
+		if metric.Type != string(types.Gauge) || metric.Value == nil {
+			continue
+		}
+
+		id := types.MetricID{ID: metric.ID, Type: metric.Type}
+		model, exists := s.anomalyModels[id]
+		if !exists {
+			model = &types.AnomalyModel{Metric: id}
+			s.anomalyModels[id] = model
+		}
+		value := *metric.Value
+
+		// A flat gauge has no deviation to compare with, its first change is learned rather than flagged
+		var zscore float64
+		if model.Count > 0 && model.Variance > 0 {
+			zscore = (value - model.Mean) / math.Sqrt(model.Variance)
+		}
+
+		if model.Count == 0 {
+			model.Mean = value
+		} else {
+			diff := value - model.Mean
+			increment := AnomalyAlpha * diff
+			model.Mean += increment
+			model.Variance = (1 - AnomalyAlpha) * (model.Variance + diff*increment)
+		}
+		model.Count++
+		model.UpdatedAt = now
+
+		model.ZScores = append(model.ZScores, zscore)
+		if len(model.ZScores) > MaxAnomalySamples {
+			model.ZScores = model.ZScores[len(model.ZScores)-MaxAnomalySamples:]
+		}
+		s.dirtyModels[id] = true
+	}
+}
+
+// GetAnomalyModel returns a copy of the model of the gauge or nil if it has not been seen.
+func (s *MetricService) GetAnomalyModel(ctx context.Context, id types.MetricID) (*types.AnomalyModel, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	model, exists := s.anomalyModels[id]
+	if !exists {
+		return nil, nil
+	}
+	return copyAnomalyModel(model), nil
+}
+
+// LoadAnomalyModels restores the models saved before a restart.
+func (s *MetricService) LoadAnomalyModels(ctx context.Context) error {
+	models, err := s.models.ListModels(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, model := range models {
+		s.anomalyModels[model.Metric] = copyAnomalyModel(model)
+	}
+	return nil
+}
+
+// SaveAnomalyModels persists the models changed since the last save.
+func (s *MetricService) SaveAnomalyModels(ctx context.Context) error {
+	s.mu.Lock()
+	models := make([]*types.AnomalyModel, 0, len(s.dirtyModels))
+	for id := range s.dirtyModels {
+		models = append(models, copyAnomalyModel(s.anomalyModels[id]))
+	}
+	s.dirtyModels = make(map[types.MetricID]bool)
+	s.mu.Unlock()
+
+	if err := s.models.SaveModels(ctx, models); err != nil {
+		// Retry the failed models on the next save
+		s.mu.Lock()
+		for _, model := range models {
+			s.dirtyModels[model.Metric] = true
+		}
+		s.mu.Unlock()
+		return err
+	}
+	return nil
+}
+
+// recentZScores returns the last n z-scores of the model, or false while the model is warming up.
+func recentZScores(model *types.AnomalyModel, n int) ([]float64, bool) {
+	if model == nil || model.Count < AnomalyWarmupSamples+int64(n) || len(model.ZScores) < n {
+		return nil, false
+	}
+	return model.ZScores[len(model.ZScores)-n:], true
+}
+
+func copyAnomalyModel(model *types.AnomalyModel) *types.AnomalyModel {
+	copied := *model
+	copied.ZScores = append([]float64(nil), model.ZScores...)
+	return &copied
+}
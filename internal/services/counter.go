@@ -0,0 +1,72 @@
+package services
+
+import (
+	"context"
+	"go-metrics-alerting/internal/types"
+	"time"
+)
+
+// CounterHistoryWindow is how long counter samples are kept for rate and increase rules.
+const CounterHistoryWindow = time.Hour
+
+// recordCounterSamples appends the current values of the counters to their history
+// and drops samples that fell out of CounterHistoryWindow.
+func (s *MetricService) recordCounterSamples(metrics []*types.Metrics, now time.Time) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	cutoff := now.Add(-CounterHistoryWindow)
+	for _, metric := range metrics {
+		if metric.Type != string(types.Counter) || metric.Delta == nil {
+			continue
+		}
+
+		id := types.MetricID{ID: metric.ID, Type: metric.Type}
+		samples := append(s.counterHistory[id], types.Sample{Timestamp: now, Value: float64(*metric.Delta)})
+
+		// Keep one sample older than the window as the baseline of the first increase
+		drop := 0
+		for drop < len(samples)-1 && samples[drop+1].Timestamp.Before(cutoff) {
+			drop++
+		}
+		s.counterHistory[id] = samples[drop:]
+	}
+}
+
+// ListCounterSamples returns the samples of the counter taken since the given time,
+// preceded by the last sample taken before it, if any.
+func (s *MetricService) ListCounterSamples(ctx context.Context, id types.MetricID, since time.Time) ([]types.Sample, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	history := s.counterHistory[id]
+	start := len(history)
+	for i, sample := range history {
+		if !sample.Timestamp.Before(since) {
+			start = i
+			break
+		}
+	}
+	if start > 0 {
+		start--
+	}
+
+	samples := make([]types.Sample, len(history)-start)
+	copy(samples, history[start:])
+	return samples, nil
+}
+
+// counterIncrease sums the growth of a counter between consecutive samples.
+// A value lower than the previous one means the counter was reset (e.g. the agent
+// restarted), so the whole new value counts as growth.
+func counterIncrease(samples []types.Sample) float64 {
+	var increase float64
+	for i := 1; i < len(samples); i++ {
+		if samples[i].Value >= samples[i-1].Value {
+			increase += samples[i].Value - samples[i-1].Value
+		} else {
+			increase += samples[i].Value
+		}
+	}
+	return increase
+}
@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+	"sort"
+	"time"
+)
+
+// AlertLookup defines the read of the current alert state needed to escalate alerts.
+type AlertLookup interface {
+	GetAlert(ctx context.Context, id string) (*types.Alert, error)
+}
+
+// escalation tracks the progress of a firing alert through its escalation policy.
+type escalation struct {
+	event  *types.AlertEvent // The event the alert started firing with
+	policy *configs.EscalationPolicy
+	next   int             // Index of the next step to take
+	sent   map[string]bool // Receivers notified so far, so that retried steps skip them
+}
+
+// escalationSend is the delivery of the due steps of an escalation, up to the step index.
+type escalationSend struct {
+	id         string
+	esc        *escalation
+	upTo       int
+	receivers  []string
+	deliveries []*delivery
+}
+
+// trackEscalation starts or stops the escalation of the alert of the event.
+// Called with the lock held.
+func (s *NotificationService) trackEscalation(event *types.AlertEvent) {
+	id := event.Alert.RuleID
+	if event.Alert.State != types.AlertFiring {
+		delete(s.escalations, id)
+		return
+	}
+	if _, exists := s.escalations[id]; exists {
+		return
+	}
+
+	for i := range s.config.EscalationPolicies {
+		policy := &s.config.EscalationPolicies[i]
+		if matchLabels(policy.Match, event.Alert.Labels) {
+			s.escalations[id] = &escalation{event: event, policy: policy, sent: make(map[string]bool)}
+			return
+		}
+	}
+}
+
+// EscalateAlerts notifies the receivers of every escalation step whose timeout has passed.
+// Acknowledged, silenced and inhibited alerts are not escalated; acknowledged alerts stay
+// quiet until they resolve. A step is only taken once all of its receivers were notified,
+// so failed deliveries are retried on the next call without notifying the others again.
+func (s *NotificationService) EscalateAlerts(ctx context.Context, now time.Time) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	s.mu.Lock()
+	sends, errs := s.dueEscalations(ctx, now)
+	s.mu.Unlock()
+
+	var deliveries []*delivery
+	for _, send := range sends {
+		deliveries = append(deliveries, send.deliveries...)
+	}
+	s.sendDeliveries(ctx, deliveries)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, send := range sends {
+		for _, d := range send.deliveries {
+			if d.err != nil {
+				errs = append(errs, fmt.Errorf("escalation %s of %s to %s: %w", send.esc.policy.Name, send.id, d.receiver, d.err))
+				continue
+			}
+			send.esc.sent[d.receiver] = true
+		}
+
+		complete := true
+		for _, name := range send.receivers {
+			if !send.esc.sent[name] {
+				complete = false
+				break
+			}
+		}
+		if complete {
+			send.esc.next = send.upTo
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// dueEscalations returns the deliveries of the escalation steps whose timeout has passed to the
+// receivers not notified yet. Called with the lock held.
+func (s *NotificationService) dueEscalations(ctx context.Context, now time.Time) ([]*escalationSend, []error) {
+	ids := make([]string, 0, len(s.escalations))
+	for id := range s.escalations {
+		ids = append(ids, id)
+	}
+	sort.Strings(ids)
+
+	var sends []*escalationSend
+	var errs []error
+	for _, id := range ids {
+		esc := s.escalations[id]
+		if esc.next >= len(esc.policy.Steps) {
+			continue
+		}
+
+		alert, err := s.alerts.GetAlert(ctx, id)
+		if errors.Is(err, ErrAlertNotFound) {
+			delete(s.escalations, id)
+			continue
+		}
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		if alert.State != types.AlertFiring || alert.FiredAt == nil {
+			continue
+		}
+		if alert.Ack != nil || alert.Silenced || s.inhibited(alert) {
+			continue
+		}
+
+		// Take every step that is due, notifying each receiver once
+		firing := now.Sub(*alert.FiredAt)
+		send := &escalationSend{id: id, esc: esc, upTo: esc.next}
+		for send.upTo < len(esc.policy.Steps) {
+			step := esc.policy.Steps[send.upTo]
+			if firing < time.Duration(step.After)*time.Second {
+				break
+			}
+			send.receivers = appendMissing(send.receivers, step.Receivers...)
+			send.upTo++
+		}
+		if send.upTo == esc.next {
+			continue
+		}
+
+		event := &types.AlertEvent{
+			Rule:          esc.event.Rule,
+			Alert:         *alert,
+			PreviousState: types.AlertFiring,
+			Timestamp:     now,
+		}
+		for _, name := range send.receivers {
+			if !esc.sent[name] {
+				send.deliveries = append(send.deliveries, &delivery{receiver: name, events: []*types.AlertEvent{event}})
+			}
+		}
+		sends = append(sends, send)
+	}
+
+	return sends, errs
+}
+
+// validateEscalationReceivers checks that every receiver named in the escalation policies is configured.
+func validateEscalationReceivers(policies []configs.EscalationPolicy, receivers map[string]AlertNotifier) error {
+	for _, policy := range policies {
+		for _, step := range policy.Steps {
+			for _, name := range step.Receivers {
+				if _, exists := receivers[name]; !exists {
+					return fmt.Errorf("escalation policy %q: receiver %q is not configured", policy.Name, name)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func appendMissing(list []string, items ...string) []string {
+	for _, item := range items {
+		found := false
+		for _, existing := range list {
+			if existing == item {
+				found = true
+				break
+			}
+		}
+		if !found {
+			list = append(list, item)
+		}
+	}
+	return list
+}
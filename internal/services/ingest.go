@@ -0,0 +1,177 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/types"
+	"math"
+	"sync"
+)
+
+// DefaultIngestBatchSize is how many metrics are written with a single UpdatesMetric call.
+const DefaultIngestBatchSize = 1000
+
+// MaxMetricIDLength limits the length of the ID built from the name and labels of a point.
+// IDs of a few hundred bytes are common, but longer ones would not fit the database indexes.
+const MaxMetricIDLength = 2048
+
+// IngestMetricService defines the metric writes used to store ingested points.
+type IngestMetricService interface {
+	UpdatesMetric(ctx context.Context, metrics []*types.Metrics) ([]*types.Metrics, error)
+}
+
+// CounterTotalRepository defines the storage of the running totals of cumulative counters,
+// which must survive restarts so that the first push after one is not counted in full again.
+type CounterTotalRepository interface {
+	SaveTotals(ctx context.Context, totals []*types.CounterTotal) error
+	ListTotals(ctx context.Context) ([]*types.CounterTotal, error)
+}
+
+// IngestService translates points pushed by external systems into metric updates.
+type IngestService struct {
+	metrics   IngestMetricService
+	repo      CounterTotalRepository
+	batchSize int
+	totals    map[types.MetricID]int64 // Last running total of every cumulative counter
+	mu        sync.Mutex
+}
+
+func NewIngestService(metrics IngestMetricService, repo CounterTotalRepository, batchSize int) *IngestService {
+	return &IngestService{
+		metrics:   metrics,
+		repo:      repo,
+		batchSize: batchSize,
+		totals:    make(map[types.MetricID]int64),
+	}
+}
+
+// LoadTotals restores the running totals saved before a restart.
+func (s *IngestService) LoadTotals(ctx context.Context) error {
+	totals, err := s.repo.ListTotals(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, total := range totals {
+		s.totals[total.Metric] = total.Total
+	}
+	return nil
+}
+
+// Ingest stores the points in batches. Points of the same metric are merged first:
+// the last gauge value wins and counter increments add up. Cumulative counters are
+// turned into increments against the previously seen total; a total lower than the
+// previous one means the source restarted, so the whole new total is the increment.
+func (s *IngestService) Ingest(ctx context.Context, points []*types.Point) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	// New running totals only replace the known ones once the batch of their metric
+	// was written, so that a retried request yields the same increments
+	totals := make(map[types.MetricID]int64)
+
+	var order []types.MetricID
+	merged := make(map[types.MetricID]*types.Metrics)
+	for _, point := range points {
+		metric, err := s.translate(point, totals)
+		if err != nil {
+			return err
+		}
+		if metric == nil {
+			continue
+		}
+
+		id := types.MetricID{ID: metric.ID, Type: metric.Type}
+		existing, exists := merged[id]
+		switch {
+		case !exists:
+			merged[id] = metric
+			order = append(order, id)
+		case metric.Type == string(types.Gauge):
+			existing.Value = metric.Value
+		default:
+			*existing.Delta += *metric.Delta
+		}
+	}
+
+	for start := 0; start < len(order); start += s.batchSize {
+		batch := order[start:min(start+s.batchSize, len(order))]
+		if err := s.writeBatch(ctx, batch, merged, totals); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// writeBatch writes the metrics of the batch, then commits and saves the running totals
+// of its cumulative counters. Called with the lock held.
+func (s *IngestService) writeBatch(ctx context.Context, batch []types.MetricID, merged map[types.MetricID]*types.Metrics, totals map[types.MetricID]int64) error {
+	metrics := make([]*types.Metrics, 0, len(batch))
+	for _, id := range batch {
+		metrics = append(metrics, merged[id])
+	}
+	if _, err := s.metrics.UpdatesMetric(ctx, metrics); err != nil {
+		return err
+	}
+
+	var committed []*types.CounterTotal
+	for _, id := range batch {
+		if total, exists := totals[id]; exists {
+			s.totals[id] = total
+			committed = append(committed, &types.CounterTotal{Metric: id, Total: total})
+		}
+	}
+	if err := s.repo.SaveTotals(ctx, committed); err != nil {
+		return fmt.Errorf("failed to save counter totals: %w", err)
+	}
+	return nil
+}
+
+// translate builds the metric update of the point, or nil when the point carries no value.
+// Running totals of cumulative counters are tracked in totals. Called with the lock held.
+func (s *IngestService) translate(point *types.Point, totals map[types.MetricID]int64) (*types.Metrics, error) {
+	if point.Name == "" {
+		return nil, fmt.Errorf("%w: missing metric name", ErrInvalidPoint)
+	}
+	// NaN marks stale series in Prometheus and has no meaningful value elsewhere
+	if math.IsNaN(point.Value) || math.IsInf(point.Value, 0) {
+		return nil, nil
+	}
+
+	id := types.MetricID{ID: types.FormatMetricName(point.Name, point.Labels), Type: string(point.Type)}
+	if len(id.ID) > MaxMetricIDLength {
+		return nil, fmt.Errorf("%w: metric ID of %s is %d bytes long, the limit is %d", ErrInvalidPoint, point.Name, len(id.ID), MaxMetricIDLength)
+	}
+	switch point.Type {
+	case types.Gauge:
+		value := point.Value
+		return &types.Metrics{ID: id.ID, Type: id.Type, Value: &value}, nil
+
+	case types.Counter:
+		delta := int64(math.Round(point.Value))
+		if point.Cumulative {
+			total := delta
+			last, seen := totals[id]
+			if !seen {
+				last, seen = s.totals[id]
+			}
+			if seen && total >= last {
+				delta = total - last
+			}
+			totals[id] = total
+		}
+		if delta < 0 {
+			return nil, fmt.Errorf("%w: negative counter increment for %s", ErrInvalidPoint, id.ID)
+		}
+		return &types.Metrics{ID: id.ID, Type: id.Type, Delta: &delta}, nil
+	}
+
+	return nil, fmt.Errorf("%w: unknown metric type %q", ErrInvalidPoint, point.Type)
+}
+
+// ErrInvalidPoint is returned when an ingested point cannot be stored as a metric.
+var ErrInvalidPoint = errors.New("invalid point")
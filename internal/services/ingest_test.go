@@ -0,0 +1,252 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-metrics-alerting/internal/types"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// fakeIngestMetrics records the written batches and fails the configured write.
+type fakeIngestMetrics struct {
+	batches [][]types.Metrics
+	failAt  int // 1-based index of the failing write, 0 never fails
+}
+
+func (f *fakeIngestMetrics) UpdatesMetric(ctx context.Context, metrics []*types.Metrics) ([]*types.Metrics, error) {
+	if f.failAt == len(f.batches)+1 {
+		f.failAt = 0
+		return nil, errors.New("storage unavailable")
+	}
+	batch := make([]types.Metrics, 0, len(metrics))
+	for _, metric := range metrics {
+		batch = append(batch, *metric)
+	}
+	f.batches = append(f.batches, batch)
+	return metrics, nil
+}
+
+type fakeCounterTotals struct {
+	totals map[types.MetricID]int64
+}
+
+func (f *fakeCounterTotals) SaveTotals(ctx context.Context, totals []*types.CounterTotal) error {
+	for _, total := range totals {
+		f.totals[total.Metric] = total.Total
+	}
+	return nil
+}
+
+func (f *fakeCounterTotals) ListTotals(ctx context.Context) ([]*types.CounterTotal, error) {
+	var totals []*types.CounterTotal
+	for metric, total := range f.totals {
+		totals = append(totals, &types.CounterTotal{Metric: metric, Total: total})
+	}
+	return totals, nil
+}
+
+func gaugeMetric(id string, value float64) types.Metrics {
+	return types.Metrics{ID: id, Type: string(types.Gauge), Value: &value}
+}
+
+func counterMetric(id string, delta int64) types.Metrics {
+	return types.Metrics{ID: id, Type: string(types.Counter), Delta: &delta}
+}
+
+func TestIngestServiceIngest(t *testing.T) {
+	tests := []struct {
+		name      string
+		saved     map[types.MetricID]int64
+		batchSize int
+		pushes    [][]*types.Point
+		want      [][]types.Metrics
+	}{
+		{
+			name: "gauges with labels",
+			pushes: [][]*types.Point{{
+				{Name: "temperature", Labels: map[string]string{"room": "a"}, Type: types.Gauge, Value: 21.5},
+				{Name: "humidity", Type: types.Gauge, Value: 40},
+			}},
+			want: [][]types.Metrics{{
+				gaugeMetric(`temperature{room="a"}`, 21.5),
+				gaugeMetric("humidity", 40),
+			}},
+		},
+		{
+			name: "merged points of the same metric",
+			pushes: [][]*types.Point{{
+				{Name: "temperature", Type: types.Gauge, Value: 20},
+				{Name: "requests", Type: types.Counter, Value: 2},
+				{Name: "temperature", Type: types.Gauge, Value: 22},
+				{Name: "requests", Type: types.Counter, Value: 3.4},
+			}},
+			want: [][]types.Metrics{{
+				gaugeMetric("temperature", 22),
+				counterMetric("requests", 5),
+			}},
+		},
+		{
+			name: "non-finite values are skipped",
+			pushes: [][]*types.Point{{
+				{Name: "stale", Type: types.Gauge, Value: math.NaN()},
+				{Name: "overflow", Type: types.Counter, Value: math.Inf(1)},
+				{Name: "up", Type: types.Gauge, Value: 1},
+			}},
+			want: [][]types.Metrics{{gaugeMetric("up", 1)}},
+		},
+		{
+			name: "cumulative counters count their increase",
+			pushes: [][]*types.Point{
+				{{Name: "requests", Type: types.Counter, Value: 100, Cumulative: true}},
+				{
+					{Name: "requests", Type: types.Counter, Value: 130, Cumulative: true},
+					{Name: "requests", Type: types.Counter, Value: 150, Cumulative: true},
+				},
+				// The source restarted
+				{{Name: "requests", Type: types.Counter, Value: 7, Cumulative: true}},
+			},
+			want: [][]types.Metrics{
+				{counterMetric("requests", 100)},
+				{counterMetric("requests", 50)},
+				{counterMetric("requests", 7)},
+			},
+		},
+		{
+			name:  "cumulative counters continue from saved totals",
+			saved: map[types.MetricID]int64{{ID: "requests", Type: string(types.Counter)}: 100},
+			pushes: [][]*types.Point{
+				{{Name: "requests", Type: types.Counter, Value: 130, Cumulative: true}},
+			},
+			want: [][]types.Metrics{{counterMetric("requests", 30)}},
+		},
+		{
+			name:      "batches",
+			batchSize: 2,
+			pushes: [][]*types.Point{{
+				{Name: "a", Type: types.Gauge, Value: 1},
+				{Name: "b", Type: types.Gauge, Value: 2},
+				{Name: "c", Type: types.Gauge, Value: 3},
+			}},
+			want: [][]types.Metrics{
+				{gaugeMetric("a", 1), gaugeMetric("b", 2)},
+				{gaugeMetric("c", 3)},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := &fakeIngestMetrics{}
+			totals := &fakeCounterTotals{totals: make(map[types.MetricID]int64)}
+			for metric, total := range tt.saved {
+				totals.totals[metric] = total
+			}
+			batchSize := tt.batchSize
+			if batchSize == 0 {
+				batchSize = DefaultIngestBatchSize
+			}
+
+			s := NewIngestService(metrics, totals, batchSize)
+			if err := s.LoadTotals(context.Background()); err != nil {
+				t.Fatalf("LoadTotals() error = %v", err)
+			}
+			for _, points := range tt.pushes {
+				if err := s.Ingest(context.Background(), points); err != nil {
+					t.Fatalf("Ingest() error = %v", err)
+				}
+			}
+			if !reflect.DeepEqual(metrics.batches, tt.want) {
+				t.Errorf("written batches = %+v, want %+v", metrics.batches, tt.want)
+			}
+		})
+	}
+}
+
+func TestIngestServiceIngestInvalidPoints(t *testing.T) {
+	tests := []struct {
+		name  string
+		point *types.Point
+	}{
+		{name: "missing name", point: &types.Point{Type: types.Gauge, Value: 1}},
+		{name: "negative counter increment", point: &types.Point{Name: "requests", Type: types.Counter, Value: -1}},
+		{name: "unknown type", point: &types.Point{Name: "requests", Type: "histogram", Value: 1}},
+		{
+			name:  "metric ID over the limit",
+			point: &types.Point{Name: "requests", Labels: map[string]string{"path": strings.Repeat("a", MaxMetricIDLength)}, Type: types.Gauge, Value: 1},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			metrics := &fakeIngestMetrics{}
+			s := NewIngestService(metrics, &fakeCounterTotals{totals: make(map[types.MetricID]int64)}, DefaultIngestBatchSize)
+
+			points := []*types.Point{{Name: "up", Type: types.Gauge, Value: 1}, tt.point}
+			if err := s.Ingest(context.Background(), points); !errors.Is(err, ErrInvalidPoint) {
+				t.Errorf("Ingest() error = %v, want %v", err, ErrInvalidPoint)
+			}
+			if len(metrics.batches) != 0 {
+				t.Errorf("written batches = %+v, want none", metrics.batches)
+			}
+		})
+	}
+}
+
+func TestIngestServiceIngestLongMetricID(t *testing.T) {
+	// Labels easily make IDs longer than 255 characters, which must be stored intact
+	labels := map[string]string{
+		"instance": "api-" + strings.Repeat("0", 100),
+		"path":     "/v1/" + strings.Repeat("x", 200),
+	}
+	id := types.FormatMetricName("http_requests_total", labels)
+	if len(id) < 300 {
+		t.Fatalf("test metric ID is %d bytes long, want at least 300", len(id))
+	}
+
+	metrics := &fakeIngestMetrics{}
+	s := NewIngestService(metrics, &fakeCounterTotals{totals: make(map[types.MetricID]int64)}, DefaultIngestBatchSize)
+	points := []*types.Point{{Name: "http_requests_total", Labels: labels, Type: types.Counter, Value: 3}}
+	if err := s.Ingest(context.Background(), points); err != nil {
+		t.Fatalf("Ingest() error = %v", err)
+	}
+
+	want := [][]types.Metrics{{counterMetric(id, 3)}}
+	if !reflect.DeepEqual(metrics.batches, want) {
+		t.Errorf("written batches = %+v, want %+v", metrics.batches, want)
+	}
+}
+
+func TestIngestServiceIngestRetriedAfterFailure(t *testing.T) {
+	metrics := &fakeIngestMetrics{failAt: 2}
+	totals := &fakeCounterTotals{totals: make(map[types.MetricID]int64)}
+	s := NewIngestService(metrics, totals, 1)
+
+	points := []*types.Point{
+		{Name: "a", Type: types.Counter, Value: 10, Cumulative: true},
+		{Name: "b", Type: types.Counter, Value: 20, Cumulative: true},
+	}
+	if err := s.Ingest(context.Background(), points); err == nil {
+		t.Fatal("Ingest() error = nil, want the storage error")
+	}
+
+	// Only the totals of the written batch are committed, so the retry counts b in full again
+	wantTotals := map[types.MetricID]int64{{ID: "a", Type: string(types.Counter)}: 10}
+	if !reflect.DeepEqual(totals.totals, wantTotals) {
+		t.Errorf("saved totals = %v, want %v", totals.totals, wantTotals)
+	}
+
+	if err := s.Ingest(context.Background(), points); err != nil {
+		t.Fatalf("Ingest() error = %v", err)
+	}
+	want := [][]types.Metrics{
+		{counterMetric("a", 10)},
+		{counterMetric("a", 0)},
+		{counterMetric("b", 20)},
+	}
+	if !reflect.DeepEqual(metrics.batches, want) {
+		t.Errorf("written batches = %+v, want %+v", metrics.batches, want)
+	}
+}
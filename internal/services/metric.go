@@ -4,24 +4,53 @@ import (
 	"context"
 	"errors"
 	"go-metrics-alerting/internal/types"
+	"sync"
+	"time"
 )
 
 type MetricRepository interface {
 	SaveMetrics(ctx context.Context, metrics []*types.Metrics) error
 	FilterMetricsByTypeAndID(ctx context.Context, metricIDs []types.MetricID) ([]*types.Metrics, error)
 	ListMetrics(ctx context.Context) ([]*types.Metrics, error)
+	SaveSamples(ctx context.Context, samples []*types.MetricSample) error
+	ListSamples(ctx context.Context, id types.MetricID, from, to time.Time) ([]types.Sample, error)
+	SaveRollups(ctx context.Context, rollups []*types.MetricRollup) error
+	ListRollups(ctx context.Context, id types.MetricID, resolution time.Duration, from, to time.Time) ([]*types.MetricRollup, error)
 }
 
 type MetricService struct {
-	repo MetricRepository
+	repo           MetricRepository
+	models         AnomalyModelRepository
+	updatedAt      map[types.MetricID]time.Time
+	counterHistory map[types.MetricID][]types.Sample
+	anomalyModels  map[types.MetricID]*types.AnomalyModel
+	dirtyModels    map[types.MetricID]bool // Models changed since the last save
+	mu             sync.RWMutex
+	updateMu       sync.Mutex // Serializes the read-modify-write of stored metrics
+
+	rollupWatermarks map[time.Duration]time.Time // End of the rolled up buckets per resolution
+	rollupMu         sync.Mutex
 }
 
-func NewMetricService(repo MetricRepository) *MetricService {
-	return &MetricService{repo: repo}
+func NewMetricService(repo MetricRepository, models AnomalyModelRepository) *MetricService {
+	return &MetricService{
+		repo:           repo,
+		models:         models,
+		updatedAt:      make(map[types.MetricID]time.Time),
+		counterHistory: make(map[types.MetricID][]types.Sample),
+		anomalyModels:  make(map[types.MetricID]*types.AnomalyModel),
+		dirtyModels:    make(map[types.MetricID]bool),
+
+		rollupWatermarks: make(map[time.Duration]time.Time),
+	}
 }
 
 // UpdatesMetric updates the metrics and returns the updated metrics.
 func (s *MetricService) UpdatesMetric(ctx context.Context, metrics []*types.Metrics) ([]*types.Metrics, error) {
+	// Concurrent updates of a counter would otherwise both add to the same stored total
+	s.updateMu.Lock()
+	defer s.updateMu.Unlock()
+
 	var metricIDs []types.MetricID
 	for _, metric := range metrics {
 		metricIDs = append(metricIDs, types.MetricID{ID: metric.ID, Type: metric.Type})
@@ -62,11 +91,35 @@ func (s *MetricService) UpdatesMetric(ctx context.Context, metrics []*types.Metr
 		updatedMetrics = append(updatedMetrics, metric)
 	}
 
+	// Record the new values in the history before saving the metrics: a failed history write
+	// leaves the counters untouched for the retry, whereas a failed metrics write only leaves
+	// samples of the new values, which the retry records again
+	now := time.Now()
+	samples := make([]*types.MetricSample, 0, len(updatedMetrics))
+	for _, metric := range updatedMetrics {
+		if value, ok := metricValue(metric); ok {
+			samples = append(samples, &types.MetricSample{ID: metric.ID, Type: metric.Type, Timestamp: now, Value: value})
+		}
+	}
+	if err := s.repo.SaveSamples(ctx, samples); err != nil {
+		return nil, err
+	}
+
 	// Save updated metrics to the repository
 	if err := s.repo.SaveMetrics(ctx, updatedMetrics); err != nil {
 		return nil, err
 	}
 
+	// Remember when every received metric was last updated
+	s.mu.Lock()
+	for _, metric := range metrics {
+		s.updatedAt[types.MetricID{ID: metric.ID, Type: metric.Type}] = now
+	}
+	s.mu.Unlock()
+
+	s.recordCounterSamples(updatedMetrics, now)
+	s.updateAnomalyModels(metrics, now)
+
 	return updatedMetrics, nil
 }
 
@@ -97,5 +150,21 @@ func (s *MetricService) ListAllMetrics(ctx context.Context) ([]*types.Metrics, e
 	return metrics, nil
 }
 
+// ListMetricUpdates returns the time of the last update of every metric written since the start.
+func (s *MetricService) ListMetricUpdates(ctx context.Context) (map[types.MetricID]time.Time, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	updates := make(map[types.MetricID]time.Time, len(s.updatedAt))
+	for id, updatedAt := range s.updatedAt {
+		updates[id] = updatedAt
+	}
+
+	return updates, nil
+}
+
 // Helper for logging errors related to not finding a metric.
 var ErrMetricNotFound = errors.New("not found")
+
+// ErrInvalidSampleRange is returned when the requested history range is empty.
+var ErrInvalidSampleRange = errors.New("invalid sample range")
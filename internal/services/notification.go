@@ -0,0 +1,396 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+)
+
+// GroupByMetricPrefix is the special group_by key grouping alerts by the prefix of the metric name,
+// e.g. HeapAlloc and HeapSys both belong to the Heap group.
+const GroupByMetricPrefix = "metric_prefix"
+
+// NotificationRetryInterval is how long after a failed delivery the group is flushed again.
+const NotificationRetryInterval = 30 * time.Second
+
+// AlertNotifier delivers alert events to an external receiver.
+type AlertNotifier interface {
+	Notify(ctx context.Context, events []*types.AlertEvent) error
+}
+
+// partialDelivery is implemented by the errors of notifiers sending the events in several requests,
+// reporting the events delivered before the failure.
+type partialDelivery interface {
+	DeliveredEvents() []*types.AlertEvent
+}
+
+// alertGroup collects alerts sharing the same values of the group_by keys.
+type alertGroup struct {
+	labels    map[string]string
+	alerts    map[string]*types.AlertEvent           // Latest event of every alert by rule ID
+	notified  map[string]map[string]types.AlertState // Last state delivered to every receiver by receiver and rule ID
+	createdAt time.Time
+	flushedAt time.Time
+	failed    bool // A delivery of the last flush failed and is retried after NotificationRetryInterval
+}
+
+// NotificationService groups, deduplicates and inhibits alert events before routing them to the receivers.
+type NotificationService struct {
+	config      *configs.NotificationConfig
+	receivers   map[string]AlertNotifier
+	route       *alertRoute // Nil when every alert goes to all receivers
+	alerts      AlertLookup
+	groups      map[string]*alertGroup
+	firing      map[string]*types.Alert // Firing alerts by rule ID, the sources of inhibition
+	escalations map[string]*escalation  // Firing alerts with an escalation policy by rule ID
+	mu          sync.Mutex
+	sendMu      sync.Mutex // Serializes flushes and escalations, which deliver without holding mu
+}
+
+func NewNotificationService(config *configs.NotificationConfig, receivers map[string]AlertNotifier, alerts AlertLookup) (*NotificationService, error) {
+	s := &NotificationService{
+		config:      config,
+		receivers:   receivers,
+		alerts:      alerts,
+		groups:      make(map[string]*alertGroup),
+		firing:      make(map[string]*types.Alert),
+		escalations: make(map[string]*escalation),
+	}
+
+	if config.Route != nil {
+		s.route = newAlertRoute(config.Route)
+		if err := s.route.validateReceivers(receivers); err != nil {
+			return nil, err
+		}
+	}
+	if err := validateEscalationReceivers(config.EscalationPolicies, receivers); err != nil {
+		return nil, err
+	}
+
+	return s, nil
+}
+
+// EnqueueEvents adds firing and resolved events to their groups. Silenced firing events are
+// dropped, while resolutions are always queued so that alerts notified as firing are closed.
+func (s *NotificationService) EnqueueEvents(ctx context.Context, events []*types.AlertEvent, now time.Time) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	for _, event := range events {
+		alert := event.Alert
+		if alert.State == types.AlertFiring {
+			s.firing[alert.RuleID] = &alert
+		} else {
+			delete(s.firing, alert.RuleID)
+		}
+		s.trackEscalation(event)
+
+		// Pending and inactive transitions are not worth a notification
+		if alert.State != types.AlertFiring && alert.State != types.AlertResolved {
+			continue
+		}
+
+		labels := s.groupLabels(&alert)
+		key := groupKey(labels)
+		group, exists := s.groups[key]
+
+		// A silenced alert leaves its group, which keeps what was delivered to report its resolution
+		if alert.Silenced && alert.State == types.AlertFiring {
+			if exists {
+				delete(group.alerts, alert.RuleID)
+			}
+			continue
+		}
+
+		if !exists {
+			group = &alertGroup{
+				labels:    labels,
+				alerts:    make(map[string]*types.AlertEvent),
+				notified:  make(map[string]map[string]types.AlertState),
+				createdAt: now,
+			}
+			s.groups[key] = group
+		}
+		group.alerts[alert.RuleID] = event
+	}
+
+	return nil
+}
+
+// FlushNotifications sends a notification for every group that is due: new groups after group_wait,
+// changed groups after group_interval, groups with still firing alerts after repeat_interval and
+// groups with failed deliveries after NotificationRetryInterval. An alert counts as notified to a
+// receiver only once the delivery to it succeeded.
+func (s *NotificationService) FlushNotifications(ctx context.Context, now time.Time) error {
+	s.sendMu.Lock()
+	defer s.sendMu.Unlock()
+
+	s.mu.Lock()
+	keys, deliveries := s.dueDeliveries(now)
+	s.mu.Unlock()
+
+	s.sendDeliveries(ctx, deliveries)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	var errs []error
+	for _, d := range deliveries {
+		if d.err != nil {
+			d.group.failed = true
+			errs = append(errs, fmt.Errorf("receiver %s: %w", d.receiver, d.err))
+
+			// Events delivered before the failure are not sent again by the retry
+			var partial partialDelivery
+			if !errors.As(d.err, &partial) {
+				continue
+			}
+			d.events = partial.DeliveredEvents()
+		}
+		notified := d.group.notified[d.receiver]
+		if notified == nil {
+			notified = make(map[string]types.AlertState)
+			d.group.notified[d.receiver] = notified
+		}
+		for _, event := range d.events {
+			notified[event.Alert.RuleID] = event.Alert.State
+		}
+	}
+
+	for _, key := range keys {
+		group := s.groups[key]
+		s.dropResolved(group)
+		if len(group.alerts) == 0 {
+			delete(s.groups, key)
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// dueDeliveries marks the groups that are due as flushed and returns their keys along with
+// the deliveries of their alerts to every receiver. Called with the lock held.
+func (s *NotificationService) dueDeliveries(now time.Time) ([]string, []*delivery) {
+	groupWait := time.Duration(s.config.GroupWait) * time.Second
+	groupInterval := time.Duration(s.config.GroupInterval) * time.Second
+	repeatInterval := time.Duration(s.config.RepeatInterval) * time.Second
+
+	keys := make([]string, 0, len(s.groups))
+	for key := range s.groups {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+
+	var flushed []string
+	var deliveries []*delivery
+	for _, key := range keys {
+		group := s.groups[key]
+		changed := s.routeAlerts(group, false)
+
+		var routed map[string][]*types.AlertEvent
+		switch {
+		case group.flushedAt.IsZero():
+			if now.Sub(group.createdAt) < groupWait {
+				continue
+			}
+			routed = changed
+		case group.failed && now.Sub(group.flushedAt) >= NotificationRetryInterval:
+			routed = changed
+		case len(changed) != 0 && now.Sub(group.flushedAt) >= groupInterval:
+			routed = changed
+		case now.Sub(group.flushedAt) >= repeatInterval:
+			routed = s.routeAlerts(group, true)
+		default:
+			continue
+		}
+		group.flushedAt = now
+		group.failed = false
+		flushed = append(flushed, key)
+
+		receivers := make([]string, 0, len(routed))
+		for receiver := range routed {
+			receivers = append(receivers, receiver)
+		}
+		sort.Strings(receivers)
+		for _, receiver := range receivers {
+			deliveries = append(deliveries, &delivery{receiver: receiver, events: routed[receiver], group: group})
+		}
+	}
+
+	return flushed, deliveries
+}
+
+// routeAlerts returns the alerts of the group to notify by receiver: the alerts whose state differs
+// from the one last delivered to the receiver, and with repeat every firing alert. Resolved alerts
+// are only reported to receivers that were notified of them firing, and inhibited alerts not at all.
+func (s *NotificationService) routeAlerts(group *alertGroup, repeat bool) map[string][]*types.AlertEvent {
+	routed := make(map[string][]*types.AlertEvent)
+	for _, event := range sortedEvents(group) {
+		firing := event.Alert.State == types.AlertFiring
+		if firing && s.inhibited(&event.Alert) {
+			continue
+		}
+		for _, receiver := range s.routeReceivers(event.Alert.Labels) {
+			notified := group.notified[receiver][event.Alert.RuleID]
+			if (firing && (repeat || notified != types.AlertFiring)) || (!firing && notified == types.AlertFiring) {
+				routed[receiver] = append(routed[receiver], event)
+			}
+		}
+	}
+	return routed
+}
+
+// dropResolved removes resolved alerts that need no further notification to any receiver.
+func (s *NotificationService) dropResolved(group *alertGroup) {
+	for id, event := range group.alerts {
+		if event.Alert.State != types.AlertResolved {
+			continue
+		}
+		pending := false
+		for _, notified := range group.notified {
+			if notified[id] == types.AlertFiring {
+				pending = true
+				break
+			}
+		}
+		if pending {
+			continue
+		}
+		delete(group.alerts, id)
+		for _, notified := range group.notified {
+			delete(notified, id)
+		}
+	}
+}
+
+// inhibited reports whether another firing alert suppresses the alert through an inhibit rule.
+func (s *NotificationService) inhibited(alert *types.Alert) bool {
+	for _, rule := range s.config.InhibitRules {
+		if !matchLabels(rule.TargetMatch, alert.Labels) {
+			continue
+		}
+		for id, source := range s.firing {
+			if id == alert.RuleID {
+				continue
+			}
+			if matchLabels(rule.SourceMatch, source.Labels) && equalLabels(rule.Equal, source.Labels, alert.Labels) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// delivery is a batch of events for one receiver and the outcome of sending it.
+type delivery struct {
+	receiver string
+	events   []*types.AlertEvent
+	group    *alertGroup // Nil for escalations
+	err      error
+}
+
+// sendDeliveries sends the deliveries with a goroutine per receiver, so that a slow or failing
+// receiver does not hold up the others, and records the result of every delivery.
+func (s *NotificationService) sendDeliveries(ctx context.Context, deliveries []*delivery) {
+	queues := make(map[string][]*delivery)
+	for _, d := range deliveries {
+		queues[d.receiver] = append(queues[d.receiver], d)
+	}
+
+	var wg sync.WaitGroup
+	for name, queue := range queues {
+		notifier := s.receivers[name]
+		wg.Add(1)
+		go func(queue []*delivery) {
+			defer wg.Done()
+			for _, d := range queue {
+				d.err = notifier.Notify(ctx, d.events)
+			}
+		}(queue)
+	}
+	wg.Wait()
+}
+
+// groupLabels returns the values of the group_by keys of the alert.
+func (s *NotificationService) groupLabels(alert *types.Alert) map[string]string {
+	labels := make(map[string]string, len(s.config.GroupBy))
+	for _, key := range s.config.GroupBy {
+		if key == GroupByMetricPrefix {
+			labels[key] = metricPrefix(alert.Metric.ID)
+			continue
+		}
+		labels[key] = alert.Labels[key]
+	}
+	return labels
+}
+
+// groupKey builds a stable identifier of the group from its labels.
+func groupKey(labels map[string]string) string {
+	pairs := make([]string, 0, len(labels))
+	for name, value := range labels {
+		pairs = append(pairs, name+"="+value)
+	}
+	sort.Strings(pairs)
+	return strings.Join(pairs, ",")
+}
+
+// sortedEvents returns the events of the group ordered by rule ID.
+func sortedEvents(group *alertGroup) []*types.AlertEvent {
+	events := make([]*types.AlertEvent, 0, len(group.alerts))
+	for _, event := range group.alerts {
+		events = append(events, event)
+	}
+	sort.Slice(events, func(i, j int) bool { return events[i].Alert.RuleID < events[j].Alert.RuleID })
+	return events
+}
+
+// metricPrefix returns the first word of a metric name: the part before a separator
+// or the leading CamelCase word, keeping acronyms together (GCSys -> GC).
+func metricPrefix(id string) string {
+	if i := strings.IndexAny(id, "_.:"); i > 0 {
+		return id[:i]
+	}
+
+	runes := []rune(id)
+	for i := 1; i < len(runes); i++ {
+		if !unicode.IsUpper(runes[i]) {
+			continue
+		}
+		// Inside an acronym the word ends before the last capital followed by a lowercase letter
+		if unicode.IsUpper(runes[i-1]) {
+			if i+1 < len(runes) && unicode.IsLower(runes[i+1]) {
+				return string(runes[:i])
+			}
+			continue
+		}
+		return string(runes[:i])
+	}
+	return id
+}
+
+// matchLabels reports whether the labels contain all the matchers.
+func matchLabels(matchers, labels map[string]string) bool {
+	for name, value := range matchers {
+		if labels[name] != value {
+			return false
+		}
+	}
+	return true
+}
+
+// equalLabels reports whether both label sets have the same values of the given names.
+func equalLabels(names []string, a, b map[string]string) bool {
+	for _, name := range names {
+		if a[name] != b[name] {
+			return false
+		}
+	}
+	return true
+}
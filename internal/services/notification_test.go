@@ -0,0 +1,87 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+	"testing"
+	"time"
+)
+
+// partialError reports the first events of a batch as delivered.
+type partialError struct {
+	delivered []*types.AlertEvent
+}
+
+func (e *partialError) Error() string {
+	return "partially delivered"
+}
+
+func (e *partialError) DeliveredEvents() []*types.AlertEvent {
+	return e.delivered
+}
+
+// partialNotifier delivers only the first event of the first batch and records every batch.
+type partialNotifier struct {
+	batches [][]string
+}
+
+func (n *partialNotifier) Notify(ctx context.Context, events []*types.AlertEvent) error {
+	var ids []string
+	for _, event := range events {
+		ids = append(ids, event.Alert.RuleID)
+	}
+	n.batches = append(n.batches, ids)
+	if len(n.batches) == 1 {
+		return &partialError{delivered: events[:1]}
+	}
+	return nil
+}
+
+func TestNotificationServicePartialDelivery(t *testing.T) {
+	ctx := context.Background()
+	now := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	notifier := &partialNotifier{}
+	s, err := NewNotificationService(&configs.NotificationConfig{RepeatInterval: 3600}, map[string]AlertNotifier{"webhook": notifier}, nil)
+	if err != nil {
+		t.Fatalf("NewNotificationService() error = %v", err)
+	}
+
+	var events []*types.AlertEvent
+	for _, id := range []string{"a", "b", "c"} {
+		events = append(events, &types.AlertEvent{
+			Rule:          &types.AlertRule{ID: id},
+			Alert:         types.Alert{RuleID: id, Metric: types.MetricID{ID: id, Type: string(types.Gauge)}, State: types.AlertFiring},
+			PreviousState: types.AlertPending,
+			Timestamp:     now,
+		})
+	}
+	if err := s.EnqueueEvents(ctx, events, now); err != nil {
+		t.Fatalf("EnqueueEvents() error = %v", err)
+	}
+
+	var partial *partialError
+	if err := s.FlushNotifications(ctx, now); !errors.As(err, &partial) {
+		t.Fatalf("FlushNotifications() error = %v, want the partial delivery", err)
+	}
+	// The retry only sends the events that were not delivered
+	if err := s.FlushNotifications(ctx, now.Add(NotificationRetryInterval)); err != nil {
+		t.Fatalf("FlushNotifications() error = %v", err)
+	}
+
+	want := [][]string{{"a", "b", "c"}, {"b", "c"}}
+	if len(notifier.batches) != len(want) {
+		t.Fatalf("batches = %v, want %v", notifier.batches, want)
+	}
+	for i := range want {
+		if len(notifier.batches[i]) != len(want[i]) {
+			t.Fatalf("batches = %v, want %v", notifier.batches, want)
+		}
+		for j := range want[i] {
+			if notifier.batches[i][j] != want[i][j] {
+				t.Errorf("batches = %v, want %v", notifier.batches, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,199 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/promql"
+	"go-metrics-alerting/internal/types"
+	"sort"
+	"time"
+)
+
+// QueryMetricService defines the metric reads used to answer queries.
+type QueryMetricService interface {
+	ListAllMetrics(ctx context.Context) ([]*types.Metrics, error)
+	ListSeries(ctx context.Context, id types.MetricID, from, to time.Time, resolution time.Duration) (*types.MetricSeries, error)
+}
+
+// QueryService answers PromQL queries over the metric history. Series carry the metric
+// name as exposed on /metrics and the labels encoded in the metric ID.
+type QueryService struct {
+	metrics QueryMetricService
+}
+
+func NewQueryService(metrics QueryMetricService) *QueryService {
+	return &QueryService{metrics: metrics}
+}
+
+// Query evaluates the query at the given time.
+func (s *QueryService) Query(ctx context.Context, query string, t time.Time) (promql.Value, error) {
+	node, err := promql.Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+	}
+	series, err := s.loadSeries(ctx, promql.Selectors(node), t.Add(-promql.Window(node)), t, 0)
+	if err != nil {
+		return nil, err
+	}
+	return promql.Eval(node, series, t)
+}
+
+// QueryRange evaluates the query at every step from start to end.
+func (s *QueryService) QueryRange(ctx context.Context, query string, start, end time.Time, step time.Duration) (promql.Matrix, error) {
+	node, err := promql.Parse(query)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+	}
+	// Read rollups where the step allows, keeping at least two points within every window
+	resolution := RollupResolution(min(step, promql.Window(node)/2))
+	series, err := s.loadSeries(ctx, promql.Selectors(node), start.Add(-promql.Window(node)), end, resolution)
+	if err != nil {
+		return nil, err
+	}
+
+	matrix, err := promql.EvalRange(node, series, start, end, step)
+	if errors.Is(err, promql.ErrTooManyPoints) {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+	}
+	return matrix, err
+}
+
+// Series returns the label sets of the series matching any of the selectors
+// that have samples between start and end.
+func (s *QueryService) Series(ctx context.Context, matchers []string, start, end time.Time) ([]map[string]string, error) {
+	series, err := s.selectSeries(ctx, matchers, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]map[string]string, 0, len(series))
+	for _, s := range series {
+		result = append(result, s.Labels)
+	}
+	return result, nil
+}
+
+// LabelNames returns the sorted label names of the matching series. Without
+// selectors all series are considered.
+func (s *QueryService) LabelNames(ctx context.Context, matchers []string, start, end time.Time) ([]string, error) {
+	series, err := s.selectSeries(ctx, matchers, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	names := make(map[string]bool)
+	for _, s := range series {
+		for name := range s.Labels {
+			names[name] = true
+		}
+	}
+	return sortedKeys(names), nil
+}
+
+// LabelValues returns the sorted values of the label across the matching series.
+func (s *QueryService) LabelValues(ctx context.Context, name string, matchers []string, start, end time.Time) ([]string, error) {
+	series, err := s.selectSeries(ctx, matchers, start, end)
+	if err != nil {
+		return nil, err
+	}
+
+	values := make(map[string]bool)
+	for _, s := range series {
+		if value, exists := s.Labels[name]; exists {
+			values[value] = true
+		}
+	}
+	return sortedKeys(values), nil
+}
+
+// selectSeries returns the series matching any of the selectors with a sample visible
+// to queries between start and end. No selectors match every series, and a zero start
+// selects series regardless of their samples.
+func (s *QueryService) selectSeries(ctx context.Context, matchers []string, start, end time.Time) ([]*promql.Series, error) {
+	var selectors []*promql.VectorSelector
+	for _, matcher := range matchers {
+		selector, err := promql.ParseSelector(matcher)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %v", ErrInvalidQuery, err)
+		}
+		selectors = append(selectors, selector)
+	}
+
+	if start.IsZero() {
+		return s.loadSeries(ctx, selectors, time.Time{}, time.Time{}, 0)
+	}
+
+	resolution := RollupResolution(end.Sub(start) / MaxSeriesPoints)
+	loaded, err := s.loadSeries(ctx, selectors, start.Add(-promql.LookbackDelta), end, resolution)
+	if err != nil {
+		return nil, err
+	}
+	var selected []*promql.Series
+	for _, series := range loaded {
+		if len(series.Samples) > 0 {
+			selected = append(selected, series)
+		}
+	}
+	return selected, nil
+}
+
+// loadSeries builds a series for every metric matching any of the selectors, or every
+// metric without selectors, carrying the samples from from to to at the resolution.
+// A zero to skips reading samples.
+func (s *QueryService) loadSeries(ctx context.Context, selectors []*promql.VectorSelector, from, to time.Time, resolution time.Duration) ([]*promql.Series, error) {
+	metrics, err := s.metrics.ListAllMetrics(ctx)
+	if err != nil {
+		return nil, err
+	}
+	names := types.PrometheusNames(metrics)
+
+	var series []*promql.Series
+	for _, metric := range metrics {
+		id := types.MetricID{ID: metric.ID, Type: metric.Type}
+		_, metricLabels := types.ParseMetricName(metric.ID)
+		labels := map[string]string{promql.MetricNameLabel: names[id]}
+		labelNames := types.PrometheusLabelNames(metricLabels)
+		for name, value := range metricLabels {
+			labels[labelNames[name]] = value
+		}
+
+		matched := len(selectors) == 0
+		for _, selector := range selectors {
+			if selector.Matches(labels) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			continue
+		}
+
+		var samples []types.Sample
+		if !to.IsZero() {
+			history, err := s.metrics.ListSeries(ctx, id, from, to, resolution)
+			if err != nil {
+				return nil, err
+			}
+			samples = history.Samples
+		}
+		series = append(series, &promql.Series{Labels: labels, Samples: samples})
+	}
+
+	sort.Slice(series, func(i, j int) bool {
+		return types.FormatMetricName("", series[i].Labels) < types.FormatMetricName("", series[j].Labels)
+	})
+	return series, nil
+}
+
+func sortedKeys(set map[string]bool) []string {
+	keys := make([]string, 0, len(set))
+	for key := range set {
+		keys = append(keys, key)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// ErrInvalidQuery is returned for queries and selectors that cannot be parsed or evaluated.
+var ErrInvalidQuery = errors.New("invalid query")
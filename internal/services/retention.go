@@ -0,0 +1,133 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+	"sync"
+	"time"
+)
+
+// MinRetention is the shortest retention enforced, longer than RollupBackfill by the coarsest
+// resolution so that rolling up again after a restart never reads partly deleted buckets.
+const MinRetention = RollupBackfill + time.Hour
+
+// RetentionRepository defines the deletions used to enforce the retention.
+type RetentionRepository interface {
+	DeleteSamples(ctx context.Context, cutoff types.RetentionCutoff) (int64, error)
+	DeleteRollups(ctx context.Context, resolution time.Duration, cutoff types.RetentionCutoff) (int64, error)
+}
+
+// RetentionService deletes the metric history older than the retention configured for the metric.
+type RetentionService struct {
+	repo           RetentionRepository
+	config         *configs.RetentionConfig
+	lastCompaction *types.CompactionStats
+	mu             sync.RWMutex
+}
+
+func NewRetentionService(repo RetentionRepository, config *configs.RetentionConfig) (*RetentionService, error) {
+	policies := []configs.RetentionPolicy{config.Default}
+	for _, policy := range config.Metrics {
+		policies = append(policies, policy.RetentionPolicy)
+	}
+	for _, policy := range policies {
+		for resolution := range policy.Rollups {
+			if !isRollupResolution(time.Duration(resolution)) {
+				return nil, fmt.Errorf("retention of %s rollups: resolution is not rolled up", resolution)
+			}
+		}
+	}
+
+	return &RetentionService{repo: repo, config: config}, nil
+}
+
+// Compact deletes the expired samples and rollups and records the statistics of the run.
+func (s *RetentionService) Compact(ctx context.Context, now time.Time) error {
+	stats := &types.CompactionStats{StartedAt: now, RollupsDeleted: make(map[string]int64)}
+	err := s.compact(ctx, now, stats)
+	stats.FinishedAt = time.Now()
+	if err != nil {
+		stats.Error = err.Error()
+	}
+
+	s.mu.Lock()
+	s.lastCompaction = stats
+	s.mu.Unlock()
+
+	return err
+}
+
+func (s *RetentionService) compact(ctx context.Context, now time.Time, stats *types.CompactionStats) error {
+	cutoff := s.cutoff(now, func(policy configs.RetentionPolicy) configs.Duration { return policy.Raw })
+	if !cutoff.Latest.IsZero() {
+		deleted, err := s.repo.DeleteSamples(ctx, cutoff)
+		stats.SamplesDeleted = deleted
+		if err != nil {
+			return err
+		}
+	}
+
+	for _, resolution := range RollupResolutions {
+		key := configs.Duration(resolution)
+		cutoff := s.cutoff(now, func(policy configs.RetentionPolicy) configs.Duration { return policy.Rollups[key] })
+		if cutoff.Latest.IsZero() {
+			continue
+		}
+		deleted, err := s.repo.DeleteRollups(ctx, resolution, cutoff)
+		stats.RollupsDeleted[key.String()] = deleted
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// cutoff derives the expiry of every metric from the retention selected from its policy.
+// Latest is zero when nothing expires.
+func (s *RetentionService) cutoff(now time.Time, retention func(policy configs.RetentionPolicy) configs.Duration) types.RetentionCutoff {
+	expiry := func(policy configs.RetentionPolicy) time.Time {
+		duration := time.Duration(retention(policy))
+		if duration == 0 {
+			return time.Time{}
+		}
+		return now.Add(-max(duration, MinRetention))
+	}
+
+	// The metric policies only override the retentions they set
+	var latest time.Time
+	policies := []configs.RetentionPolicy{s.config.Default}
+	for _, policy := range s.config.Metrics {
+		policies = append(policies, policy.RetentionPolicy)
+	}
+	for _, policy := range policies {
+		if before := expiry(policy); before.After(latest) {
+			latest = before
+		}
+	}
+
+	return types.RetentionCutoff{
+		Latest: latest,
+		Before: func(id types.MetricID) time.Time { return expiry(s.config.Policy(id.ID)) },
+	}
+}
+
+// RetentionSettings returns the retention config and the statistics of the last compaction,
+// nil before the first one.
+func (s *RetentionService) RetentionSettings(ctx context.Context) (*configs.RetentionConfig, *types.CompactionStats, error) {
+	s.mu.RLock()
+	defer s.mu.RUnlock()
+
+	return s.config, s.lastCompaction, nil
+}
+
+func isRollupResolution(resolution time.Duration) bool {
+	for _, candidate := range RollupResolutions {
+		if candidate == resolution {
+			return true
+		}
+	}
+	return false
+}
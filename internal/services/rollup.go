@@ -0,0 +1,319 @@
+package services
+
+import (
+	"context"
+	"fmt"
+	"go-metrics-alerting/internal/types"
+	"math"
+	"time"
+)
+
+// RollupResolutions are the resolutions the history is aggregated into, finest first.
+// The finest resolution is built from raw samples and every other one from the rollups
+// of the previous resolution, so each must be a multiple of the previous one.
+var RollupResolutions = []time.Duration{time.Minute, time.Hour}
+
+const (
+	// RollupDelay is how long after its end a bucket is rolled up, leaving time for late writes
+	RollupDelay = 10 * time.Second
+
+	// RollupBackfill is how far back the first rollup after a start reaches
+	RollupBackfill = 24 * time.Hour
+
+	// RollupBaseline is how far before a bucket the last counter total is looked up,
+	// so that the increase of the bucket includes the growth up to its first sample
+	RollupBaseline = 10 * time.Minute
+
+	// MaxSeriesPoints is the number of points a series read without a step aims for
+	MaxSeriesPoints = 1000
+)
+
+// RollupSamples aggregates the buckets of every resolution that ended since the last run.
+// The first run after a start backfills the buckets of the last RollupBackfill not stored yet.
+func (s *MetricService) RollupSamples(ctx context.Context, now time.Time) error {
+	metrics, err := s.repo.ListMetrics(ctx)
+	if err != nil {
+		return err
+	}
+
+	s.rollupMu.Lock()
+	defer s.rollupMu.Unlock()
+
+	backfill := now.Add(-RollupDelay - RollupBackfill)
+	for i, resolution := range RollupResolutions {
+		from, exists := s.rollupWatermarks[resolution]
+		if !exists {
+			from = ceilTime(backfill, resolution)
+		}
+
+		// Coarser buckets are only rolled up once all of their finer buckets are
+		to := now.Add(-RollupDelay).Truncate(resolution)
+		if i > 0 {
+			if finer := s.rollupWatermarks[RollupResolutions[i-1]].Truncate(resolution); finer.Before(to) {
+				to = finer
+			}
+		}
+		if !from.Before(to) {
+			continue
+		}
+
+		var rollups []*types.MetricRollup
+		for _, metric := range metrics {
+			id := types.MetricID{ID: metric.ID, Type: metric.Type}
+
+			var metricRollups []*types.MetricRollup
+			if i == 0 {
+				metricRollups, err = s.rollupRawSamples(ctx, id, resolution, from, to)
+			} else {
+				metricRollups, err = s.rollupFinerRollups(ctx, id, RollupResolutions[i-1], resolution, from, to)
+			}
+			if err != nil {
+				return err
+			}
+
+			// The first run after a start reaches back over buckets rolled up before: rolling them up
+			// again would duplicate them or, once their samples expired, replace them with partial ones
+			if !exists {
+				metricRollups, err = s.withoutStoredRollups(ctx, id, resolution, metricRollups)
+				if err != nil {
+					return err
+				}
+			}
+			rollups = append(rollups, metricRollups...)
+		}
+
+		if len(rollups) > 0 {
+			if err := s.repo.SaveRollups(ctx, rollups); err != nil {
+				return err
+			}
+		}
+		s.rollupWatermarks[resolution] = to
+	}
+
+	return nil
+}
+
+// withoutStoredRollups drops the rollups of the metric whose buckets are already stored.
+func (s *MetricService) withoutStoredRollups(ctx context.Context, id types.MetricID, resolution time.Duration, rollups []*types.MetricRollup) ([]*types.MetricRollup, error) {
+	if len(rollups) == 0 {
+		return nil, nil
+	}
+	stored, err := s.repo.ListRollups(ctx, id, resolution, rollups[0].Start, rollups[len(rollups)-1].Start)
+	if err != nil {
+		return nil, err
+	}
+
+	existing := make(map[int64]bool, len(stored))
+	for _, rollup := range stored {
+		existing[rollup.Start.UnixNano()] = true
+	}
+	var missing []*types.MetricRollup
+	for _, rollup := range rollups {
+		if !existing[rollup.Start.UnixNano()] {
+			missing = append(missing, rollup)
+		}
+	}
+	return missing, nil
+}
+
+// rollupRawSamples aggregates the raw samples of the metric into the buckets from from to to.
+func (s *MetricService) rollupRawSamples(ctx context.Context, id types.MetricID, resolution time.Duration, from, to time.Time) ([]*types.MetricRollup, error) {
+	samples, err := s.repo.ListSamples(ctx, id, from.Add(-RollupBaseline), to)
+	if err != nil {
+		return nil, err
+	}
+
+	var rollups []*types.MetricRollup
+	var current *types.MetricRollup
+	var bucket []types.Sample // Samples of the current bucket, preceded by the last earlier sample
+	var baseline []types.Sample
+
+	finish := func() {
+		if current != nil && current.Type == string(types.Counter) {
+			increase := counterIncrease(bucket)
+			current.Increase = &increase
+		}
+	}
+
+	for _, sample := range samples {
+		if !sample.Timestamp.Before(to) {
+			break
+		}
+		if sample.Timestamp.Before(from) {
+			baseline = []types.Sample{sample}
+			continue
+		}
+
+		start := sample.Timestamp.Truncate(resolution)
+		if current == nil || !current.Start.Equal(start) {
+			finish()
+			if len(bucket) > 0 {
+				baseline = bucket[len(bucket)-1:]
+			}
+			current = &types.MetricRollup{
+				ID:         id.ID,
+				Type:       id.Type,
+				Resolution: int64(resolution / time.Second),
+				Start:      start,
+				Min:        math.Inf(1),
+				Max:        math.Inf(-1),
+			}
+			bucket = append([]types.Sample(nil), baseline...)
+			rollups = append(rollups, current)
+		}
+
+		current.Min = math.Min(current.Min, sample.Value)
+		current.Max = math.Max(current.Max, sample.Value)
+		current.Sum += sample.Value
+		current.Last = sample.Value
+		current.Count++
+		current.Avg = current.Sum / float64(current.Count)
+		bucket = append(bucket, sample)
+	}
+	finish()
+
+	return rollups, nil
+}
+
+// rollupFinerRollups combines the rollups of the metric at the finer resolution into
+// the buckets from from to to.
+func (s *MetricService) rollupFinerRollups(ctx context.Context, id types.MetricID, finer, resolution time.Duration, from, to time.Time) ([]*types.MetricRollup, error) {
+	parts, err := s.repo.ListRollups(ctx, id, finer, from, to.Add(-finer))
+	if err != nil {
+		return nil, err
+	}
+
+	var rollups []*types.MetricRollup
+	var current *types.MetricRollup
+	for _, part := range parts {
+		start := part.Start.Truncate(resolution)
+		if current == nil || !current.Start.Equal(start) {
+			current = &types.MetricRollup{
+				ID:         id.ID,
+				Type:       id.Type,
+				Resolution: int64(resolution / time.Second),
+				Start:      start,
+				Min:        part.Min,
+				Max:        part.Max,
+			}
+			if part.Increase != nil {
+				current.Increase = new(float64)
+			}
+			rollups = append(rollups, current)
+		}
+
+		current.Min = math.Min(current.Min, part.Min)
+		current.Max = math.Max(current.Max, part.Max)
+		current.Sum += part.Sum
+		current.Last = part.Last
+		current.Count += part.Count
+		current.Avg = current.Sum / float64(current.Count)
+		if current.Increase != nil && part.Increase != nil {
+			*current.Increase += *part.Increase
+		}
+	}
+
+	return rollups, nil
+}
+
+// RollupResolution returns the coarsest rollup resolution not exceeding the step,
+// or 0 when raw samples are needed.
+func RollupResolution(step time.Duration) time.Duration {
+	var resolution time.Duration
+	for _, candidate := range RollupResolutions {
+		if candidate <= step {
+			resolution = candidate
+		}
+	}
+	return resolution
+}
+
+// QuerySeries returns the history of the metric from from to to at the resolution
+// fitting the step. Without a step the resolution is picked so that the range holds
+// about MaxSeriesPoints points.
+func (s *MetricService) QuerySeries(ctx context.Context, id types.MetricID, from, to time.Time, step time.Duration) (*types.MetricSeries, error) {
+	if to.Before(from) {
+		return nil, fmt.Errorf("%w: from is after to", ErrInvalidSampleRange)
+	}
+	if step < 0 {
+		return nil, fmt.Errorf("%w: negative step", ErrInvalidSampleRange)
+	}
+	if step == 0 {
+		step = to.Sub(from) / MaxSeriesPoints
+	}
+	return s.ListSeries(ctx, id, from, to, RollupResolution(step))
+}
+
+// ListSeries returns the history of the metric from from to to at the resolution, which
+// is 0 for raw samples or one of RollupResolutions. Rollups are represented by their value
+// at the end of their bucket; the time after the last rollup, which was not rolled up yet,
+// is filled from the finer resolutions.
+func (s *MetricService) ListSeries(ctx context.Context, id types.MetricID, from, to time.Time, resolution time.Duration) (*types.MetricSeries, error) {
+	level := -1
+	for i, candidate := range RollupResolutions {
+		if candidate == resolution {
+			level = i
+		}
+	}
+	if level < 0 && resolution != 0 {
+		return nil, fmt.Errorf("%w: unsupported resolution %s", ErrInvalidSampleRange, resolution)
+	}
+
+	samples, rollups, err := s.listSeriesSamples(ctx, id, from, to, level)
+	if err != nil {
+		return nil, err
+	}
+	return &types.MetricSeries{
+		ID:         id.ID,
+		Type:       id.Type,
+		Resolution: int64(resolution / time.Second),
+		Samples:    samples,
+		Rollups:    rollups,
+	}, nil
+}
+
+// listSeriesSamples returns the samples from from to to built from the rollups of the
+// resolution at the level, returned as well, and after the last of them from the finer levels.
+func (s *MetricService) listSeriesSamples(ctx context.Context, id types.MetricID, from, to time.Time, level int) ([]types.Sample, []*types.MetricRollup, error) {
+	if level < 0 {
+		samples, err := s.repo.ListSamples(ctx, id, from, to)
+		return samples, nil, err
+	}
+
+	resolution := RollupResolutions[level]
+	rollups, err := s.repo.ListRollups(ctx, id, resolution, from, to.Add(-resolution))
+	if err != nil {
+		return nil, nil, err
+	}
+
+	samples := make([]types.Sample, 0, len(rollups))
+	for _, rollup := range rollups {
+		samples = append(samples, types.Sample{Timestamp: rollup.End(), Value: rollup.Value()})
+	}
+
+	// Finer rollups starting at the end of the last rollup are represented after it, but a raw
+	// sample taken exactly then is left out so that timestamps stay unique
+	tailFrom := from
+	if len(rollups) > 0 {
+		tailFrom = rollups[len(rollups)-1].End()
+		if level == 0 {
+			tailFrom = tailFrom.Add(time.Nanosecond)
+		}
+	}
+	if tailFrom.After(to) {
+		return samples, rollups, nil
+	}
+	tail, _, err := s.listSeriesSamples(ctx, id, tailFrom, to, level-1)
+	if err != nil {
+		return nil, nil, err
+	}
+	return append(samples, tail...), rollups, nil
+}
+
+// ceilTime rounds the time up to a multiple of the duration.
+func ceilTime(t time.Time, d time.Duration) time.Time {
+	if truncated := t.Truncate(d); !truncated.Equal(t) {
+		return truncated.Add(d)
+	}
+	return t
+}
@@ -0,0 +1,91 @@
+package services
+
+import (
+	"context"
+	"go-metrics-alerting/internal/repositories"
+	"go-metrics-alerting/internal/types"
+	"testing"
+	"time"
+)
+
+// recordingRollupRepository records the rollups saved to the memory repository.
+type recordingRollupRepository struct {
+	*repositories.MetricMemoryRepository
+	saved []*types.MetricRollup
+}
+
+func (r *recordingRollupRepository) SaveRollups(ctx context.Context, rollups []*types.MetricRollup) error {
+	r.saved = append(r.saved, rollups...)
+	return r.MetricMemoryRepository.SaveRollups(ctx, rollups)
+}
+
+func TestMetricServiceRollupSamplesRestart(t *testing.T) {
+	ctx := context.Background()
+	start := time.Date(2024, 3, 1, 12, 0, 0, 0, time.UTC)
+	repo := &recordingRollupRepository{MetricMemoryRepository: repositories.NewMetricMemoryRepository()}
+
+	value := 1.0
+	if err := repo.SaveMetrics(ctx, []*types.Metrics{{ID: "Load", Type: string(types.Gauge), Value: &value}}); err != nil {
+		t.Fatalf("SaveMetrics() error = %v", err)
+	}
+	var samples []*types.MetricSample
+	for at := time.Duration(0); at < 5*time.Minute; at += 10 * time.Second {
+		samples = append(samples, &types.MetricSample{ID: "Load", Type: string(types.Gauge), Timestamp: start.Add(at), Value: float64(at / time.Second)})
+	}
+	if err := repo.SaveSamples(ctx, samples); err != nil {
+		t.Fatalf("SaveSamples() error = %v", err)
+	}
+
+	models := repositories.NewAnomalyModelMemoryRepository()
+	before := NewMetricService(repo, models)
+	after := NewMetricService(repo, models) // The same storage after a restart
+
+	steps := []struct {
+		name string
+		svc  *MetricService
+		now  time.Time
+		want []time.Duration // Starts of the minute rollups saved, relative to start
+	}{
+		{name: "first run", svc: before, now: start.Add(3*time.Minute + 30*time.Second), want: []time.Duration{0, time.Minute, 2 * time.Minute}},
+		{name: "first run after a restart", svc: after, now: start.Add(4*time.Minute + 30*time.Second), want: []time.Duration{3 * time.Minute}},
+		{name: "next run", svc: after, now: start.Add(5*time.Minute + 30*time.Second), want: []time.Duration{4 * time.Minute}},
+	}
+	for _, step := range steps {
+		repo.saved = nil
+		if err := step.svc.RollupSamples(ctx, step.now); err != nil {
+			t.Fatalf("%s: RollupSamples() error = %v", step.name, err)
+		}
+
+		var got []time.Duration
+		for _, rollup := range repo.saved {
+			if rollup.Resolution != int64(time.Minute/time.Second) {
+				t.Errorf("%s: saved a rollup at resolution %ds, want only minute rollups", step.name, rollup.Resolution)
+				continue
+			}
+			got = append(got, rollup.Start.Sub(start))
+		}
+		if len(got) != len(step.want) {
+			t.Errorf("%s: saved rollups starting at %v, want %v", step.name, got, step.want)
+			continue
+		}
+		for i := range got {
+			if got[i] != step.want[i] {
+				t.Errorf("%s: saved rollups starting at %v, want %v", step.name, got, step.want)
+				break
+			}
+		}
+	}
+
+	rollups, err := repo.ListRollups(ctx, types.MetricID{ID: "Load", Type: string(types.Gauge)}, time.Minute, start, start.Add(time.Hour))
+	if err != nil {
+		t.Fatalf("ListRollups() error = %v", err)
+	}
+	if len(rollups) != 5 {
+		t.Fatalf("stored rollups = %d, want 5", len(rollups))
+	}
+	for i, rollup := range rollups {
+		if rollup.Count != 6 || rollup.Min != float64(i*60) || rollup.Last != float64(i*60+50) {
+			t.Errorf("rollup %d = %+v, want the 6 samples of its minute", i, rollup)
+		}
+	}
+}
@@ -0,0 +1,123 @@
+package services
+
+import (
+	"fmt"
+	"go-metrics-alerting/internal/configs"
+	"go-metrics-alerting/internal/types"
+	"regexp"
+	"sort"
+	"strconv"
+)
+
+// RootRoutePath is the path of the root of the routing tree.
+const RootRoutePath = "root"
+
+// alertRoute is a node of the routing tree with compiled matchers.
+type alertRoute struct {
+	receiver string
+	match    map[string]string
+	matchRe  map[string]*regexp.Regexp
+	next     bool // Continue with the following sibling routes
+	routes   []*alertRoute
+}
+
+// newAlertRoute compiles the configured routing tree. The config is validated
+// when it is loaded, so the regular expressions are known to compile.
+func newAlertRoute(route *configs.Route) *alertRoute {
+	compiled := &alertRoute{
+		receiver: route.Receiver,
+		match:    route.Match,
+		matchRe:  make(map[string]*regexp.Regexp, len(route.MatchRe)),
+		next:     route.Continue,
+	}
+	for label, expr := range route.MatchRe {
+		compiled.matchRe[label] = regexp.MustCompile(configs.AnchorRegexp(expr))
+	}
+	for _, child := range route.Routes {
+		compiled.routes = append(compiled.routes, newAlertRoute(child))
+	}
+	return compiled
+}
+
+// matches reports whether the labels satisfy all matchers of the route.
+func (r *alertRoute) matches(labels map[string]string) bool {
+	if !matchLabels(r.match, labels) {
+		return false
+	}
+	for label, re := range r.matchRe {
+		if !re.MatchString(labels[label]) {
+			return false
+		}
+	}
+	return true
+}
+
+// walk returns the deepest routes the labels reach below this route.
+func (r *alertRoute) walk(path, receiver string, labels map[string]string) []types.AlertRouteMatch {
+	if r.receiver != "" {
+		receiver = r.receiver
+	}
+
+	var matches []types.AlertRouteMatch
+	for i, child := range r.routes {
+		if !child.matches(labels) {
+			continue
+		}
+		matches = append(matches, child.walk(path+"."+strconv.Itoa(i), receiver, labels)...)
+		if !child.next {
+			break
+		}
+	}
+	if len(matches) == 0 {
+		matches = []types.AlertRouteMatch{{Path: path, Receiver: receiver}}
+	}
+	return matches
+}
+
+// validateReceivers checks that every receiver named in the routing tree is configured.
+func (r *alertRoute) validateReceivers(receivers map[string]AlertNotifier) error {
+	if r.receiver != "" {
+		if _, exists := receivers[r.receiver]; !exists {
+			return fmt.Errorf("route receiver %q is not configured", r.receiver)
+		}
+	}
+	for _, child := range r.routes {
+		if err := child.validateReceivers(receivers); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// RouteAlert returns the routes an alert with the labels is delivered through.
+// Without a routing tree the alert goes to every configured receiver.
+func (s *NotificationService) RouteAlert(labels map[string]string) []types.AlertRouteMatch {
+	if s.route != nil {
+		return s.route.walk(RootRoutePath, "", labels)
+	}
+
+	names := make([]string, 0, len(s.receivers))
+	for name := range s.receivers {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	matches := make([]types.AlertRouteMatch, 0, len(names))
+	for _, name := range names {
+		matches = append(matches, types.AlertRouteMatch{Path: RootRoutePath, Receiver: name})
+	}
+	return matches
+}
+
+// routeReceivers returns the distinct receivers of the alert.
+func (s *NotificationService) routeReceivers(labels map[string]string) []string {
+	seen := make(map[string]bool)
+	var receivers []string
+	for _, match := range s.RouteAlert(labels) {
+		if !seen[match.Receiver] {
+			seen[match.Receiver] = true
+			receivers = append(receivers, match.Receiver)
+		}
+	}
+	return receivers
+}
@@ -0,0 +1,239 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"go-metrics-alerting/internal/types"
+	"path"
+	"strings"
+	"time"
+)
+
+// SilenceRepository defines the storage of silences.
+type SilenceRepository interface {
+	SaveSilence(ctx context.Context, silence *types.Silence) error
+	GetSilenceByID(ctx context.Context, id string) (*types.Silence, error)
+	ListSilences(ctx context.Context) ([]*types.Silence, error)
+	DeleteSilence(ctx context.Context, id string) error
+}
+
+type SilenceService struct {
+	repo SilenceRepository
+}
+
+func NewSilenceService(repo SilenceRepository) *SilenceService {
+	return &SilenceService{repo: repo}
+}
+
+// CreateSilence validates and stores a new silence.
+func (s *SilenceService) CreateSilence(ctx context.Context, silence *types.Silence) (*types.Silence, error) {
+	id, err := newID()
+	if err != nil {
+		return nil, err
+	}
+	silence.ID = id
+	silence.CreatedAt = time.Now()
+	if silence.StartsAt.IsZero() {
+		silence.StartsAt = silence.CreatedAt
+	}
+
+	if err := ValidateSilence(silence); err != nil {
+		return nil, err
+	}
+
+	if err := s.repo.SaveSilence(ctx, silence); err != nil {
+		return nil, err
+	}
+
+	return silence, nil
+}
+
+// GetSilence fetches a silence by its ID.
+func (s *SilenceService) GetSilence(ctx context.Context, id string) (*types.Silence, error) {
+	silence, err := s.repo.GetSilenceByID(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if silence == nil {
+		return nil, ErrSilenceNotFound
+	}
+
+	return silence, nil
+}
+
+// ListSilences returns all the silences in the repository.
+func (s *SilenceService) ListSilences(ctx context.Context) ([]*types.Silence, error) {
+	return s.repo.ListSilences(ctx)
+}
+
+// DeleteSilence removes an existing silence.
+func (s *SilenceService) DeleteSilence(ctx context.Context, id string) error {
+	if _, err := s.GetSilence(ctx, id); err != nil {
+		return err
+	}
+
+	return s.repo.DeleteSilence(ctx, id)
+}
+
+// isSilenced reports whether any of the silences active at the given time matches the alert of the rule.
+func isSilenced(silences []*types.Silence, rule *types.AlertRule, now time.Time) bool {
+	for _, silence := range silences {
+		if matchSilence(silence, rule) && silenceActive(silence, now) {
+			return true
+		}
+	}
+	return false
+}
+
+// ValidateSilence checks the time range, matchers and schedule of the silence.
+func ValidateSilence(silence *types.Silence) error {
+	if silence.CreatedBy == "" {
+		return fmt.Errorf("%w: missing created_by", ErrInvalidSilence)
+	}
+	if silence.Schedule == nil && silence.EndsAt == nil {
+		return fmt.Errorf("%w: ends_at is required without a schedule", ErrInvalidSilence)
+	}
+	if silence.EndsAt != nil && !silence.EndsAt.After(silence.StartsAt) {
+		return fmt.Errorf("%w: ends_at must be after starts_at", ErrInvalidSilence)
+	}
+	if _, err := path.Match(silence.RuleID, ""); err != nil {
+		return fmt.Errorf("%w: invalid rule pattern %q", ErrInvalidSilence, silence.RuleID)
+	}
+	if _, err := path.Match(silence.Metric.ID, ""); err != nil {
+		return fmt.Errorf("%w: invalid metric pattern %q", ErrInvalidSilence, silence.Metric.ID)
+	}
+
+	if schedule := silence.Schedule; schedule != nil {
+		for _, day := range schedule.Weekdays {
+			if _, ok := weekdays[strings.ToLower(day)]; !ok {
+				return fmt.Errorf("%w: unknown weekday %q", ErrInvalidSilence, day)
+			}
+		}
+		if _, err := parseClock(schedule.Start); err != nil {
+			return fmt.Errorf("%w: invalid schedule start: %v", ErrInvalidSilence, err)
+		}
+		if _, err := parseClock(schedule.End); err != nil {
+			return fmt.Errorf("%w: invalid schedule end: %v", ErrInvalidSilence, err)
+		}
+		if _, err := time.LoadLocation(schedule.Timezone); err != nil {
+			return fmt.Errorf("%w: invalid schedule timezone: %v", ErrInvalidSilence, err)
+		}
+	}
+
+	return nil
+}
+
+// matchSilence reports whether the matchers of the silence select the rule.
+func matchSilence(silence *types.Silence, rule *types.AlertRule) bool {
+	if silence.RuleID != "" {
+		if matched, err := path.Match(silence.RuleID, rule.ID); err != nil || !matched {
+			return false
+		}
+	}
+	if silence.Metric.ID != "" {
+		if matched, err := path.Match(silence.Metric.ID, rule.Metric.ID); err != nil || !matched {
+			return false
+		}
+	}
+	if silence.Metric.Type != "" && silence.Metric.Type != rule.Metric.Type {
+		return false
+	}
+	return matchLabels(silence.Labels, rule.Labels)
+}
+
+// silenceActive reports whether the silence mutes notifications at the given time.
+func silenceActive(silence *types.Silence, now time.Time) bool {
+	if now.Before(silence.StartsAt) {
+		return false
+	}
+	if silence.EndsAt != nil && !now.Before(*silence.EndsAt) {
+		return false
+	}
+	if silence.Schedule == nil {
+		return true
+	}
+	return windowActive(silence.Schedule, now)
+}
+
+// windowActive reports whether the recurring maintenance window covers the given time.
+func windowActive(window *types.MaintenanceWindow, now time.Time) bool {
+	location, err := time.LoadLocation(window.Timezone)
+	if err != nil {
+		return false
+	}
+	start, err := parseClock(window.Start)
+	if err != nil {
+		return false
+	}
+	end, err := parseClock(window.End)
+	if err != nil {
+		return false
+	}
+
+	local := now.In(location)
+	midnight := time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location)
+
+	// Check the window started today and, for windows spanning midnight, the one started yesterday
+	for _, dayStart := range []time.Time{midnight, midnight.AddDate(0, 0, -1)} {
+		if !windowOnDay(window, dayStart.Weekday()) {
+			continue
+		}
+		from := clockOn(dayStart, start)
+		to := clockOn(dayStart, end)
+		if end <= start {
+			to = clockOn(dayStart.AddDate(0, 0, 1), end)
+		}
+		if !local.Before(from) && local.Before(to) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// clockOn returns the wall clock time of the day, which is not a fixed offset from midnight
+// on the days daylight saving time starts or ends.
+func clockOn(day time.Time, clock time.Duration) time.Time {
+	hour, minute := int(clock/time.Hour), int(clock%time.Hour/time.Minute)
+	return time.Date(day.Year(), day.Month(), day.Day(), hour, minute, 0, 0, day.Location())
+}
+
+// windowOnDay reports whether the window starts on the given weekday.
+func windowOnDay(window *types.MaintenanceWindow, day time.Weekday) bool {
+	if len(window.Weekdays) == 0 {
+		return true
+	}
+	for _, name := range window.Weekdays {
+		if weekdays[strings.ToLower(name)] == day {
+			return true
+		}
+	}
+	return false
+}
+
+// parseClock parses HH:MM into the offset from midnight.
+func parseClock(value string) (time.Duration, error) {
+	t, err := time.Parse("15:04", value)
+	if err != nil {
+		return 0, err
+	}
+	return time.Duration(t.Hour())*time.Hour + time.Duration(t.Minute())*time.Minute, nil
+}
+
+var weekdays = map[string]time.Weekday{
+	"sunday":    time.Sunday,
+	"monday":    time.Monday,
+	"tuesday":   time.Tuesday,
+	"wednesday": time.Wednesday,
+	"thursday":  time.Thursday,
+	"friday":    time.Friday,
+	"saturday":  time.Saturday,
+}
+
+var (
+	// ErrInvalidSilence is returned when a silence fails validation.
+	ErrInvalidSilence = errors.New("invalid silence")
+	// ErrSilenceNotFound is returned when a silence with the given ID does not exist.
+	ErrSilenceNotFound = errors.New("silence not found")
+)
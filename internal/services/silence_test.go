@@ -0,0 +1,49 @@
+package services
+
+import (
+	"go-metrics-alerting/internal/types"
+	"testing"
+	"time"
+)
+
+func TestWindowActive(t *testing.T) {
+	newYork, err := time.LoadLocation("America/New_York")
+	if err != nil {
+		t.Skipf("time zone data not available: %v", err)
+	}
+	at := func(year int, month time.Month, day, hour, minute int) time.Time {
+		return time.Date(year, month, day, hour, minute, 0, 0, newYork)
+	}
+
+	business := &types.MaintenanceWindow{Start: "09:00", End: "17:00", Timezone: "America/New_York"}
+	overnight := &types.MaintenanceWindow{Weekdays: []string{"saturday"}, Start: "22:00", End: "06:00", Timezone: "America/New_York"}
+
+	tests := []struct {
+		name   string
+		window *types.MaintenanceWindow
+		now    time.Time
+		want   bool
+	}{
+		{name: "inside", window: business, now: at(2024, time.March, 5, 9, 30), want: true},
+		{name: "before the start", window: business, now: at(2024, time.March, 5, 8, 59), want: false},
+		{name: "at the end", window: business, now: at(2024, time.March, 5, 17, 0), want: false},
+		// Midnight plus 9 hours is 10:00 on the day clocks spring forward and 08:00 when they fall back
+		{name: "start on the day DST starts", window: business, now: at(2024, time.March, 10, 9, 30), want: true},
+		{name: "end on the day DST starts", window: business, now: at(2024, time.March, 10, 17, 30), want: false},
+		{name: "before the start on the day DST ends", window: business, now: at(2024, time.November, 3, 8, 30), want: false},
+		{name: "end on the day DST ends", window: business, now: at(2024, time.November, 3, 16, 30), want: true},
+		{name: "overnight on the start day", window: overnight, now: at(2024, time.March, 9, 23, 0), want: true},
+		{name: "overnight across the DST change", window: overnight, now: at(2024, time.March, 10, 5, 30), want: true},
+		{name: "overnight after the end", window: overnight, now: at(2024, time.March, 10, 6, 0), want: false},
+		{name: "overnight on another day", window: overnight, now: at(2024, time.March, 10, 23, 0), want: false},
+		{name: "converted to the window time zone", window: business, now: time.Date(2024, time.March, 5, 14, 30, 0, 0, time.UTC), want: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := windowActive(tt.window, tt.now); got != tt.want {
+				t.Errorf("windowActive() at %s = %v, want %v", tt.now, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,252 @@
+package templates
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"go-metrics-alerting/internal/types"
+	"io"
+	"math"
+	"strings"
+	"text/template"
+	"time"
+)
+
+// Built-in templates that can be referenced by name instead of template text.
+const (
+	// Short is a single line suitable for chat messages.
+	Short = `[{{.State | upper}}] {{.Rule.ID}}: {{.MetricType}}/{{.MetricID}} = {{if .Value}}{{.Value}}{{else}}{{.Delta}}{{end}}`
+
+	// Long is a multi line description suitable for emails.
+	Long = `Rule: {{.Rule.ID}}
+Metric: {{.MetricType}}/{{.MetricID}}
+State: {{.PreviousState}} -> {{.State}}
+Value: {{if .Value}}{{.Value}}{{else}}{{.Delta}}{{end}}
+{{range $name, $value := .Labels}}Label {{$name}}: {{$value}}
+{{end}}{{with .ActiveAt}}Active since: {{formatTime .}}
+{{end}}{{with .FiredAt}}Fired at: {{formatTime .}}
+{{end}}{{with .ResolvedAt}}Resolved at: {{formatTime .}}
+{{end}}`
+
+	// JSON is a machine readable document suitable for ticketing systems.
+	JSON = `{{toJSON .}}`
+)
+
+var builtins = map[string]string{
+	"short": Short,
+	"long":  Long,
+	"json":  JSON,
+}
+
+// Data is the view of an alert event available to notification templates.
+type Data struct {
+	Rule          *types.AlertRule  `json:"rule"`
+	MetricID      string            `json:"metric_id"`
+	MetricType    string            `json:"metric_type"`
+	Value         *float64          `json:"value,omitempty"`
+	Delta         *int64            `json:"delta,omitempty"`
+	Labels        map[string]string `json:"labels,omitempty"`
+	State         types.AlertState  `json:"state"`
+	PreviousState types.AlertState  `json:"previous_state"`
+	ActiveAt      *time.Time        `json:"active_at,omitempty"`
+	FiredAt       *time.Time        `json:"fired_at,omitempty"`
+	ResolvedAt    *time.Time        `json:"resolved_at,omitempty"`
+	Timestamp     time.Time         `json:"timestamp"`
+}
+
+// NewData builds the template data from an alert event.
+func NewData(event *types.AlertEvent) Data {
+	data := Data{
+		Rule:          event.Rule,
+		MetricID:      event.Alert.Metric.ID,
+		MetricType:    event.Alert.Metric.Type,
+		Labels:        event.Alert.Labels,
+		State:         event.Alert.State,
+		PreviousState: event.PreviousState,
+		ActiveAt:      event.Alert.ActiveAt,
+		FiredAt:       event.Alert.FiredAt,
+		ResolvedAt:    event.Alert.ResolvedAt,
+		Timestamp:     event.Timestamp,
+	}
+
+	// Expose the value in the same shape as types.Metrics, except for the rate and increase
+	// of counters which are fractional
+	value := event.Alert.Value
+	if event.Alert.Metric.Type == string(types.Counter) && !isCounterRuleKind(event.Rule) {
+		delta := int64(value)
+		data.Delta = &delta
+	} else {
+		data.Value = &value
+	}
+
+	return data
+}
+
+// isCounterRuleKind tells whether the rule evaluates the growth of a counter rather than its value.
+func isCounterRuleKind(rule *types.AlertRule) bool {
+	return rule != nil && (rule.Kind == types.RuleRate || rule.Kind == types.RuleIncrease)
+}
+
+// Parse parses a notification template. The text may also be the name of a built-in template.
+func Parse(name, text string) (*template.Template, error) {
+	if builtin, ok := builtins[text]; ok {
+		text = builtin
+	}
+	return template.New(name).Funcs(FuncMap()).Parse(text)
+}
+
+// Validate parses the template and renders it against sample data to catch unknown fields early.
+func Validate(name, text string) error {
+	tmpl, err := Parse(name, text)
+	if err != nil {
+		return err
+	}
+
+	value := 1.0
+	now := time.Now()
+	sample := Data{
+		Rule:          &types.AlertRule{ID: "sample"},
+		MetricID:      "sample",
+		MetricType:    string(types.Gauge),
+		Value:         &value,
+		Labels:        map[string]string{},
+		State:         types.AlertFiring,
+		PreviousState: types.AlertPending,
+		ActiveAt:      &now,
+		FiredAt:       &now,
+		Timestamp:     now,
+	}
+
+	return tmpl.Execute(io.Discard, sample)
+}
+
+// Render executes the template with the data of the event.
+func Render(tmpl *template.Template, event *types.AlertEvent) (string, error) {
+	var buf bytes.Buffer
+	if err := tmpl.Execute(&buf, NewData(event)); err != nil {
+		return "", err
+	}
+	return buf.String(), nil
+}
+
+// FuncMap returns the helper functions available to notification templates.
+func FuncMap() template.FuncMap {
+	return template.FuncMap{
+		"humanize":       humanize,
+		"humanizeBytes":  humanizeBytes,
+		"formatDuration": formatDuration,
+		"formatTime":     formatTime,
+		"since":          since,
+		"toJSON":         toJSON,
+		"upper":          func(v interface{}) string { return strings.ToUpper(fmt.Sprint(v)) },
+		"lower":          func(v interface{}) string { return strings.ToLower(fmt.Sprint(v)) },
+		"join":           strings.Join,
+	}
+}
+
+// humanize formats a number with SI suffixes, e.g. 1234567 -> 1.235M.
+func humanize(v interface{}) (string, error) {
+	f, err := toFloat(v)
+	if err != nil {
+		return "", err
+	}
+
+	suffixes := []string{"", "k", "M", "G", "T", "P", "E"}
+	i := 0
+	for math.Abs(f) >= 1000 && i < len(suffixes)-1 {
+		f /= 1000
+		i++
+	}
+	return fmt.Sprintf("%.4g%s", f, suffixes[i]), nil
+}
+
+// humanizeBytes formats a number of bytes with binary suffixes, e.g. 1536 -> 1.5KiB.
+func humanizeBytes(v interface{}) (string, error) {
+	f, err := toFloat(v)
+	if err != nil {
+		return "", err
+	}
+
+	suffixes := []string{"B", "KiB", "MiB", "GiB", "TiB", "PiB", "EiB"}
+	i := 0
+	for math.Abs(f) >= 1024 && i < len(suffixes)-1 {
+		f /= 1024
+		i++
+	}
+	return fmt.Sprintf("%.4g%s", f, suffixes[i]), nil
+}
+
+// formatDuration formats a time.Duration or a number of seconds, e.g. 90 -> 1m30s.
+func formatDuration(v interface{}) (string, error) {
+	if d, ok := v.(time.Duration); ok {
+		return d.String(), nil
+	}
+
+	f, err := toFloat(v)
+	if err != nil {
+		return "", err
+	}
+	return time.Duration(f * float64(time.Second)).Round(time.Millisecond).String(), nil
+}
+
+// formatTime formats a time in RFC3339, accepting both values and pointers.
+func formatTime(v interface{}) string {
+	switch t := v.(type) {
+	case time.Time:
+		return t.Format(time.RFC3339)
+	case *time.Time:
+		if t != nil {
+			return t.Format(time.RFC3339)
+		}
+	}
+	return ""
+}
+
+// since returns the time elapsed since the given time rounded to seconds.
+func since(v interface{}) time.Duration {
+	switch t := v.(type) {
+	case time.Time:
+		return time.Since(t).Round(time.Second)
+	case *time.Time:
+		if t != nil {
+			return time.Since(*t).Round(time.Second)
+		}
+	}
+	return 0
+}
+
+// toJSON marshals the value into a JSON string.
+func toJSON(v interface{}) (string, error) {
+	data, err := json.Marshal(v)
+	if err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// toFloat converts numbers and pointers to numbers into float64.
+func toFloat(v interface{}) (float64, error) {
+	switch n := v.(type) {
+	case float64:
+		return n, nil
+	case *float64:
+		if n != nil {
+			return *n, nil
+		}
+		return 0, nil
+	case int64:
+		return float64(n), nil
+	case *int64:
+		if n != nil {
+			return float64(*n), nil
+		}
+		return 0, nil
+	case int:
+		return float64(n), nil
+	case uint64:
+		return float64(n), nil
+	case time.Duration:
+		return n.Seconds(), nil
+	}
+	return 0, fmt.Errorf("expected a number, got %T", v)
+}
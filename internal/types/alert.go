@@ -0,0 +1,122 @@
+package types
+
+import "time"
+
+type AlertOperator string
+
+const (
+	OperatorGreater      AlertOperator = ">"
+	OperatorGreaterEqual AlertOperator = ">="
+	OperatorLess         AlertOperator = "<"
+	OperatorLessEqual    AlertOperator = "<="
+	OperatorEqual        AlertOperator = "=="
+	OperatorNotEqual     AlertOperator = "!="
+)
+
+type AlertRuleKind string
+
+const (
+	// RuleThreshold compares the current metric value with the threshold.
+	RuleThreshold AlertRuleKind = "threshold"
+	// RuleAbsent fires when no metric matching the pattern was updated for StaleAfter seconds.
+	RuleAbsent AlertRuleKind = "absent"
+	// RuleRate compares the per-second rate of a counter over Window seconds with the threshold.
+	RuleRate AlertRuleKind = "rate"
+	// RuleIncrease compares the increase of a counter over Window seconds with the threshold.
+	RuleIncrease AlertRuleKind = "increase"
+	// RuleAnomaly fires when the absolute z-score of a gauge against its moving mean and
+	// variance reaches the threshold for Samples consecutive samples.
+	RuleAnomaly AlertRuleKind = "anomaly"
+	// RuleExpression fires when the boolean expression Expr over several metrics holds.
+	RuleExpression AlertRuleKind = "expression"
+)
+
+type AlertRule struct {
+	ID         string        `json:"id"`
+	Kind       AlertRuleKind `json:"kind,omitempty"`     // Defaults to RuleThreshold
+	Metric     MetricID      `json:"metric"`             // For absent rules the ID may be a glob and the type may be empty
+	Expr       string        `json:"expr,omitempty"`     // Condition of expression rules, e.g. "gauge:HeapInuse / gauge:HeapSys > 0.9"
+	Severity   string        `json:"severity,omitempty"` // Added to the alert labels, e.g. "critical" or "warning"
+	Operator   AlertOperator `json:"operator,omitempty"`
+	Threshold  float64       `json:"threshold"`
+	Interval   int64         `json:"interval"`              // Evaluation interval in seconds
+	For        int64         `json:"for"`                   // Seconds the condition must hold before firing
+	StaleAfter int64         `json:"stale_after,omitempty"` // Seconds without updates before an absent rule fires
+	Window     int64         `json:"window,omitempty"`      // Sliding window in seconds of rate and increase rules
+	Samples    int64         `json:"samples,omitempty"`     // Consecutive anomalous samples before an anomaly rule matches
+
+	Labels    map[string]string `json:"labels,omitempty"`
+	Templates map[string]string `json:"templates,omitempty"` // Notification templates by channel name
+}
+
+type AlertState string
+
+const (
+	AlertInactive AlertState = "inactive"
+	AlertPending  AlertState = "pending"
+	AlertFiring   AlertState = "firing"
+	AlertResolved AlertState = "resolved"
+)
+
+// Labels added to every alert on top of the rule labels.
+const (
+	LabelRule       = "rule"
+	LabelMetric     = "metric"
+	LabelMetricType = "metric_type"
+	LabelSeverity   = "severity"
+)
+
+// Alert is the current state of a single alert rule.
+type Alert struct {
+	RuleID          string            `json:"rule_id"`
+	Metric          MetricID          `json:"metric"`
+	Labels          map[string]string `json:"labels"` // Rule and metric labels plus the rule, metric, metric_type and severity labels
+	State           AlertState        `json:"state"`
+	Value           float64           `json:"value"`
+	ActiveAt        *time.Time        `json:"active_at,omitempty"`
+	FiredAt         *time.Time        `json:"fired_at,omitempty"`
+	ResolvedAt      *time.Time        `json:"resolved_at,omitempty"`
+	LastEvaluatedAt time.Time         `json:"last_evaluated_at"`
+	Silenced        bool              `json:"silenced"`
+	Ack             *AlertAck         `json:"ack,omitempty"` // Cleared when the alert resolves
+}
+
+// AlertAck records who acknowledged a firing alert.
+type AlertAck struct {
+	By      string    `json:"by"`
+	Comment string    `json:"comment"`
+	At      time.Time `json:"at"`
+}
+
+// AlertEvent describes a single alert state transition.
+type AlertEvent struct {
+	Rule          *AlertRule `json:"rule"`
+	Alert         Alert      `json:"alert"`
+	PreviousState AlertState `json:"previous_state"`
+	Timestamp     time.Time  `json:"timestamp"`
+}
+
+// AlertTransition is an entry of the alert history log.
+type AlertTransition struct {
+	RuleID    string     `json:"rule_id"`
+	Metric    MetricID   `json:"metric"`
+	FromState AlertState `json:"from_state"`
+	ToState   AlertState `json:"to_state"`
+	Value     float64    `json:"value"`
+	Timestamp time.Time  `json:"timestamp"`
+}
+
+// AlertRouteMatch is a leaf of the notification routing tree an alert is delivered through.
+type AlertRouteMatch struct {
+	Path     string `json:"path"` // Route indexes from the root, e.g. "root.0.1"
+	Receiver string `json:"receiver"`
+}
+
+// AlertHistoryFilter selects a page of the alert history log. Zero values disable a filter.
+type AlertHistoryFilter struct {
+	RuleID string
+	Since  time.Time
+	Until  time.Time
+	Limit  int
+	Offset int
+}
@@ -0,0 +1,14 @@
+package types
+
+import "time"
+
+// AnomalyModel is the exponentially weighted moving mean and variance of a gauge
+// together with the z-scores of its most recent samples.
+type AnomalyModel struct {
+	Metric    MetricID  `json:"metric"`
+	Mean      float64   `json:"mean"`
+	Variance  float64   `json:"variance"`
+	Count     int64     `json:"count"`   // Samples the model has seen
+	ZScores   []float64 `json:"zscores"` // Oldest first
+	UpdatedAt time.Time `json:"updated_at"`
+}
@@ -0,0 +1,186 @@
+package types
+
+import (
+	"fmt"
+	"hash/fnv"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParseMetricName splits a metric ID written as name{label="value",...} into the name and the labels.
+// IDs without labels, or with a malformed label set, are returned unchanged with no labels.
+func ParseMetricName(id string) (string, map[string]string) {
+	open := strings.IndexByte(id, '{')
+	if open <= 0 || !strings.HasSuffix(id, "}") {
+		return id, nil
+	}
+
+	labels, err := parseLabels(id[open+1 : len(id)-1])
+	if err != nil {
+		return id, nil
+	}
+	return id[:open], labels
+}
+
+// FormatMetricName builds a metric ID from the name and the labels, sorting labels by name
+// so that the same label set always yields the same ID.
+func FormatMetricName(name string, labels map[string]string) string {
+	if len(labels) == 0 {
+		return name
+	}
+
+	names := make([]string, 0, len(labels))
+	for label := range labels {
+		names = append(names, label)
+	}
+	sort.Strings(names)
+
+	var b strings.Builder
+	b.WriteString(name)
+	b.WriteByte('{')
+	for i, label := range names {
+		if i > 0 {
+			b.WriteByte(',')
+		}
+		b.WriteString(label)
+		b.WriteByte('=')
+		b.WriteString(strconv.Quote(labels[label]))
+	}
+	b.WriteByte('}')
+	return b.String()
+}
+
+// parseLabels parses a comma separated list of label="value" pairs.
+func parseLabels(s string) (map[string]string, error) {
+	labels := make(map[string]string)
+	for s != "" {
+		eq := strings.IndexByte(s, '=')
+		if eq <= 0 {
+			return nil, fmt.Errorf("missing label value in %q", s)
+		}
+		name := strings.TrimSpace(s[:eq])
+
+		rest := strings.TrimLeft(s[eq+1:], " ")
+		quoted, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of label %s: %v", name, err)
+		}
+		value, err := strconv.Unquote(quoted)
+		if err != nil {
+			return nil, fmt.Errorf("invalid value of label %s: %v", name, err)
+		}
+		labels[name] = value
+
+		s = strings.TrimLeft(rest[len(quoted):], " ")
+		if s == "" {
+			break
+		}
+		if s[0] != ',' {
+			return nil, fmt.Errorf("expected ',' after label %s", name)
+		}
+		s = s[1:]
+	}
+	return labels, nil
+}
+
+// PrometheusNames returns the Prometheus metric name of every metric: the name part of the ID
+// with the characters Prometheus does not allow replaced. Names that only become equal once
+// sanitized (a.b and a_b) would merge different metrics into one series, so all but the one
+// needing no replacement get the hash of their original name appended. A counter sharing its
+// name with a gauge gets the conventional _total suffix so that every name has a single type.
+func PrometheusNames(metrics []*Metrics) map[MetricID]string {
+	ids := make([]string, 0, len(metrics))
+	for _, metric := range metrics {
+		name, _ := ParseMetricName(metric.ID)
+		ids = append(ids, name)
+	}
+	promNames := uniqueNames(ids, SanitizeMetricName)
+
+	gauges := make(map[string]bool)
+	for _, metric := range metrics {
+		if metric.Type == string(Gauge) {
+			name, _ := ParseMetricName(metric.ID)
+			gauges[promNames[name]] = true
+		}
+	}
+
+	names := make(map[MetricID]string, len(metrics))
+	for _, metric := range metrics {
+		id, _ := ParseMetricName(metric.ID)
+		name := promNames[id]
+		if metric.Type == string(Counter) && gauges[name] {
+			name += "_total"
+		}
+		names[MetricID{ID: metric.ID, Type: metric.Type}] = name
+	}
+	return names
+}
+
+// PrometheusLabelNames returns the Prometheus name of every label of a series, appending the
+// hash of the original name like PrometheusNames to the names that only become equal once
+// sanitized, so that no label overwrites another.
+func PrometheusLabelNames(labels map[string]string) map[string]string {
+	names := make([]string, 0, len(labels))
+	for name := range labels {
+		names = append(names, name)
+	}
+	return uniqueNames(names, SanitizeLabelName)
+}
+
+// uniqueNames sanitizes every name. When several names sanitize to the same name, all but the
+// one needing no replacement get the hash of their original name appended.
+func uniqueNames(names []string, sanitize func(string) string) map[string]string {
+	originals := make(map[string]map[string]bool) // Original names by sanitized name
+	for _, name := range names {
+		sanitized := sanitize(name)
+		if originals[sanitized] == nil {
+			originals[sanitized] = make(map[string]bool)
+		}
+		originals[sanitized][name] = true
+	}
+
+	result := make(map[string]string, len(names))
+	for _, name := range names {
+		sanitized := sanitize(name)
+		if len(originals[sanitized]) > 1 && name != sanitized {
+			hash := fnv.New32a()
+			hash.Write([]byte(name))
+			sanitized = fmt.Sprintf("%s_%08x", sanitized, hash.Sum32())
+		}
+		result[name] = sanitized
+	}
+	return result
+}
+
+// SanitizeMetricName replaces the characters Prometheus does not allow in metric names with underscores.
+func SanitizeMetricName(name string) string {
+	return sanitizeName(name, true)
+}
+
+// SanitizeLabelName replaces the characters Prometheus does not allow in label names with underscores.
+func SanitizeLabelName(name string) string {
+	return sanitizeName(name, false)
+}
+
+func sanitizeName(name string, allowColon bool) string {
+	if name == "" {
+		return "_"
+	}
+
+	var b strings.Builder
+	for i, c := range name {
+		valid := c == '_' || (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') ||
+			(allowColon && c == ':') || (i > 0 && c >= '0' && c <= '9')
+		switch {
+		case valid:
+			b.WriteRune(c)
+		case i == 0 && c >= '0' && c <= '9':
+			b.WriteByte('_')
+			b.WriteRune(c)
+		default:
+			b.WriteByte('_')
+		}
+	}
+	return b.String()
+}
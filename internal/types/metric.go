@@ -1,5 +1,7 @@
 package types
 
+import "time"
+
 type MType string
 
 const (
@@ -18,3 +20,17 @@ type Metrics struct {
 	Delta *int64   `json:"delta,omitempty"`
 	Value *float64 `json:"value,omitempty"`
 }
+
+// Sample is a single timestamped value of a metric.
+type Sample struct {
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
+
+// MetricSample is a timestamped value of a metric. Counters are sampled with their running total.
+type MetricSample struct {
+	ID        string    `json:"id"`
+	Type      string    `json:"type"`
+	Timestamp time.Time `json:"timestamp"`
+	Value     float64   `json:"value"`
+}
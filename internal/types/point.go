@@ -0,0 +1,18 @@
+package types
+
+// Point is a single value pushed by an external system (Prometheus, InfluxDB, Graphite, ...)
+// before it is translated into a metric update.
+type Point struct {
+	Name       string
+	Labels     map[string]string
+	Type       MType
+	Value      float64
+	Cumulative bool // Counter values are running totals rather than increments
+}
+
+// CounterTotal is the last running total pushed for a cumulative counter, which the
+// increments of the next pushes are computed against.
+type CounterTotal struct {
+	Metric MetricID `json:"metric"`
+	Total  int64    `json:"total"`
+}
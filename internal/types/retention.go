@@ -0,0 +1,18 @@
+package types
+
+import "time"
+
+// RetentionCutoff tells before which time the history of every metric expires.
+type RetentionCutoff struct {
+	Latest time.Time                   // No metric expires data taken at or after it
+	Before func(id MetricID) time.Time // Zero keeps the whole history of the metric
+}
+
+// CompactionStats describes a run of the compaction enforcing the retention.
+type CompactionStats struct {
+	StartedAt      time.Time        `json:"started_at"`
+	FinishedAt     time.Time        `json:"finished_at"`
+	SamplesDeleted int64            `json:"samples_deleted"`
+	RollupsDeleted map[string]int64 `json:"rollups_deleted"` // By resolution, e.g. "1m"
+	Error          string           `json:"error,omitempty"`
+}
@@ -0,0 +1,43 @@
+package types
+
+import "time"
+
+// MetricRollup aggregates the samples of a metric taken within one bucket of a resolution.
+// Counters are aggregated over their running totals and additionally carry the increase.
+type MetricRollup struct {
+	ID         string    `json:"id"`
+	Type       string    `json:"type"`
+	Resolution int64     `json:"resolution"` // Bucket length in seconds
+	Start      time.Time `json:"start"`
+	Min        float64   `json:"min"`
+	Max        float64   `json:"max"`
+	Avg        float64   `json:"avg"`
+	Sum        float64   `json:"sum"`
+	Last       float64   `json:"last"`
+	Count      int64     `json:"count"`
+	Increase   *float64  `json:"increase,omitempty"`
+}
+
+// End returns the end of the bucket of the rollup.
+func (r *MetricRollup) End() time.Time {
+	return r.Start.Add(time.Duration(r.Resolution) * time.Second)
+}
+
+// Value returns the value representing the bucket: the last total of counters, so that
+// increases can be computed across buckets, and the average of gauges.
+func (r *MetricRollup) Value() float64 {
+	if r.Type == string(Counter) {
+		return r.Last
+	}
+	return r.Avg
+}
+
+// MetricSeries is the history of a metric at a resolution. Samples are derived from the
+// rollups where the resolution is not raw (0) and taken from raw samples after the last rollup.
+type MetricSeries struct {
+	ID         string          `json:"id"`
+	Type       string          `json:"type"`
+	Resolution int64           `json:"resolution"` // Seconds, 0 for raw samples
+	Samples    []Sample        `json:"samples"`
+	Rollups    []*MetricRollup `json:"rollups,omitempty"`
+}
@@ -0,0 +1,26 @@
+package types
+
+import "time"
+
+// Silence mutes notifications of matching alerts. Empty matchers match everything.
+type Silence struct {
+	ID        string             `json:"id"`
+	RuleID    string             `json:"rule_id,omitempty"` // Glob on the rule ID
+	Metric    MetricID           `json:"metric"`            // Glob on the metric ID, empty type matches any type
+	Labels    map[string]string  `json:"labels,omitempty"`  // Rule labels that must be equal
+	StartsAt  time.Time          `json:"starts_at"`
+	EndsAt    *time.Time         `json:"ends_at,omitempty"` // Required unless a schedule is set
+	Schedule  *MaintenanceWindow `json:"schedule,omitempty"`
+	CreatedBy string             `json:"created_by"`
+	Comment   string             `json:"comment"`
+	CreatedAt time.Time          `json:"created_at"`
+}
+
+// MaintenanceWindow is a weekly recurring time range, e.g. every Sunday 02:00-04:00.
+// A window whose end is not after its start spans midnight.
+type MaintenanceWindow struct {
+	Weekdays []string `json:"weekdays"` // Lowercase English names, empty means every day
+	Start    string   `json:"start"`    // HH:MM
+	End      string   `json:"end"`      // HH:MM
+	Timezone string   `json:"timezone,omitempty"`
+}
@@ -0,0 +1,68 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"go-metrics-alerting/internal/types"
+	"time"
+)
+
+// AlertEvaluator defines the method used to evaluate alert rules.
+type AlertEvaluator interface {
+	EvaluateRules(ctx context.Context, now time.Time) ([]*types.AlertEvent, error)
+}
+
+// AlertDispatcher queues alert events for the notification worker.
+type AlertDispatcher interface {
+	EnqueueEvents(ctx context.Context, events []*types.AlertEvent, now time.Time) error
+}
+
+// AlertWorker periodically evaluates alert rules and hands the produced events to the dispatcher.
+type AlertWorker struct {
+	svc        AlertEvaluator
+	dispatcher AlertDispatcher
+	interval   time.Duration
+}
+
+// NewAlertWorker creates a new instance of AlertWorker.
+func NewAlertWorker(svc AlertEvaluator, dispatcher AlertDispatcher, interval time.Duration) *AlertWorker {
+	return &AlertWorker{
+		svc:        svc,
+		dispatcher: dispatcher,
+		interval:   interval,
+	}
+}
+
+// Start runs the evaluation loop until the context is canceled.
+func (w *AlertWorker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			w.evaluate(ctx, now)
+		}
+	}
+}
+
+// evaluate runs a single evaluation pass, logging failures instead of stopping the worker.
+// The events of a pass that partly failed are still dispatched since their transitions happened.
+func (w *AlertWorker) evaluate(ctx context.Context, now time.Time) {
+	events, err := w.svc.EvaluateRules(ctx, now)
+	if err != nil {
+		fmt.Printf("Error: Failed to evaluate alert rules: %v\n", err)
+	}
+
+	for _, event := range events {
+		fmt.Printf("Alert: rule=%s, metric=%s/%s, value=%f, state=%s -> %s, silenced=%t\n",
+			event.Alert.RuleID, event.Alert.Metric.Type, event.Alert.Metric.ID, event.Alert.Value,
+			event.PreviousState, event.Alert.State, event.Alert.Silenced)
+	}
+
+	if err := w.dispatcher.EnqueueEvents(ctx, events, now); err != nil {
+		fmt.Printf("Error: Failed to enqueue alert notifications: %v\n", err)
+	}
+}
@@ -0,0 +1,43 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// AnomalyModelSaver defines the method used to persist the anomaly models.
+type AnomalyModelSaver interface {
+	SaveAnomalyModels(ctx context.Context) error
+}
+
+// AnomalyModelWorker periodically persists the anomaly models so they survive restarts.
+type AnomalyModelWorker struct {
+	svc      AnomalyModelSaver
+	interval time.Duration
+}
+
+// NewAnomalyModelWorker creates a new instance of AnomalyModelWorker.
+func NewAnomalyModelWorker(svc AnomalyModelSaver, interval time.Duration) *AnomalyModelWorker {
+	return &AnomalyModelWorker{
+		svc:      svc,
+		interval: interval,
+	}
+}
+
+// Start saves the changed models on every tick until the context is canceled.
+func (w *AnomalyModelWorker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := w.svc.SaveAnomalyModels(ctx); err != nil {
+				fmt.Printf("Error: Failed to save anomaly models: %v\n", err)
+			}
+		}
+	}
+}
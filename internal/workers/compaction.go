@@ -0,0 +1,45 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// RetentionCompactor defines the method used to delete the expired metric history.
+type RetentionCompactor interface {
+	Compact(ctx context.Context, now time.Time) error
+}
+
+// CompactionWorker periodically enforces the retention of the metric history.
+type CompactionWorker struct {
+	svc      RetentionCompactor
+	interval time.Duration
+}
+
+// NewCompactionWorker creates a new instance of CompactionWorker.
+func NewCompactionWorker(svc RetentionCompactor, interval time.Duration) *CompactionWorker {
+	return &CompactionWorker{
+		svc:      svc,
+		interval: interval,
+	}
+}
+
+// Start compacts once right away and then on every tick until the context is canceled.
+func (w *CompactionWorker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	now := time.Now()
+	for {
+		if err := w.svc.Compact(ctx, now); err != nil {
+			fmt.Printf("Error: Failed to compact metric history: %v\n", err)
+		}
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case now = <-ticker.C:
+		}
+	}
+}
@@ -0,0 +1,48 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// NotificationSender sends the notifications and escalations that are due.
+type NotificationSender interface {
+	FlushNotifications(ctx context.Context, now time.Time) error
+	EscalateAlerts(ctx context.Context, now time.Time) error
+}
+
+// NotificationWorker delivers alert notifications apart from the rule evaluation,
+// so that slow or failing receivers never delay the alert worker.
+type NotificationWorker struct {
+	svc      NotificationSender
+	interval time.Duration
+}
+
+// NewNotificationWorker creates a new instance of NotificationWorker.
+func NewNotificationWorker(svc NotificationSender, interval time.Duration) *NotificationWorker {
+	return &NotificationWorker{
+		svc:      svc,
+		interval: interval,
+	}
+}
+
+// Start sends the due notifications on every tick until the context is canceled.
+func (w *NotificationWorker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			if err := w.svc.FlushNotifications(ctx, now); err != nil {
+				fmt.Printf("Error: Failed to send alert notifications: %v\n", err)
+			}
+			if err := w.svc.EscalateAlerts(ctx, now); err != nil {
+				fmt.Printf("Error: Failed to escalate alerts: %v\n", err)
+			}
+		}
+	}
+}
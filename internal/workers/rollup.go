@@ -0,0 +1,43 @@
+package workers
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// SampleRollupService defines the method used to aggregate the metric history.
+type SampleRollupService interface {
+	RollupSamples(ctx context.Context, now time.Time) error
+}
+
+// RollupWorker periodically aggregates raw samples into the rollup resolutions.
+type RollupWorker struct {
+	svc      SampleRollupService
+	interval time.Duration
+}
+
+// NewRollupWorker creates a new instance of RollupWorker.
+func NewRollupWorker(svc SampleRollupService, interval time.Duration) *RollupWorker {
+	return &RollupWorker{
+		svc:      svc,
+		interval: interval,
+	}
+}
+
+// Start rolls up the finished buckets on every tick until the context is canceled.
+func (w *RollupWorker) Start(ctx context.Context) error {
+	ticker := time.NewTicker(w.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case now := <-ticker.C:
+			if err := w.svc.RollupSamples(ctx, now); err != nil {
+				fmt.Printf("Error: Failed to roll up samples: %v\n", err)
+			}
+		}
+	}
+}